@@ -8,10 +8,16 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // user time zones must resolve without system zoneinfo
 
 	"github.com/user/go-todo-api/internal/config"
 	"github.com/user/go-todo-api/internal/database"
-	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/events"
+	"github.com/user/go-todo-api/internal/mailer"
+	"github.com/user/go-todo-api/internal/middleware"
+	"github.com/user/go-todo-api/internal/migrate"
+	"github.com/user/go-todo-api/internal/repository"
+	"github.com/user/go-todo-api/internal/repository/memory"
 	"github.com/user/go-todo-api/internal/routes"
 	"github.com/user/go-todo-api/internal/worker"
 )
@@ -40,25 +46,38 @@ func main() {
 	// Load configuration
 	config.LoadConfig()
 
-	// Connect to database
-	database.Connect()
+	// "api migrate ..." manages the schema and exits
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		database.Connect()
+		runMigrate(os.Args[2:])
+		return
+	}
+
+	// Initialize data access layer
+	repos := setupRepositories()
 
 	// Initialize background worker
-	worker.InitWorker()
-
-	// Auto-migrate database schema
-	err := database.DB.AutoMigrate(
-		&models.User{},
-		&models.Todo{},
-		&models.RefreshToken{},
-	)
-	if err != nil {
-		log.Fatalf("Failed to migrate database: %v", err)
-	}
-	log.Println("Database migration completed")
+	worker.InitWorker(repos, worker.Options{
+		Concurrency:  config.AppConfig.WorkerConcurrency,
+		MaxAttempts:  config.AppConfig.WorkerMaxAttempts,
+		PollInterval: config.AppConfig.WorkerPollInterval,
+		Mailer:       setupMailer(),
+
+		TrashRetention:        config.AppConfig.TrashRetention,
+		ArchiveCompletedAfter: config.AppConfig.ArchiveCompletedAfter,
+		JobRetention:          config.AppConfig.JobRetention,
+	})
+	events.Default.Subscribe(worker.GlobalWorker.DispatchWebhooks)
+
+	// Live updates: the last 100 events of each user can be resumed from
+	broker := events.NewBroker(100)
+	events.Default.Subscribe(broker.Publish)
+
+	// Rate limiting: 100 requests per minute per IP
+	limiter := middleware.NewRateLimiter(100, time.Minute)
 
 	// Setup router
-	router := routes.SetupRouter()
+	router := routes.SetupRouter(repos, limiter, broker)
 
 	// Create server with graceful shutdown
 	srv := &http.Server{
@@ -67,6 +86,9 @@ func main() {
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
 	}
+	// Event streams never finish on their own; end them so Shutdown
+	// does not wait for them
+	srv.RegisterOnShutdown(broker.Close)
 
 	// Start server in goroutine
 	go func() {
@@ -87,8 +109,68 @@ func main() {
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+		log.Printf("Server forced to shutdown: %v", err)
+	}
+
+	// Then let the worker finish the tasks those requests enqueued; any
+	// still running at the deadline are queued again for the next start
+	workerCtx, cancelWorker := context.WithTimeout(context.Background(), config.AppConfig.WorkerShutdownTimeout)
+	defer cancelWorker()
+
+	if err := worker.GlobalWorker.Stop(workerCtx); err != nil {
+		log.Printf("Worker stopped with tasks unfinished: %v", err)
 	}
+	limiter.Stop()
 
 	log.Println("Server exited gracefully")
 }
+
+// setupRepositories connects to the configured database and applies pending
+// migrations, or returns in-memory repositories when DB_DRIVER=memory
+func setupRepositories() repository.Repositories {
+	if config.AppConfig.DBDriver == database.DriverMemory {
+		log.Println("Using in-memory repositories; data is lost on restart")
+		return memory.NewRepositories()
+	}
+
+	database.Connect()
+
+	if config.AppConfig.AutoMigrate {
+		m, err := migrate.New(database.DB)
+		if err != nil {
+			log.Fatalf("Failed to load migrations: %v", err)
+		}
+		n, err := m.Up()
+		if err != nil {
+			log.Fatalf("Failed to migrate database: %v", err)
+		}
+		log.Printf("Database migration completed (%d applied)", n)
+	}
+
+	return repository.NewRepositories(database.DB)
+}
+
+// setupMailer returns the mailer selected by MAIL_DRIVER
+func setupMailer() mailer.Mailer {
+	cfg := config.AppConfig
+	switch cfg.MailDriver {
+	case "smtp":
+		log.Printf("Sending email through SMTP server %s:%d", cfg.SMTPHost, cfg.SMTPPort)
+		return mailer.NewSMTPMailer(mailer.SMTPConfig{
+			Host:       cfg.SMTPHost,
+			Port:       cfg.SMTPPort,
+			Username:   cfg.SMTPUsername,
+			Password:   cfg.SMTPPassword,
+			From:       cfg.MailFrom,
+			RequireTLS: cfg.SMTPRequireTLS,
+		})
+	case "outbox":
+		if cfg.MailOutboxDir != "" {
+			log.Printf("Writing email to outbox directory %s", cfg.MailOutboxDir)
+		}
+		return mailer.NewOutbox(cfg.MailOutboxDir, cfg.MailFrom)
+	default:
+		log.Fatalf("Unknown MAIL_DRIVER %q (want smtp or outbox)", cfg.MailDriver)
+		return nil
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/user/go-todo-api/internal/database"
+	"github.com/user/go-todo-api/internal/migrate"
+)
+
+const migrateUsage = `usage: api migrate <command>
+
+commands:
+  up          apply all pending migrations
+  down [N]    roll back the last N migrations (default 1)
+  status      list migrations and whether they are applied
+  to N        migrate up or down to version N (0 rolls back everything)`
+
+// runMigrate implements the "migrate" subcommand
+func runMigrate(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+
+	m, err := migrate.New(database.DB)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	switch args[0] {
+	case "up":
+		n, err := m.Up()
+		reportMigration(m, n, err)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps = parseMigrateArg(args[1])
+		}
+		n, err := m.Down(steps)
+		reportMigration(m, n, err)
+	case "to":
+		if len(args) < 2 {
+			fmt.Fprintln(os.Stderr, migrateUsage)
+			os.Exit(2)
+		}
+		n, err := m.To(parseMigrateArg(args[1]))
+		reportMigration(m, n, err)
+	case "status":
+		printMigrationStatus(m)
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+}
+
+func parseMigrateArg(arg string) int {
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 0 {
+		log.Fatalf("Invalid migration number %q", arg)
+	}
+	return n
+}
+
+func reportMigration(m *migrate.Migrator, count int, err error) {
+	if err != nil {
+		log.Fatalf("Migration failed after %d step(s): %v", count, err)
+	}
+	version, err := m.Version()
+	if err != nil {
+		log.Fatalf("Failed to read schema version: %v", err)
+	}
+	log.Printf("Ran %d migration(s); schema is at version %d", count, version)
+}
+
+func printMigrationStatus(m *migrate.Migrator) {
+	statuses, err := m.Status()
+	if err != nil {
+		log.Fatalf("Failed to read migration status: %v", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, s := range statuses {
+		state, appliedAt := "pending", "-"
+		if s.Applied {
+			state, appliedAt = "applied", s.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", s.Version, s.Name, state, appliedAt)
+	}
+	w.Flush()
+}
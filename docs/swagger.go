@@ -42,6 +42,18 @@ type UserDoc struct {
 	// example: John Doe
 	Name string `json:"name"`
 
+	// IANA time zone that reminders and quiet hours use
+	// example: Europe/Berlin
+	Timezone string `json:"timezone"`
+
+	// Start of the reminder quiet hours, local HH:MM
+	// example: 22:00
+	QuietHoursStart string `json:"quiet_hours_start,omitempty"`
+
+	// End of the reminder quiet hours, local HH:MM
+	// example: 07:00
+	QuietHoursEnd string `json:"quiet_hours_end,omitempty"`
+
 	// Created timestamp
 	// example: 2024-01-01T00:00:00Z
 	CreatedAt string `json:"created_at"`
@@ -68,15 +80,229 @@ type TodoDoc struct {
 	// example: pending
 	Status string `json:"status"`
 
+	// Todo priority
+	// enum: none,low,medium,high,urgent
+	// example: high
+	Priority string `json:"priority"`
+
 	// Due date
 	// example: 2024-12-31T23:59:59Z
 	DueDate *string `json:"due_date"`
 
+	// Minutes before the due date to send reminders, earliest first
+	// example: [1440, 15]
+	ReminderOffsets []int `json:"reminder_offsets"`
+
+	// Tags attached to the todo, ordered by name
+	Tags []TagDoc `json:"tags"`
+
+	// Checklist items in display order
+	Items []ChecklistItemDoc `json:"items"`
+
+	// Completed checklist items out of the total
+	Progress ProgressDoc `json:"progress"`
+
+	// Complete the todo automatically once every checklist item is done
+	AutoComplete bool `json:"auto_complete"`
+
+	// Left out of GET /api/todos unless include_archived is set
+	Archived bool `json:"archived"`
+
+	// Listed before the todos that are not pinned
+	Pinned bool `json:"pinned"`
+
+	// Recurrence rule of the todo's series (only present for recurring todos)
+	// example: FREQ=WEEKLY;BYDAY=MO
+	RRule string `json:"rrule,omitempty"`
+
+	// Series shared by every instance of a recurring todo
+	// example: 1
+	SeriesID *uint `json:"series_id,omitempty"`
+
+	// Project the todo belongs to (only present for todos in a project)
+	// example: 1
+	ProjectID *uint `json:"project_id,omitempty"`
+
+	// User the todo is assigned to (only present for assigned todos)
+	// example: 2
+	AssigneeID *uint `json:"assignee_id,omitempty"`
+
+	// When the todo was completed (only present for completed todos)
+	CompletedAt string `json:"completed_at,omitempty"`
+
+	// Incremented by every update; part of the ETag
+	// example: 3
+	Version uint `json:"version"`
+
+	// Search snippet with matches wrapped in <mark> (only present for searches)
+	// example: Buy <mark>milk</mark>
+	Highlight string `json:"highlight,omitempty"`
+
 	// Created timestamp
 	CreatedAt string `json:"created_at"`
 
 	// Updated timestamp
 	UpdatedAt string `json:"updated_at"`
+
+	// When the todo was moved to the trash (only present for deleted todos)
+	DeletedAt string `json:"deleted_at,omitempty"`
+}
+
+// swagger:model Tag
+type TagDoc struct {
+	// Tag ID
+	// required: true
+	// example: 1
+	ID uint `json:"id"`
+
+	// Tag name, unique per user
+	// required: true
+	// example: work
+	Name string `json:"name"`
+
+	// Hex color code
+	// example: #6b7280
+	Color string `json:"color"`
+}
+
+// swagger:model Project
+type ProjectDoc struct {
+	// Project ID
+	// required: true
+	// example: 1
+	ID uint `json:"id"`
+
+	// ID of the user who created and owns the project
+	// example: 1
+	OwnerID uint `json:"owner_id"`
+
+	// The current user's role: owner, editor or viewer
+	// example: owner
+	Role string `json:"role"`
+
+	// Project name, unique per user
+	// required: true
+	// example: Release 2.0
+	Name string `json:"name"`
+
+	// Hex color code
+	// example: #3b82f6
+	Color string `json:"color"`
+
+	// Project description
+	Description string `json:"description"`
+
+	// Place in the user's project list
+	// example: 0
+	Position int `json:"position"`
+
+	// Left out of GET /api/projects unless include_archived is set
+	Archived bool `json:"archived"`
+
+	// The project's todos by status, archived todos excluded
+	Counts StatusCountsDoc `json:"counts"`
+
+	// Created timestamp
+	CreatedAt string `json:"created_at"`
+
+	// Updated timestamp
+	UpdatedAt string `json:"updated_at"`
+}
+
+// swagger:model StatusCounts
+type StatusCountsDoc struct {
+	// example: 3
+	Pending int `json:"pending"`
+
+	// example: 1
+	InProgress int `json:"in_progress"`
+
+	// example: 5
+	Completed int `json:"completed"`
+
+	// example: 9
+	Total int `json:"total"`
+}
+
+// swagger:model ChecklistItem
+type ChecklistItemDoc struct {
+	// Item ID
+	// required: true
+	// example: 1
+	ID uint `json:"id"`
+
+	// Item title
+	// required: true
+	// example: Pack boxes
+	Title string `json:"title"`
+
+	// Whether the item is done
+	Done bool `json:"done"`
+
+	// Zero-based position in the checklist
+	// example: 0
+	Position int `json:"position"`
+}
+
+// swagger:model Progress
+type ProgressDoc struct {
+	// Number of done items
+	// example: 2
+	Done int `json:"done"`
+
+	// Number of items
+	// example: 5
+	Total int `json:"total"`
+}
+
+// swagger:model Webhook
+type WebhookDoc struct {
+	// Webhook ID
+	// required: true
+	// example: 1
+	ID uint `json:"id"`
+
+	// URL the events are POSTed to
+	// required: true
+	// example: https://example.com/hooks/todo
+	URL string `json:"url"`
+
+	// Subscribed events: todo.created, todo.updated, todo.completed, todo.deleted, todo.restored
+	// example: ["todo.completed"]
+	Events []string `json:"events"`
+
+	// Whether events are delivered
+	Active bool `json:"active"`
+
+	// Signing secret, only returned on creation
+	// example: whsec_9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822c
+	Secret string `json:"secret,omitempty"`
+}
+
+// swagger:model WebhookDelivery
+type WebhookDeliveryDoc struct {
+	// Delivery ID
+	// required: true
+	// example: 1
+	ID uint `json:"id"`
+
+	// ID of the delivered event, also sent as X-Todo-Delivery
+	// example: evt_4f1c2b9e8a7d6c5b4a3f2e1d
+	EventID string `json:"event_id"`
+
+	// Event type
+	// example: todo.completed
+	Event string `json:"event"`
+
+	// HTTP status of the response, 0 when none was received
+	// example: 200
+	StatusCode int `json:"status_code"`
+
+	// Whether the endpoint answered with a 2xx status
+	Success bool `json:"success"`
+
+	// Error of a failed attempt
+	Error string `json:"error,omitempty"`
 }
 
 // swagger:model TokenPair
@@ -140,9 +366,146 @@ type CreateTodoRequestDoc struct {
 	// enum: pending,in_progress,completed
 	Status string `json:"status"`
 
+	// Todo priority, defaults to none
+	// enum: none,low,medium,high,urgent
+	Priority string `json:"priority"`
+
 	// Due date in RFC3339 format
 	// example: 2024-12-31T23:59:59Z
 	DueDate *string `json:"due_date"`
+
+	// Minutes before the due date to send reminders, earliest first
+	// example: [1440, 15]
+	ReminderOffsets []int `json:"reminder_offsets"`
+
+	// IDs of the user's tags to attach
+	// example: [1, 2]
+	TagIDs []uint `json:"tag_ids"`
+
+	// Complete the todo automatically once every checklist item is done
+	AutoComplete bool `json:"auto_complete"`
+
+	// RFC 5545 recurrence rule; requires due_date
+	// example: FREQ=WEEKLY;BYDAY=MO
+	RRule string `json:"rrule"`
+
+	// ID of the user's project to put the todo in
+	// example: 1
+	ProjectID *uint `json:"project_id"`
+}
+
+// swagger:model BulkTodoOperation
+type BulkTodoOperationDoc struct {
+	// Operation to apply
+	// required: true
+	// enum: create,update_status,delete,shift_due_date
+	Op string `json:"op"`
+
+	// Todo to change; not used by create
+	// example: 1
+	ID uint `json:"id"`
+
+	// Todo to create (create only)
+	Todo *CreateTodoRequestDoc `json:"todo"`
+
+	// New status (update_status only)
+	// enum: pending,in_progress,completed
+	Status string `json:"status"`
+
+	// Minutes to move the due date by, negative for earlier (shift_due_date only)
+	// example: 1440
+	Minutes int `json:"minutes"`
+}
+
+// swagger:model BulkTodoResult
+type BulkTodoResultDoc struct {
+	// Position of the operation in the request
+	// example: 0
+	Index int `json:"index"`
+
+	// Operation applied
+	// example: update_status
+	Op string `json:"op"`
+
+	// ID of the todo
+	// example: 1
+	ID uint `json:"id,omitempty"`
+
+	// Outcome; rolled_back when an atomic batch failed elsewhere
+	// enum: ok,failed,rolled_back
+	Result string `json:"result"`
+
+	// Why the operation failed
+	Error string `json:"error,omitempty"`
+
+	// The todo as the operation left it
+	Todo *TodoDoc `json:"todo,omitempty"`
+}
+
+// swagger:model CreateTagRequest
+type CreateTagRequestDoc struct {
+	// Tag name (max 50 characters)
+	// required: true
+	// example: work
+	Name string `json:"name"`
+
+	// Hex color code, defaults to #6b7280
+	// example: #3b82f6
+	Color string `json:"color"`
+}
+
+// swagger:model ProjectMember
+type ProjectMemberDoc struct {
+	// User ID
+	// required: true
+	// example: 2
+	UserID uint `json:"user_id"`
+
+	// User name
+	// example: Jane Doe
+	Name string `json:"name"`
+
+	// User email
+	// example: jane@example.com
+	Email string `json:"email"`
+
+	// owner, editor or viewer
+	// example: editor
+	Role string `json:"role"`
+
+	// Set for the owner who created the project, who cannot be removed
+	Creator bool `json:"creator"`
+
+	// When the user got access
+	JoinedAt string `json:"joined_at"`
+}
+
+// swagger:model AddProjectMemberRequest
+type AddProjectMemberRequestDoc struct {
+	// Email of a registered user
+	// required: true
+	// example: jane@example.com
+	Email string `json:"email"`
+
+	// owner, editor or viewer
+	// required: true
+	// example: editor
+	Role string `json:"role"`
+}
+
+// swagger:model CreateProjectRequest
+type CreateProjectRequestDoc struct {
+	// Project name (max 100 characters)
+	// required: true
+	// example: Release 2.0
+	Name string `json:"name"`
+
+	// Hex color code, defaults to #3b82f6
+	// example: #22c55e
+	Color string `json:"color"`
+
+	// Project description (max 1000 characters)
+	Description string `json:"description"`
 }
 
 // swagger:model APIResponse
@@ -216,8 +579,16 @@ type PaginatedTodosDoc struct {
 // - page_size: Items per page (default: 10, max: 100)
 // - status: Filter by status (pending, in_progress, completed)
 // - search: Search in title and description
-// - sort_by: Sort field (created_at, updated_at, title, status, due_date)
+// - tags: Comma-separated tag IDs
+// - tag_mode: any (default) or all of the given tags
+// - priority_min, priority_max: Priority range (none, low, medium, high, urgent)
+// - sort_by: Sort field (smart, created_at, updated_at, title, status, priority, due_date)
 // - sort_dir: Sort direction (ASC, DESC)
+// - include_archived: Also list archived todos
+// - project_id: Project ID, or none for todos without a project
+// - assigned_to_me: Only list todos assigned to the current user
+//
+// Pinned todos are listed first whatever the sort order.
 //
 // security:
 // - Bearer: []
@@ -235,6 +606,16 @@ type PaginatedTodosDoc struct {
 //   400: errorResponse
 //   401: errorResponse
 
+// swagger:route POST /api/todos/bulk Todos bulkTodos
+// Create, update the status of, delete or shift the due date of many todos in one transaction
+//
+// security:
+// - Bearer: []
+// responses:
+//   200: bulkTodosResponse
+//   400: bulkTodosResponse
+//   401: errorResponse
+
 // swagger:route GET /api/todos/{id} Todos getTodo
 // Get a single todo by ID
 //
@@ -246,7 +627,7 @@ type PaginatedTodosDoc struct {
 //   404: errorResponse
 
 // swagger:route PUT /api/todos/{id} Todos updateTodo
-// Update an existing todo
+// Replace an existing todo; omitted fields take their defaults
 //
 // security:
 // - Bearer: []
@@ -256,8 +637,400 @@ type PaginatedTodosDoc struct {
 //   401: errorResponse
 //   404: errorResponse
 
+// swagger:route PATCH /api/todos/{id} Todos patchTodo
+// Change some fields of a todo with a JSON merge patch or JSON Patch
+//
+// consumes:
+// - application/merge-patch+json
+// - application/json-patch+json
+// security:
+// - Bearer: []
+// responses:
+//   200: todoResponse
+//   400: errorResponse
+//   401: errorResponse
+//   404: errorResponse
+//   409: errorResponse
+//   415: errorResponse
+
+// swagger:route GET /api/todos/{id}/occurrences Todos getOccurrences
+// Preview the upcoming occurrences of a recurring todo
+//
+// security:
+// - Bearer: []
+// responses:
+//   200: occurrencesResponse
+//   400: errorResponse
+//   401: errorResponse
+//   404: errorResponse
+
 // swagger:route DELETE /api/todos/{id} Todos deleteTodo
-// Delete a todo
+// Move a todo to the trash, or delete it for good with permanent=true
+//
+// security:
+// - Bearer: []
+// responses:
+//   200: successResponse
+//   400: errorResponse
+//   401: errorResponse
+//   404: errorResponse
+//   412: errorResponse
+
+// swagger:route POST /api/todos/{id}/archive Todos archiveTodo
+// Leave a todo out of the default listing
+//
+// security:
+// - Bearer: []
+// responses:
+//   200: todoResponse
+//   400: errorResponse
+//   401: errorResponse
+//   404: errorResponse
+//   412: errorResponse
+
+// swagger:route POST /api/todos/{id}/unarchive Todos unarchiveTodo
+// Bring an archived todo back to the default listing
+//
+// security:
+// - Bearer: []
+// responses:
+//   200: todoResponse
+//   400: errorResponse
+//   401: errorResponse
+//   404: errorResponse
+//   412: errorResponse
+
+// swagger:route POST /api/todos/{id}/pin Todos pinTodo
+// List a todo ahead of the others
+//
+// security:
+// - Bearer: []
+// responses:
+//   200: todoResponse
+//   400: errorResponse
+//   401: errorResponse
+//   404: errorResponse
+//   412: errorResponse
+
+// swagger:route POST /api/todos/{id}/unpin Todos unpinTodo
+// Return a pinned todo to its place in the sort order
+//
+// security:
+// - Bearer: []
+// responses:
+//   200: todoResponse
+//   400: errorResponse
+//   401: errorResponse
+//   404: errorResponse
+//   412: errorResponse
+
+// swagger:route POST /api/todos/{id}/assign Todos assignTodo
+// Assign a todo to its creator or a member of its project, who is emailed
+//
+// security:
+// - Bearer: []
+// responses:
+//   200: todoResponse
+//   400: errorResponse
+//   401: errorResponse
+//   403: errorResponse
+//   404: errorResponse
+//   412: errorResponse
+
+// swagger:route POST /api/todos/{id}/unassign Todos unassignTodo
+// Remove a todo's assignee
+//
+// security:
+// - Bearer: []
+// responses:
+//   200: todoResponse
+//   400: errorResponse
+//   401: errorResponse
+//   403: errorResponse
+//   404: errorResponse
+//   412: errorResponse
+
+// swagger:route GET /api/todos/trash Todos getTrash
+// List the todos in the trash, most recently deleted first
+//
+// security:
+// - Bearer: []
+// responses:
+//   200: todosResponse
+//   401: errorResponse
+
+// swagger:route POST /api/todos/{id}/restore Todos restoreTodo
+// Take a todo out of the trash
+//
+// security:
+// - Bearer: []
+// responses:
+//   200: todoResponse
+//   400: errorResponse
+//   401: errorResponse
+//   404: errorResponse
+
+// swagger:route GET /api/tags Tags getTags
+// Get all tags of the current user
+//
+// security:
+// - Bearer: []
+// responses:
+//   200: tagsResponse
+//   401: errorResponse
+
+// swagger:route POST /api/tags Tags createTag
+// Create a new tag
+//
+// security:
+// - Bearer: []
+// responses:
+//   201: tagResponse
+//   400: errorResponse
+//   401: errorResponse
+//   409: errorResponse
+
+// swagger:route GET /api/tags/{id} Tags getTag
+// Get a single tag by ID
+//
+// security:
+// - Bearer: []
+// responses:
+//   200: tagResponse
+//   401: errorResponse
+//   404: errorResponse
+
+// swagger:route PUT /api/tags/{id} Tags updateTag
+// Rename or recolor a tag
+//
+// security:
+// - Bearer: []
+// responses:
+//   200: tagResponse
+//   400: errorResponse
+//   401: errorResponse
+//   404: errorResponse
+//   409: errorResponse
+
+// swagger:route DELETE /api/tags/{id} Tags deleteTag
+// Delete a tag and detach it from all todos
+//
+// security:
+// - Bearer: []
+// responses:
+//   200: successResponse
+//   401: errorResponse
+//   404: errorResponse
+
+// swagger:route GET /api/projects Projects getProjects
+// Get the projects of the current user in order, then those shared with them, with their todos counted by status
+//
+// Query parameters:
+// - include_archived: Also list archived projects
+//
+// security:
+// - Bearer: []
+// responses:
+//   200: projectsResponse
+//   400: errorResponse
+//   401: errorResponse
+
+// swagger:route POST /api/projects Projects createProject
+// Create a new project after the existing ones
+//
+// security:
+// - Bearer: []
+// responses:
+//   201: projectResponse
+//   400: errorResponse
+//   401: errorResponse
+//   409: errorResponse
+
+// swagger:route PUT /api/projects/order Projects reorderProjects
+// Reorder the projects the current user created; every one of them must be listed once
+//
+// security:
+// - Bearer: []
+// responses:
+//   200: projectsResponse
+//   400: errorResponse
+//   401: errorResponse
+
+// swagger:route GET /api/projects/{id} Projects getProject
+// Get a single project by ID
+//
+// security:
+// - Bearer: []
+// responses:
+//   200: projectResponse
+//   401: errorResponse
+//   404: errorResponse
+
+// swagger:route PUT /api/projects/{id} Projects updateProject
+// Rename, recolor, describe or archive a project (owners only)
+//
+// security:
+// - Bearer: []
+// responses:
+//   200: projectResponse
+//   400: errorResponse
+//   401: errorResponse
+//   403: errorResponse
+//   404: errorResponse
+//   409: errorResponse
+
+// swagger:route DELETE /api/projects/{id} Projects deleteProject
+// Delete a project (owners only); its todos are kept without a project
+//
+// security:
+// - Bearer: []
+// responses:
+//   200: successResponse
+//   401: errorResponse
+//   403: errorResponse
+//   404: errorResponse
+
+// swagger:route GET /api/projects/{id}/members Projects getProjectMembers
+// List the project's creator and members
+//
+// security:
+// - Bearer: []
+// responses:
+//   200: projectMembersResponse
+//   401: errorResponse
+//   404: errorResponse
+
+// swagger:route POST /api/projects/{id}/members Projects addProjectMember
+// Share a project with a registered user by email (owners only)
+//
+// security:
+// - Bearer: []
+// responses:
+//   201: projectMembersResponse
+//   400: errorResponse
+//   401: errorResponse
+//   403: errorResponse
+//   404: errorResponse
+//   409: errorResponse
+
+// swagger:route PUT /api/projects/{id}/members/{user_id} Projects updateProjectMember
+// Change a member's role (owners only)
+//
+// security:
+// - Bearer: []
+// responses:
+//   200: projectMembersResponse
+//   400: errorResponse
+//   401: errorResponse
+//   403: errorResponse
+//   404: errorResponse
+
+// swagger:route DELETE /api/projects/{id}/members/{user_id} Projects removeProjectMember
+// Remove a member (owners), or leave a project (members)
+//
+// security:
+// - Bearer: []
+// responses:
+//   200: successResponse
+//   400: errorResponse
+//   401: errorResponse
+//   403: errorResponse
+//   404: errorResponse
+
+// swagger:route GET /api/todos/{id}/items Checklist getChecklist
+// Get the checklist items of a todo
+//
+// security:
+// - Bearer: []
+// responses:
+//   200: checklistResponse
+//   401: errorResponse
+//   404: errorResponse
+
+// swagger:route POST /api/todos/{id}/items Checklist createChecklistItem
+// Append a checklist item to a todo
+//
+// security:
+// - Bearer: []
+// responses:
+//   201: todoResponse
+//   400: errorResponse
+//   401: errorResponse
+//   404: errorResponse
+
+// swagger:route PUT /api/todos/{id}/items/order Checklist reorderChecklist
+// Reorder the checklist of a todo
+//
+// security:
+// - Bearer: []
+// responses:
+//   200: todoResponse
+//   400: errorResponse
+//   401: errorResponse
+//   404: errorResponse
+
+// swagger:route POST /api/todos/{id}/items/{item_id}/toggle Checklist toggleChecklistItem
+// Mark a checklist item done or not done
+//
+// security:
+// - Bearer: []
+// responses:
+//   200: todoResponse
+//   401: errorResponse
+//   404: errorResponse
+
+// swagger:route DELETE /api/todos/{id}/items/{item_id} Checklist deleteChecklistItem
+// Delete a checklist item
+//
+// security:
+// - Bearer: []
+// responses:
+//   200: todoResponse
+//   401: errorResponse
+//   404: errorResponse
+
+// swagger:route GET /api/webhooks Webhooks getWebhooks
+// Get all webhooks of the current user
+//
+// security:
+// - Bearer: []
+// responses:
+//   200: webhooksResponse
+//   401: errorResponse
+
+// swagger:route POST /api/webhooks Webhooks createWebhook
+// Subscribe a URL to todo events
+//
+// security:
+// - Bearer: []
+// responses:
+//   201: webhookResponse
+//   400: errorResponse
+//   401: errorResponse
+
+// swagger:route GET /api/webhooks/{id} Webhooks getWebhook
+// Get a single webhook by ID
+//
+// security:
+// - Bearer: []
+// responses:
+//   200: webhookResponse
+//   401: errorResponse
+//   404: errorResponse
+
+// swagger:route PUT /api/webhooks/{id} Webhooks updateWebhook
+// Change the URL, events, secret or active flag of a webhook
+//
+// security:
+// - Bearer: []
+// responses:
+//   200: webhookResponse
+//   400: errorResponse
+//   401: errorResponse
+//   404: errorResponse
+
+// swagger:route DELETE /api/webhooks/{id} Webhooks deleteWebhook
+// Delete a webhook and its delivery log
 //
 // security:
 // - Bearer: []
@@ -265,3 +1038,44 @@ type PaginatedTodosDoc struct {
 //   200: successResponse
 //   401: errorResponse
 //   404: errorResponse
+
+// swagger:route GET /api/webhooks/{id}/deliveries Webhooks getWebhookDeliveries
+// Get the latest delivery attempts of a webhook
+//
+// security:
+// - Bearer: []
+// responses:
+//   200: webhookDeliveriesResponse
+//   400: errorResponse
+//   401: errorResponse
+//   404: errorResponse
+
+// swagger:route POST /api/webhooks/{id}/test Webhooks testWebhook
+// Queue a ping event for a webhook
+//
+// security:
+// - Bearer: []
+// responses:
+//   202: successResponse
+//   401: errorResponse
+//   404: errorResponse
+
+// swagger:route GET /api/stream Stream streamEvents
+// Stream the current user's todo events as Server-Sent Events
+//
+// produces:
+// - text/event-stream
+// security:
+// - Bearer: []
+// responses:
+//   200: eventStream
+//   401: errorResponse
+
+// swagger:route GET /api/stream/ws Stream streamEventsWebSocket
+// Stream the current user's todo events over a WebSocket
+//
+// security:
+// - Bearer: []
+// responses:
+//   101: eventStream
+//   401: errorResponse
@@ -0,0 +1,139 @@
+// Package access decides what a user may do with todos and projects. Users
+// own the projects they create and the todos they create outside projects;
+// a todo in a project gives everyone their role on the project, its creator
+// included, so whoever leaves the project loses the todos they added to it.
+package access
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository"
+)
+
+// ErrForbidden is returned when the user can see a todo or project but
+// their role does not allow the action
+var ErrForbidden = errors.New("insufficient project role")
+
+// Checker resolves the role a user holds on todos and projects
+type Checker struct {
+	todos    repository.TodoRepository
+	projects repository.ProjectRepository
+}
+
+func NewChecker(todos repository.TodoRepository, projects repository.ProjectRepository) *Checker {
+	return &Checker{todos: todos, projects: projects}
+}
+
+// Todo loads a todo and checks that userID holds at least need on it. It
+// returns repository.ErrNotFound when the user cannot see the todo at all,
+// so its existence is not revealed, and ErrForbidden when their role is
+// too low.
+func (c *Checker) Todo(id, userID uint, need models.ProjectRole) (*models.Todo, error) {
+	todo, err := c.todos.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.checkTodo(todo, userID, need); err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+// DeletedTodo loads a todo from the trash and checks that userID holds at
+// least need on it, as Todo does for the others
+func (c *Checker) DeletedTodo(id, userID uint, need models.ProjectRole) (*models.Todo, error) {
+	todo, err := c.todos.FindDeletedByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.checkTodo(todo, userID, need); err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+func (c *Checker) checkTodo(todo *models.Todo, userID uint, need models.ProjectRole) error {
+	if todo.ProjectID == nil {
+		if todo.UserID != userID {
+			return repository.ErrNotFound
+		}
+		return check(models.RoleOwner, need)
+	}
+	project, err := c.projects.FindByID(*todo.ProjectID)
+	if err != nil {
+		return err
+	}
+	role, err := c.Role(project, userID)
+	if err != nil {
+		return err
+	}
+	return check(role, need)
+}
+
+// Project loads a project and checks that userID holds at least need on it,
+// returning the role they hold. Errors are as for Todo.
+func (c *Checker) Project(id, userID uint, need models.ProjectRole) (*models.Project, models.ProjectRole, error) {
+	project, err := c.projects.FindByID(id)
+	if err != nil {
+		return nil, "", err
+	}
+	role, err := c.Role(project, userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := check(role, need); err != nil {
+		return nil, "", err
+	}
+	return project, role, nil
+}
+
+// Role returns the role userID holds on project, or "" when it is not
+// shared with them
+func (c *Checker) Role(project *models.Project, userID uint) (models.ProjectRole, error) {
+	if project.UserID == userID {
+		return models.RoleOwner, nil
+	}
+	member, err := c.projects.FindMember(project.ID, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return member.Role, nil
+}
+
+// Audience returns the users who can see todo: the owner and members of
+// its project, or its creator when it is not in one
+func (c *Checker) Audience(todo *models.Todo) ([]uint, error) {
+	if todo.ProjectID == nil {
+		return []uint{todo.UserID}, nil
+	}
+	project, err := c.projects.FindByID(*todo.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	users := []uint{project.UserID}
+	members, err := c.projects.FindMembers(project.ID)
+	if err != nil {
+		return users, err
+	}
+	for _, member := range members {
+		if !slices.Contains(users, member.UserID) {
+			users = append(users, member.UserID)
+		}
+	}
+	return users, nil
+}
+
+func check(role, need models.ProjectRole) error {
+	if !role.Valid() {
+		return repository.ErrNotFound
+	}
+	if !role.Allows(need) {
+		return ErrForbidden
+	}
+	return nil
+}
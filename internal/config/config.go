@@ -4,36 +4,124 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
 	Port           string
-	DBPath         string // New field for SQLite
 	JWTSecret      string
 	JWTExpiryHours int
+
+	// Database settings
+	DBDriver          string // "sqlite" or "postgres"
+	DBPath            string // SQLite database file
+	DBURL             string // Full Postgres DSN, overrides the individual fields below
+	DBHost            string
+	DBPort            string
+	DBUser            string
+	DBPassword        string
+	DBName            string
+	DBSSLMode         string
+	DBMaxOpenConns    int
+	DBMaxIdleConns    int
+	DBConnMaxLifetime time.Duration
+	DBConnMaxIdleTime time.Duration
+	AutoMigrate       bool // apply pending migrations on startup
+
+	// Background worker settings
+	WorkerConcurrency  int
+	WorkerMaxAttempts  int
+	WorkerPollInterval time.Duration
+	// WorkerShutdownTimeout bounds how long shutdown waits for running
+	// tasks before queuing them again
+	WorkerShutdownTimeout time.Duration
+	// TrashRetention is how long deleted todos stay in the trash before
+	// the worker purges them
+	TrashRetention time.Duration
+	// ArchiveCompletedAfter is how long after their completion the worker
+	// archives todos; zero turns auto-archiving off
+	ArchiveCompletedAfter time.Duration
+	// JobRetention is how long the worker keeps done tasks before
+	// deleting them
+	JobRetention time.Duration
+
+	// IdempotencyTTL is how long responses to requests with an
+	// Idempotency-Key header are kept for retries
+	IdempotencyTTL time.Duration
+
+	// Email settings
+	MailDriver     string // "smtp" or "outbox"
+	MailFrom       string // sender, e.g. "Todo API <no-reply@example.com>"
+	MailOutboxDir  string // outbox driver: directory for .eml files; logged only when empty
+	SMTPHost       string
+	SMTPPort       int
+	SMTPUsername   string
+	SMTPPassword   string
+	SMTPRequireTLS bool // refuse servers without STARTTLS; disable for local sinks
 }
 
 var AppConfig *Config
 
+// LoadConfig reads the environment and stores the result in AppConfig
 func LoadConfig() {
+	AppConfig = Load()
+
+	log.Printf("Configuration loaded: Port=%s, DBDriver=%s", AppConfig.Port, AppConfig.DBDriver)
+}
+
+// Load reads configuration from the environment (and an optional .env file)
+func Load() *Config {
 	// Load .env file (ignore error if not present)
 	_ = godotenv.Load()
 
-	jwtExpiry, err := strconv.Atoi(getEnv("JWT_EXPIRY_HOURS", "24"))
-	if err != nil {
-		jwtExpiry = 24
-	}
+	return &Config{
+		Port:              getEnv("PORT", "8080"),
+		JWTSecret:         getEnv("JWT_SECRET", "default-secret-change-me"),
+		JWTExpiryHours:    getEnvInt("JWT_EXPIRY_HOURS", 24),
+		DBDriver:          getEnv("DB_DRIVER", defaultDriver()),
+		DBPath:            getEnv("DB_PATH", "todo.db"),
+		DBURL:             getEnv("DATABASE_URL", ""),
+		DBHost:            getEnv("DB_HOST", "localhost"),
+		DBPort:            getEnv("DB_PORT", "5432"),
+		DBUser:            getEnv("DB_USER", "postgres"),
+		DBPassword:        getEnv("DB_PASSWORD", ""),
+		DBName:            getEnv("DB_NAME", "todo_db"),
+		DBSSLMode:         getEnv("DB_SSLMODE", "disable"),
+		DBMaxOpenConns:    getEnvInt("DB_MAX_OPEN_CONNS", 25),
+		DBMaxIdleConns:    getEnvInt("DB_MAX_IDLE_CONNS", 5),
+		DBConnMaxLifetime: getEnvDuration("DB_CONN_MAX_LIFETIME", 30*time.Minute),
+		DBConnMaxIdleTime: getEnvDuration("DB_CONN_MAX_IDLE_TIME", 5*time.Minute),
+		AutoMigrate:       getEnv("AUTO_MIGRATE", "true") == "true",
+
+		WorkerConcurrency:     getEnvInt("WORKER_CONCURRENCY", 4),
+		WorkerMaxAttempts:     getEnvInt("WORKER_MAX_ATTEMPTS", 5),
+		WorkerPollInterval:    getEnvDuration("WORKER_POLL_INTERVAL", time.Second),
+		WorkerShutdownTimeout: getEnvDuration("WORKER_SHUTDOWN_TIMEOUT", 10*time.Second),
+		TrashRetention:        getEnvDuration("TRASH_RETENTION", 30*24*time.Hour),
+		ArchiveCompletedAfter: getEnvDuration("AUTO_ARCHIVE_AFTER", 30*24*time.Hour),
+		JobRetention:          getEnvDuration("JOB_RETENTION", 7*24*time.Hour),
+
+		IdempotencyTTL: getEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour),
 
-	AppConfig = &Config{
-		Port:           getEnv("PORT", "8080"),
-		DBPath:         getEnv("DB_PATH", "todo.db"),
-		JWTSecret:      getEnv("JWT_SECRET", "default-secret-change-me"),
-		JWTExpiryHours: jwtExpiry,
+		MailDriver:     getEnv("MAIL_DRIVER", "outbox"),
+		MailFrom:       getEnv("MAIL_FROM", "Todo API <no-reply@localhost>"),
+		MailOutboxDir:  getEnv("MAIL_OUTBOX_DIR", ""),
+		SMTPHost:       getEnv("SMTP_HOST", "localhost"),
+		SMTPPort:       getEnvInt("SMTP_PORT", 587),
+		SMTPUsername:   getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:   getEnv("SMTP_PASSWORD", ""),
+		SMTPRequireTLS: getEnv("SMTP_REQUIRE_TLS", "true") == "true",
 	}
+}
 
-	log.Printf("Configuration loaded: Port=%s, DBPath=%s", AppConfig.Port, AppConfig.DBPath)
+// defaultDriver keeps the legacy USE_SQLITE switch working when DB_DRIVER is unset
+func defaultDriver() string {
+	if getEnv("USE_SQLITE", "true") == "false" {
+		return "postgres"
+	}
+	return "sqlite"
 }
 
 func getEnv(key, defaultValue string) string {
@@ -42,3 +130,19 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(getEnv(key, ""))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(getEnv(key, ""))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
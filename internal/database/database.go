@@ -1,28 +1,124 @@
 package database
 
 import (
+	"fmt"
 	"log"
+	"net/url"
+	"strings"
 
 	"github.com/glebarez/sqlite"
 	"github.com/user/go-todo-api/internal/config"
+	"github.com/user/go-todo-api/internal/models"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
+// Supported values for DB_DRIVER
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+	// DriverMemory serves requests from repository/memory without any SQL connection
+	DriverMemory = "memory"
+)
+
 var DB *gorm.DB
 
 func Connect() {
 	var err error
-	DB, err = gorm.Open(sqlite.Open(config.AppConfig.DBPath), &gorm.Config{
+	DB, err = Open(config.AppConfig, &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
 	})
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 
-	log.Printf("Database connection established (SQLite: %s)", config.AppConfig.DBPath)
+	log.Printf("Database connection established (%s)", describe(config.AppConfig))
+}
+
+// Open connects to the database selected by cfg.DBDriver and applies the pool settings
+func Open(cfg *config.Config, opts ...gorm.Option) (*gorm.DB, error) {
+	var dialector gorm.Dialector
+	switch cfg.DBDriver {
+	case DriverSQLite, "":
+		dialector = sqlite.Open(cfg.DBPath)
+	case DriverPostgres:
+		dialector = postgres.Open(PostgresDSN(cfg))
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", cfg.DBDriver)
+	}
+
+	db, err := gorm.Open(dialector, opts...)
+	if err != nil {
+		return nil, err
+	}
+	// Report unique constraint violations as gorm.ErrDuplicatedKey on every driver
+	db.Config.TranslateError = true
+	db.Config.NowFunc = models.Now
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	// Every connection to an in-memory SQLite database gets its own empty
+	// database, so keep exactly one connection open for its whole lifetime.
+	if isMemorySQLite(cfg) {
+		sqlDB.SetMaxOpenConns(1)
+		sqlDB.SetMaxIdleConns(1)
+		return db, nil
+	}
+
+	sqlDB.SetMaxOpenConns(cfg.DBMaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.DBMaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.DBConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.DBConnMaxIdleTime)
+
+	return db, nil
+}
+
+// PostgresDSN builds a connection URL from the DB_* settings unless DATABASE_URL is set
+func PostgresDSN(cfg *config.Config) string {
+	if cfg.DBURL != "" {
+		return cfg.DBURL
+	}
+
+	dsn := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(cfg.DBUser, cfg.DBPassword),
+		Host:   cfg.DBHost + ":" + cfg.DBPort,
+		Path:   "/" + cfg.DBName,
+	}
+	query := url.Values{}
+	query.Set("sslmode", cfg.DBSSLMode)
+	dsn.RawQuery = query.Encode()
+
+	return dsn.String()
+}
+
+// Dialect returns the name of the active dialect ("sqlite" or "postgres")
+func Dialect() string {
+	return DB.Dialector.Name()
 }
 
 func GetDB() *gorm.DB {
 	return DB
 }
+
+func isMemorySQLite(cfg *config.Config) bool {
+	if cfg.DBDriver != DriverSQLite && cfg.DBDriver != "" {
+		return false
+	}
+	return cfg.DBPath == ":memory:" || strings.Contains(cfg.DBPath, "mode=memory")
+}
+
+// describe summarises the connection target without leaking credentials
+func describe(cfg *config.Config) string {
+	if cfg.DBDriver == DriverPostgres {
+		if cfg.DBURL != "" {
+			return "PostgreSQL: DATABASE_URL"
+		}
+		return fmt.Sprintf("PostgreSQL: %s:%s/%s", cfg.DBHost, cfg.DBPort, cfg.DBName)
+	}
+	return "SQLite: " + cfg.DBPath
+}
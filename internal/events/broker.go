@@ -0,0 +1,125 @@
+package events
+
+import "sync"
+
+// Broker streams each user's events to their live connections. It keeps
+// the latest events of every user so a client that reconnects can resume
+// from the last event it saw instead of refetching everything.
+type Broker struct {
+	mu          sync.Mutex
+	historySize int
+	history     map[uint][]Event // per user, oldest first
+	subs        map[uint]map[*Subscription]struct{}
+	closed      bool
+}
+
+// Subscription is one connection's feed of its user's events. Events is
+// closed when the subscriber falls too far behind or the broker shuts
+// down; the client is expected to reconnect and resume.
+type Subscription struct {
+	Events <-chan Event
+
+	events chan Event
+	userID uint
+	once   sync.Once
+}
+
+// subscriptionBuffer is how many events a subscriber may lag behind
+// before it is disconnected
+const subscriptionBuffer = 64
+
+// NewBroker returns a broker that remembers the last historySize events of
+// each user
+func NewBroker(historySize int) *Broker {
+	return &Broker{
+		historySize: historySize,
+		history:     make(map[uint][]Event),
+		subs:        make(map[uint]map[*Subscription]struct{}),
+	}
+}
+
+// Publish records e and sends it to its user's subscribers. It is meant to
+// be subscribed to a Bus.
+func (b *Broker) Publish(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	history := append(b.history[e.UserID], e)
+	if len(history) > b.historySize {
+		history = history[len(history)-b.historySize:]
+	}
+	b.history[e.UserID] = history
+
+	for sub := range b.subs[e.UserID] {
+		select {
+		case sub.events <- e:
+		default:
+			b.remove(sub)
+		}
+	}
+}
+
+// Subscribe starts a feed of userID's events. When lastEventID is set, the
+// events published after it are returned to be sent first; resumed is
+// false if it is no longer remembered, so the client must refetch.
+func (b *Broker) Subscribe(userID uint, lastEventID string) (sub *Subscription, missed []Event, resumed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	events := make(chan Event, subscriptionBuffer)
+	sub = &Subscription{Events: events, events: events, userID: userID}
+	if b.closed {
+		sub.close()
+		return sub, nil, true
+	}
+	if b.subs[userID] == nil {
+		b.subs[userID] = make(map[*Subscription]struct{})
+	}
+	b.subs[userID][sub] = struct{}{}
+
+	if lastEventID == "" {
+		return sub, nil, true
+	}
+	history := b.history[userID]
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].ID == lastEventID {
+			return sub, append([]Event(nil), history[i+1:]...), true
+		}
+	}
+	return sub, nil, false
+}
+
+// Unsubscribe ends sub's feed
+func (b *Broker) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.remove(sub)
+}
+
+// Close ends every feed, e.g. so streaming requests return on shutdown, and
+// refuses new ones
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for _, subs := range b.subs {
+		for sub := range subs {
+			b.remove(sub)
+		}
+	}
+}
+
+// remove drops sub and closes its channel. It must be called with the lock
+// held.
+func (b *Broker) remove(sub *Subscription) {
+	delete(b.subs[sub.userID], sub)
+	if len(b.subs[sub.userID]) == 0 {
+		delete(b.subs, sub.userID)
+	}
+	sub.close()
+}
+
+func (s *Subscription) close() {
+	s.once.Do(func() { close(s.events) })
+}
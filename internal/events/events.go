@@ -0,0 +1,86 @@
+// Package events carries what happens to a user's data, such as a todo
+// being completed, from the handlers to the parts of the app that react to
+// it, like webhooks. Publishing never blocks on, or fails because of, a
+// subscriber.
+package events
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// Event is one occurrence of an event type (see the models.Event*
+// constants), as delivered to subscribers
+type Event struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	UserID    uint        `json:"-"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// New returns an event of type typ that happened to userID's data now
+func New(typ string, userID uint, data interface{}) Event {
+	id := make([]byte, 12)
+	rand.Read(id)
+	return Event{
+		ID:        "evt_" + hex.EncodeToString(id),
+		Type:      typ,
+		UserID:    userID,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	}
+}
+
+// Bus fans events out to its subscribers. Subscribers run synchronously in
+// the publisher's goroutine, so they must hand slow work off, e.g. to the
+// worker.
+type Bus struct {
+	mu     sync.RWMutex
+	nextID int
+	subs   map[int]func(Event)
+}
+
+func NewBus() *Bus {
+	return &Bus{subs: make(map[int]func(Event))}
+}
+
+// Subscribe calls fn with every event published from now on, until the
+// returned function is called
+func (b *Bus) Subscribe(fn func(Event)) (unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	id := b.nextID
+	b.subs[id] = fn
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subs, id)
+	}
+}
+
+// Publish delivers e to every subscriber
+func (b *Bus) Publish(e Event) {
+	b.mu.RLock()
+	subs := make([]func(Event), 0, len(b.subs))
+	for _, fn := range b.subs {
+		subs = append(subs, fn)
+	}
+	b.mu.RUnlock()
+
+	for _, fn := range subs {
+		fn(e)
+	}
+}
+
+// Default is the application's bus: the handlers publish to it
+var Default = NewBus()
+
+// Publish delivers e to the subscribers of Default
+func Publish(e Event) {
+	Default.Publish(e)
+}
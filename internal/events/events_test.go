@@ -0,0 +1,72 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBusFansOutUntilUnsubscribed(t *testing.T) {
+	bus := NewBus()
+	var first, second []string
+	unsubscribe := bus.Subscribe(func(e Event) { first = append(first, e.Type) })
+	bus.Subscribe(func(e Event) { second = append(second, e.Type) })
+
+	e := New("todo.created", 7, map[string]int{"id": 1})
+	assert.Regexp(t, "^evt_[0-9a-f]{24}$", e.ID)
+	assert.Equal(t, uint(7), e.UserID)
+	bus.Publish(e)
+
+	unsubscribe()
+	bus.Publish(New("todo.deleted", 7, nil))
+
+	assert.Equal(t, []string{"todo.created"}, first)
+	assert.Equal(t, []string{"todo.created", "todo.deleted"}, second)
+	assert.NotEqual(t, e.ID, New("todo.created", 7, nil).ID)
+}
+
+func TestBrokerResumesFromLastEventID(t *testing.T) {
+	broker := NewBroker(3)
+	var published []Event
+	for i := 0; i < 4; i++ {
+		e := New("todo.updated", 1, i)
+		published = append(published, e)
+		broker.Publish(e)
+	}
+	broker.Publish(New("todo.updated", 2, "someone else"))
+
+	sub, missed, resumed := broker.Subscribe(1, published[1].ID)
+	assert.True(t, resumed)
+	assert.Equal(t, published[2:], missed)
+
+	// Events older than the history cannot be resumed from
+	_, missed, resumed = broker.Subscribe(1, published[0].ID)
+	assert.False(t, resumed)
+	assert.Empty(t, missed)
+
+	live := New("todo.deleted", 1, nil)
+	broker.Publish(live)
+	assert.Equal(t, live, <-sub.Events)
+
+	broker.Unsubscribe(sub)
+	_, open := <-sub.Events
+	assert.False(t, open)
+}
+
+func TestBrokerDropsSlowSubscribersAndCloses(t *testing.T) {
+	broker := NewBroker(10)
+	slow, _, _ := broker.Subscribe(1, "")
+	for i := 0; i < subscriptionBuffer+1; i++ {
+		broker.Publish(New("todo.updated", 1, i))
+	}
+	for range slow.Events {
+	}
+
+	sub, _, _ := broker.Subscribe(1, "")
+	broker.Close()
+	_, open := <-sub.Events
+	assert.False(t, open)
+	late, _, _ := broker.Subscribe(1, "")
+	_, open = <-late.Events
+	assert.False(t, open)
+}
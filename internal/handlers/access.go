@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/go-todo-api/internal/access"
+	"github.com/user/go-todo-api/internal/middleware"
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository"
+	"github.com/user/go-todo-api/pkg/utils"
+)
+
+// findAccessibleTodo loads the todo named in the path if the user holds at
+// least need on it, writing the error response and returning false if not
+func findAccessibleTodo(c *gin.Context, checker *access.Checker, need models.ProjectRole) (*models.Todo, bool) {
+	userID := middleware.GetUserIDFromContext(c)
+	todoID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid todo ID")
+		return nil, false
+	}
+
+	todo, err := checker.Todo(uint(todoID), userID, need)
+	if err != nil {
+		accessErrorResponse(c, err, "Todo not found")
+		return nil, false
+	}
+	return todo, true
+}
+
+// accessErrorResponse writes the response for a failed access check:
+// notFound when the user cannot see the record, 403 when their role on
+// its project is too low
+func accessErrorResponse(c *gin.Context, err error, notFound string) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		utils.ErrorResponse(c, http.StatusNotFound, notFound)
+	case errors.Is(err, access.ErrForbidden):
+		utils.ErrorResponse(c, http.StatusForbidden, "Your role on this project does not allow this")
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to check access")
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository"
+	"github.com/user/go-todo-api/pkg/utils"
+)
+
+// Archive hides a todo from the default listing
+// @Summary      Archive a todo
+// @Description  Leave a todo out of GET /todos unless include_archived is set. Completed todos are also
+// @Description  archived automatically a while after their completion (30 days by default), even if edited since.
+// @Tags         todos
+// @Security     Bearer
+// @Produce      json
+// @Param        id        path      int     true   "Todo ID"
+// @Param        If-Match  header    string  false  "ETag the change is based on"
+// @Success      200       {object}  utils.APIResponse{data=models.TodoResponse}
+// @Header       200       {string}  ETag  "Entity tag of the updated todo"
+// @Failure      400       {object}  utils.APIResponse
+// @Failure      401       {object}  utils.APIResponse
+// @Failure      404       {object}  utils.APIResponse
+// @Failure      412       {object}  utils.APIResponse
+// @Router       /todos/{id}/archive [post]
+func (h *TodoHandler) Archive(c *gin.Context) {
+	h.setFlag(c, "Todo archived", func(todo *models.Todo) *bool { return &todo.Archived }, true)
+}
+
+// Unarchive brings an archived todo back to the default listing
+// @Summary      Unarchive a todo
+// @Tags         todos
+// @Security     Bearer
+// @Produce      json
+// @Param        id        path      int     true   "Todo ID"
+// @Param        If-Match  header    string  false  "ETag the change is based on"
+// @Success      200       {object}  utils.APIResponse{data=models.TodoResponse}
+// @Header       200       {string}  ETag  "Entity tag of the updated todo"
+// @Failure      400       {object}  utils.APIResponse
+// @Failure      401       {object}  utils.APIResponse
+// @Failure      404       {object}  utils.APIResponse
+// @Failure      412       {object}  utils.APIResponse
+// @Router       /todos/{id}/unarchive [post]
+func (h *TodoHandler) Unarchive(c *gin.Context) {
+	h.setFlag(c, "Todo unarchived", func(todo *models.Todo) *bool { return &todo.Archived }, false)
+}
+
+// Pin lists a todo ahead of the others
+// @Summary      Pin a todo
+// @Description  List a todo before the unpinned ones, whatever the sort order.
+// @Tags         todos
+// @Security     Bearer
+// @Produce      json
+// @Param        id        path      int     true   "Todo ID"
+// @Param        If-Match  header    string  false  "ETag the change is based on"
+// @Success      200       {object}  utils.APIResponse{data=models.TodoResponse}
+// @Header       200       {string}  ETag  "Entity tag of the updated todo"
+// @Failure      400       {object}  utils.APIResponse
+// @Failure      401       {object}  utils.APIResponse
+// @Failure      404       {object}  utils.APIResponse
+// @Failure      412       {object}  utils.APIResponse
+// @Router       /todos/{id}/pin [post]
+func (h *TodoHandler) Pin(c *gin.Context) {
+	h.setFlag(c, "Todo pinned", func(todo *models.Todo) *bool { return &todo.Pinned }, true)
+}
+
+// Unpin returns a pinned todo to its place in the sort order
+// @Summary      Unpin a todo
+// @Tags         todos
+// @Security     Bearer
+// @Produce      json
+// @Param        id        path      int     true   "Todo ID"
+// @Param        If-Match  header    string  false  "ETag the change is based on"
+// @Success      200       {object}  utils.APIResponse{data=models.TodoResponse}
+// @Header       200       {string}  ETag  "Entity tag of the updated todo"
+// @Failure      400       {object}  utils.APIResponse
+// @Failure      401       {object}  utils.APIResponse
+// @Failure      404       {object}  utils.APIResponse
+// @Failure      412       {object}  utils.APIResponse
+// @Router       /todos/{id}/unpin [post]
+func (h *TodoHandler) Unpin(c *gin.Context) {
+	h.setFlag(c, "Todo unpinned", func(todo *models.Todo) *bool { return &todo.Pinned }, false)
+}
+
+// setFlag sets the flag of the todo named in the path to value and writes
+// the todo. A todo that already has the value is returned unchanged.
+func (h *TodoHandler) setFlag(c *gin.Context, message string, flag func(*models.Todo) *bool, value bool) {
+	todo, ok := h.findMatching(c)
+	if !ok {
+		return
+	}
+
+	if *flag(todo) != value {
+		*flag(todo) = value
+		err := h.todoRepo.Update(todo)
+		if errors.Is(err, repository.ErrConflict) {
+			preconditionFailed(c)
+			return
+		}
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update todo")
+			return
+		}
+		publishTodo(h.access, models.EventTodoUpdated, todo)
+	}
+
+	c.Header("ETag", todoETag(todo))
+	utils.SuccessResponse(c, http.StatusOK, message, todo.ToResponse())
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/go-todo-api/internal/access"
+	"github.com/user/go-todo-api/internal/middleware"
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository"
+	"github.com/user/go-todo-api/internal/worker"
+	"github.com/user/go-todo-api/pkg/utils"
+)
+
+// Assign makes a user responsible for a todo
+// @Summary      Assign a todo
+// @Description  Assign a todo to its creator or to a member of its project, replacing any previous assignee.
+// @Description  The assignee is emailed unless they assigned the todo to themselves. Requires the editor role on shared todos.
+// @Tags         todos
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        id        path      int                       true   "Todo ID"
+// @Param        request   body      models.AssignTodoRequest  true   "User to assign"
+// @Param        If-Match  header    string                    false  "ETag the change is based on"
+// @Success      200       {object}  utils.APIResponse{data=models.TodoResponse}
+// @Header       200       {string}  ETag  "Entity tag of the updated todo"
+// @Failure      400       {object}  utils.APIResponse
+// @Failure      401       {object}  utils.APIResponse
+// @Failure      403       {object}  utils.APIResponse
+// @Failure      404       {object}  utils.APIResponse
+// @Failure      412       {object}  utils.APIResponse
+// @Router       /todos/{id}/assign [post]
+func (h *TodoHandler) Assign(c *gin.Context) {
+	todo, ok := h.findMatching(c)
+	if !ok {
+		return
+	}
+
+	var req models.AssignTodoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid input: "+err.Error())
+		return
+	}
+
+	// Only someone who can see the todo can work on it
+	_, err := h.access.Todo(todo.ID, req.UserID, models.RoleViewer)
+	if errors.Is(err, repository.ErrNotFound) || errors.Is(err, access.ErrForbidden) {
+		utils.ValidationErrorResponse(c, "The assignee must be the todo's creator or a member of its project")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to check assignee")
+		return
+	}
+
+	h.setAssignee(c, "Todo assigned", todo, &req.UserID)
+}
+
+// Unassign leaves a todo without an assignee
+// @Summary      Unassign a todo
+// @Description  Remove a todo's assignee. Requires the editor role on shared todos.
+// @Tags         todos
+// @Security     Bearer
+// @Produce      json
+// @Param        id        path      int     true   "Todo ID"
+// @Param        If-Match  header    string  false  "ETag the change is based on"
+// @Success      200       {object}  utils.APIResponse{data=models.TodoResponse}
+// @Header       200       {string}  ETag  "Entity tag of the updated todo"
+// @Failure      400       {object}  utils.APIResponse
+// @Failure      401       {object}  utils.APIResponse
+// @Failure      403       {object}  utils.APIResponse
+// @Failure      404       {object}  utils.APIResponse
+// @Failure      412       {object}  utils.APIResponse
+// @Router       /todos/{id}/unassign [post]
+func (h *TodoHandler) Unassign(c *gin.Context) {
+	todo, ok := h.findMatching(c)
+	if !ok {
+		return
+	}
+	h.setAssignee(c, "Todo unassigned", todo, nil)
+}
+
+// setAssignee assigns todo to assigneeID, or to nobody when it is nil, and
+// writes the todo. A todo that already has the assignee is returned unchanged.
+func (h *TodoHandler) setAssignee(c *gin.Context, message string, todo *models.Todo, assigneeID *uint) {
+	if !sameID(todo.AssigneeID, assigneeID) {
+		todo.AssigneeID = assigneeID
+		err := h.todoRepo.Update(todo)
+		if errors.Is(err, repository.ErrConflict) {
+			preconditionFailed(c)
+			return
+		}
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update todo")
+			return
+		}
+		publishTodo(h.access, models.EventTodoUpdated, todo)
+		if assignerID := middleware.GetUserIDFromContext(c); assigneeID != nil && *assigneeID != assignerID {
+			notifyAssigned(todo, assignerID)
+		}
+	}
+
+	c.Header("ETag", todoETag(todo))
+	utils.SuccessResponse(c, http.StatusOK, message, todo.ToResponse())
+}
+
+func notifyAssigned(todo *models.Todo, assignerID uint) {
+	worker.GlobalWorker.Enqueue(worker.Task{
+		Type: worker.TaskTodoAssigned,
+		Payload: worker.TodoAssignedPayload{
+			ID:         todo.ID,
+			UserID:     *todo.AssigneeID,
+			AssignerID: assignerID,
+			Title:      todo.Title,
+		},
+	})
+}
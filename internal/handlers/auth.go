@@ -13,14 +13,14 @@ import (
 )
 
 type AuthHandler struct {
-	userRepo  *repository.UserRepository
-	tokenRepo *repository.TokenRepository
+	userRepo  repository.UserRepository
+	tokenRepo repository.TokenRepository
 }
 
-func NewAuthHandler() *AuthHandler {
+func NewAuthHandler(userRepo repository.UserRepository, tokenRepo repository.TokenRepository) *AuthHandler {
 	return &AuthHandler{
-		userRepo:  repository.NewUserRepository(),
-		tokenRepo: repository.NewTokenRepository(),
+		userRepo:  userRepo,
+		tokenRepo: tokenRepo,
 	}
 }
 
@@ -104,10 +104,10 @@ func (h *AuthHandler) Register(c *gin.Context) {
 
 	// Enqueue welcome email in background
 	worker.GlobalWorker.Enqueue(worker.Task{
-		Type: "SEND_WELCOME_EMAIL",
-		Payload: map[string]interface{}{
-			"email": user.Email,
-			"name":  user.Name,
+		Type: worker.TaskWelcomeEmail,
+		Payload: worker.WelcomeEmailPayload{
+			Email: user.Email,
+			Name:  user.Name,
 		},
 	})
 
@@ -259,3 +259,77 @@ func (h *AuthHandler) GetProfile(c *gin.Context) {
 
 	utils.SuccessResponse(c, http.StatusOK, "Profile retrieved", user.ToResponse())
 }
+
+// UpdateProfile changes the authenticated user's name, time zone and quiet hours
+// @Summary      Update user profile
+// @Description  Change the name, time zone or reminder quiet hours of the authenticated user.
+// @Description  Quiet hours are local "HH:MM" times and are set or cleared ("") together; no reminders are sent between them.
+// @Tags         users
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.UpdateProfileRequest  true  "Profile changes"
+// @Success      200      {object}  utils.APIResponse{data=models.UserResponse}
+// @Failure      400      {object}  utils.APIResponse
+// @Failure      401      {object}  utils.APIResponse
+// @Failure      404      {object}  utils.APIResponse
+// @Router       /profile [put]
+func (h *AuthHandler) UpdateProfile(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var req models.UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid input: "+err.Error())
+		return
+	}
+
+	user, err := h.userRepo.FindByID(userID.(uint))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "User not found")
+		return
+	}
+
+	if req.Name != nil {
+		user.Name = *req.Name
+	}
+	if req.Timezone != nil {
+		if _, err := time.LoadLocation(*req.Timezone); err != nil || *req.Timezone == "" || *req.Timezone == "Local" {
+			utils.ValidationErrorResponse(c, "Unknown timezone: expected an IANA name such as Europe/Berlin")
+			return
+		}
+		user.Timezone = *req.Timezone
+	}
+	if (req.QuietHoursStart == nil) != (req.QuietHoursEnd == nil) {
+		utils.ValidationErrorResponse(c, "quiet_hours_start and quiet_hours_end must be set together")
+		return
+	}
+	if req.QuietHoursStart != nil {
+		start, end := *req.QuietHoursStart, *req.QuietHoursEnd
+		if (start == "") != (end == "") {
+			utils.ValidationErrorResponse(c, "quiet_hours_start and quiet_hours_end must be set together")
+			return
+		}
+		if start != "" {
+			if _, err := models.ParseClock(start); err != nil {
+				utils.ValidationErrorResponse(c, "quiet_hours_start must be an HH:MM time")
+				return
+			}
+			if _, err := models.ParseClock(end); err != nil {
+				utils.ValidationErrorResponse(c, "quiet_hours_end must be an HH:MM time")
+				return
+			}
+		}
+		user.QuietHoursStart, user.QuietHoursEnd = start, end
+	}
+
+	if err := h.userRepo.Update(user); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update profile")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Profile updated", user.ToResponse())
+}
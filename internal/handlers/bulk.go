@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/user/go-todo-api/internal/access"
+	"github.com/user/go-todo-api/internal/middleware"
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository"
+	"github.com/user/go-todo-api/pkg/utils"
+)
+
+// bulkItemError fails a single bulk operation; the others still run
+type bulkItemError string
+
+func (e bulkItemError) Error() string { return string(e) }
+
+// errBulkRolledBack aborts the transaction of an atomic batch with a failed operation
+var errBulkRolledBack = errors.New("bulk operations rolled back")
+
+// bulkChange is a todo changed by a bulk operation, announced once the
+// transaction commits
+type bulkChange struct {
+	event     string
+	todo      *models.Todo
+	completed bool // the operation completed the todo
+}
+
+// Bulk applies a batch of operations to the user's todos
+// @Summary      Apply bulk operations
+// @Description  Create todos, change their status, delete them or shift their due dates in one transaction.
+// @Description  Each operation is reported by its index. By default the operations that succeed are kept;
+// @Description  with atomic set, a single failure rolls back the whole batch and the request fails with 400.
+// @Description  Bulk creates cannot set an rrule.
+// @Tags         todos
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        request          body      models.BulkTodoRequest  true   "Operations (at most 100)"
+// @Param        Idempotency-Key  header    string                  false  "Unique key that makes a retry return the first response"
+// @Success      200              {object}  utils.APIResponse{data=models.BulkTodoResponse}
+// @Failure      400              {object}  utils.APIResponse{data=models.BulkTodoResponse}
+// @Failure      401              {object}  utils.APIResponse
+// @Failure      409              {object}  utils.APIResponse
+// @Failure      422              {object}  utils.APIResponse
+// @Router       /todos/bulk [post]
+func (h *TodoHandler) Bulk(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+
+	var req models.BulkTodoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid input: "+err.Error())
+		return
+	}
+
+	// Everything that can be checked without the todos themselves is
+	// checked up front, so the transaction only touches todos
+	results := make([]models.BulkTodoResult, len(req.Operations))
+	creates := make([]*models.Todo, len(req.Operations))
+	for i, op := range req.Operations {
+		results[i] = models.BulkTodoResult{Index: i, Op: op.Op, ID: op.ID, Result: models.BulkOK}
+		todo, err := h.prepareBulk(op, userID)
+		var itemErr bulkItemError
+		if errors.As(err, &itemErr) {
+			results[i].Result, results[i].Error = models.BulkFailed, itemErr.Error()
+			continue
+		}
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to prepare bulk operations")
+			return
+		}
+		creates[i] = todo
+	}
+
+	var changes []bulkChange
+	err := h.todoRepo.Transaction(func(tx repository.TodoRepository) error {
+		failed := false
+		for i, op := range req.Operations {
+			if results[i].Result == models.BulkFailed {
+				failed = true
+				continue
+			}
+			change, err := applyBulk(tx, op, creates[i])
+			var itemErr bulkItemError
+			if errors.As(err, &itemErr) {
+				results[i].Result, results[i].Error = models.BulkFailed, itemErr.Error()
+				failed = true
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			response := change.todo.ToResponse()
+			results[i].ID, results[i].Todo = change.todo.ID, &response
+			changes = append(changes, change)
+		}
+		if failed && req.Atomic {
+			return errBulkRolledBack
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errBulkRolledBack) {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to apply bulk operations")
+		return
+	}
+
+	response := models.BulkTodoResponse{Atomic: req.Atomic, Results: results}
+	for i := range results {
+		if results[i].Result == models.BulkFailed {
+			response.Failed++
+			continue
+		}
+		if err != nil {
+			// Nothing was written, so drop what the operation would have left
+			results[i].Result, results[i].Todo = models.BulkRolledBack, nil
+			if req.Operations[i].Op == models.BulkCreate {
+				results[i].ID = 0
+			}
+			continue
+		}
+		response.Succeeded++
+	}
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.APIResponse{
+			Success: false,
+			Error:   fmt.Sprintf("%d of %d operations failed; none were applied", response.Failed, len(results)),
+			Data:    response,
+		})
+		return
+	}
+
+	for _, change := range changes {
+		publishTodo(h.access, change.event, change.todo)
+		if !change.completed {
+			continue
+		}
+		notifyCompleted(h.access, change.todo)
+		if _, err := scheduleNext(h.access, h.todoRepo, h.seriesRepo, change.todo); err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to schedule next occurrence")
+			return
+		}
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Bulk operations applied", response)
+}
+
+// prepareBulk validates op, building the todo to insert for a create and
+// checking that the user may edit the todo of any other operation
+func (h *TodoHandler) prepareBulk(op models.BulkTodoOperation, userID uint) (*models.Todo, error) {
+	if op.Op != models.BulkCreate {
+		if op.ID == 0 {
+			return nil, bulkItemError("id is required")
+		}
+		_, err := h.access.Todo(op.ID, userID, models.RoleEditor)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, bulkItemError("Todo not found")
+		}
+		if errors.Is(err, access.ErrForbidden) {
+			return nil, bulkItemError("Your role on this project does not allow this")
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch op.Op {
+	case models.BulkCreate:
+		return h.prepareBulkCreate(op.Todo, userID)
+	case models.BulkUpdateStatus:
+		if !op.Status.Valid() {
+			return nil, bulkItemError("status must be one of pending, in_progress, completed")
+		}
+	case models.BulkShiftDueDate:
+		if op.Minutes == 0 {
+			return nil, bulkItemError("minutes must not be zero")
+		}
+	case models.BulkDelete:
+	default:
+		return nil, bulkItemError("op must be one of create, update_status, delete, shift_due_date")
+	}
+	return nil, nil
+}
+
+func (h *TodoHandler) prepareBulkCreate(req *models.CreateTodoRequest, userID uint) (*models.Todo, error) {
+	if req == nil {
+		return nil, bulkItemError("todo is required")
+	}
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		return nil, bulkItemError("Invalid input: " + err.Error())
+	}
+	if req.Status != "" && !req.Status.Valid() {
+		return nil, bulkItemError("status must be one of pending, in_progress, completed")
+	}
+	if req.RRule != "" {
+		return nil, bulkItemError("rrule cannot be set in bulk; create recurring todos one at a time")
+	}
+
+	reminders, err := models.ParseReminderOffsets(req.Reminders)
+	if err != nil {
+		return nil, bulkItemError(err.Error())
+	}
+	tags, err := h.findTags(req.TagIDs, userID)
+	if errors.Is(err, errUnknownTag) {
+		return nil, bulkItemError("Unknown tag in tag_ids")
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = h.checkProject(req.ProjectID, userID)
+	if errors.Is(err, errUnknownProject) {
+		return nil, bulkItemError("Unknown project in project_id")
+	}
+	if errors.Is(err, access.ErrForbidden) {
+		return nil, bulkItemError("Your role on the project in project_id does not allow adding todos")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	status := req.Status
+	if status == "" {
+		status = models.StatusPending
+	}
+	return &models.Todo{
+		Title:        req.Title,
+		Description:  req.Description,
+		Status:       status,
+		Priority:     req.Priority,
+		DueDate:      req.DueDate,
+		Reminders:    reminders,
+		AutoComplete: req.AutoComplete,
+		UserID:       userID,
+		Tags:         tags,
+		ProjectID:    req.ProjectID,
+	}, nil
+}
+
+// applyBulk performs a validated operation inside the bulk transaction.
+// created is the todo prepared for a create.
+func applyBulk(tx repository.TodoRepository, op models.BulkTodoOperation, created *models.Todo) (bulkChange, error) {
+	if op.Op == models.BulkCreate {
+		if err := tx.Create(created); err != nil {
+			return bulkChange{}, err
+		}
+		return bulkChange{event: models.EventTodoCreated, todo: created}, nil
+	}
+
+	// Access was checked by prepareBulk; an earlier operation may have
+	// deleted the todo since
+	todo, err := tx.FindByID(op.ID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return bulkChange{}, bulkItemError("Todo not found")
+	}
+	if err != nil {
+		return bulkChange{}, err
+	}
+
+	change := bulkChange{event: models.EventTodoUpdated, todo: todo}
+	switch op.Op {
+	case models.BulkDelete:
+		change.event = models.EventTodoDeleted
+		return change, tx.Delete(todo.ID, todo.UserID)
+	case models.BulkUpdateStatus:
+		change.completed = op.Status == models.StatusCompleted && todo.Status != models.StatusCompleted
+		todo.Status = op.Status
+	case models.BulkShiftDueDate:
+		if todo.DueDate == nil {
+			return bulkChange{}, bulkItemError("Todo has no due date to shift")
+		}
+		due := todo.DueDate.Add(time.Duration(op.Minutes) * time.Minute)
+		todo.DueDate = &due
+	}
+	err = tx.Update(todo)
+	if errors.Is(err, repository.ErrConflict) {
+		return bulkChange{}, bulkItemError("Todo was changed by another request")
+	}
+	return change, err
+}
@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/go-todo-api/internal/access"
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository"
+	"github.com/user/go-todo-api/pkg/utils"
+)
+
+type ChecklistHandler struct {
+	todoRepo   repository.TodoRepository
+	itemRepo   repository.ChecklistRepository
+	seriesRepo repository.SeriesRepository
+	access     *access.Checker
+}
+
+func NewChecklistHandler(todoRepo repository.TodoRepository, projectRepo repository.ProjectRepository, itemRepo repository.ChecklistRepository, seriesRepo repository.SeriesRepository) *ChecklistHandler {
+	return &ChecklistHandler{
+		todoRepo:   todoRepo,
+		itemRepo:   itemRepo,
+		seriesRepo: seriesRepo,
+		access:     access.NewChecker(todoRepo, projectRepo),
+	}
+}
+
+// GetAll returns the checklist of a todo
+// @Summary      Get checklist items
+// @Description  Get the checklist items of a todo in display order
+// @Tags         checklist
+// @Security     Bearer
+// @Produce      json
+// @Param        id   path      int  true  "Todo ID"
+// @Success      200  {object}  utils.APIResponse{data=[]models.ChecklistItemResponse}
+// @Failure      401  {object}  utils.APIResponse
+// @Failure      404  {object}  utils.APIResponse
+// @Router       /todos/{id}/items [get]
+func (h *ChecklistHandler) GetAll(c *gin.Context) {
+	todo, ok := h.findTodo(c, models.RoleViewer)
+	if !ok {
+		return
+	}
+
+	itemsResponse := make([]models.ChecklistItemResponse, 0, len(todo.Items))
+	for _, item := range todo.Items {
+		itemsResponse = append(itemsResponse, item.ToResponse())
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Checklist retrieved", itemsResponse)
+}
+
+// Create adds an item to the end of a todo's checklist
+// @Summary      Add a checklist item
+// @Description  Append an item to the checklist of a todo; returns the updated todo
+// @Tags         checklist
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                                true  "Todo ID"
+// @Param        request  body      models.CreateChecklistItemRequest  true  "Item Information"
+// @Success      201      {object}  utils.APIResponse{data=models.TodoResponse}
+// @Failure      400      {object}  utils.APIResponse
+// @Failure      401      {object}  utils.APIResponse
+// @Failure      404      {object}  utils.APIResponse
+// @Router       /todos/{id}/items [post]
+func (h *ChecklistHandler) Create(c *gin.Context) {
+	todo, ok := h.findTodo(c, models.RoleEditor)
+	if !ok {
+		return
+	}
+
+	var req models.CreateChecklistItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid input: "+err.Error())
+		return
+	}
+
+	item := &models.ChecklistItem{
+		TodoID: todo.ID,
+		Title:  req.Title,
+	}
+
+	if err := h.itemRepo.Create(item); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create checklist item")
+		return
+	}
+
+	if !h.checklistChanged(c, todo.ID) {
+		return
+	}
+	h.respondWithTodo(c, http.StatusCreated, "Checklist item created", todo.ID)
+}
+
+// Reorder sets the order of a todo's checklist
+// @Summary      Reorder checklist items
+// @Description  Reorder the checklist of a todo. item_ids must list every item of the todo exactly once.
+// @Tags         checklist
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                             true  "Todo ID"
+// @Param        request  body      models.ReorderChecklistRequest  true  "New item order"
+// @Success      200      {object}  utils.APIResponse{data=models.TodoResponse}
+// @Failure      400      {object}  utils.APIResponse
+// @Failure      401      {object}  utils.APIResponse
+// @Failure      404      {object}  utils.APIResponse
+// @Router       /todos/{id}/items/order [put]
+func (h *ChecklistHandler) Reorder(c *gin.Context) {
+	todo, ok := h.findTodo(c, models.RoleEditor)
+	if !ok {
+		return
+	}
+
+	var req models.ReorderChecklistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid input: "+err.Error())
+		return
+	}
+
+	// The new order must be a permutation of the existing items
+	remaining := make(map[uint]bool, len(todo.Items))
+	for _, item := range todo.Items {
+		remaining[item.ID] = true
+	}
+	for _, id := range req.ItemIDs {
+		if !remaining[id] {
+			utils.ValidationErrorResponse(c, "item_ids must list every checklist item of the todo exactly once")
+			return
+		}
+		delete(remaining, id)
+	}
+	if len(remaining) > 0 {
+		utils.ValidationErrorResponse(c, "item_ids must list every checklist item of the todo exactly once")
+		return
+	}
+
+	if err := h.itemRepo.Reorder(todo.ID, req.ItemIDs); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to reorder checklist")
+		return
+	}
+
+	if !h.checklistChanged(c, todo.ID) {
+		return
+	}
+	h.respondWithTodo(c, http.StatusOK, "Checklist reordered", todo.ID)
+}
+
+// Toggle flips the done flag of a checklist item
+// @Summary      Toggle a checklist item
+// @Description  Mark a checklist item done or not done. When the todo has auto_complete set and every item is done, the todo is completed too.
+// @Tags         checklist
+// @Security     Bearer
+// @Produce      json
+// @Param        id       path      int  true  "Todo ID"
+// @Param        item_id  path      int  true  "Checklist item ID"
+// @Success      200      {object}  utils.APIResponse{data=models.TodoResponse}
+// @Failure      400      {object}  utils.APIResponse
+// @Failure      401      {object}  utils.APIResponse
+// @Failure      404      {object}  utils.APIResponse
+// @Router       /todos/{id}/items/{item_id}/toggle [post]
+func (h *ChecklistHandler) Toggle(c *gin.Context) {
+	todo, ok := h.findTodo(c, models.RoleEditor)
+	if !ok {
+		return
+	}
+	item, ok := h.findItem(c, todo.ID)
+	if !ok {
+		return
+	}
+
+	item.Done = !item.Done
+	if err := h.itemRepo.Update(item); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update checklist item")
+		return
+	}
+
+	if !h.checklistChanged(c, todo.ID) {
+		return
+	}
+	h.respondWithTodo(c, http.StatusOK, "Checklist item updated", todo.ID)
+}
+
+// Delete removes a checklist item
+// @Summary      Delete a checklist item
+// @Description  Remove an item from the checklist of a todo; returns the updated todo
+// @Tags         checklist
+// @Security     Bearer
+// @Produce      json
+// @Param        id       path      int  true  "Todo ID"
+// @Param        item_id  path      int  true  "Checklist item ID"
+// @Success      200      {object}  utils.APIResponse{data=models.TodoResponse}
+// @Failure      400      {object}  utils.APIResponse
+// @Failure      401      {object}  utils.APIResponse
+// @Failure      404      {object}  utils.APIResponse
+// @Router       /todos/{id}/items/{item_id} [delete]
+func (h *ChecklistHandler) Delete(c *gin.Context) {
+	todo, ok := h.findTodo(c, models.RoleEditor)
+	if !ok {
+		return
+	}
+	item, ok := h.findItem(c, todo.ID)
+	if !ok {
+		return
+	}
+
+	if err := h.itemRepo.Delete(item.ID, todo.ID); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to delete checklist item")
+		return
+	}
+
+	// Removing the last open item finishes the checklist as well
+	if !h.checklistChanged(c, todo.ID) {
+		return
+	}
+	h.respondWithTodo(c, http.StatusOK, "Checklist item deleted", todo.ID)
+}
+
+// findTodo loads the todo named in the path, writing the error response and
+// returning false unless the user holds at least need on it
+func (h *ChecklistHandler) findTodo(c *gin.Context, need models.ProjectRole) (*models.Todo, bool) {
+	return findAccessibleTodo(c, h.access, need)
+}
+
+func (h *ChecklistHandler) findItem(c *gin.Context, todoID uint) (*models.ChecklistItem, bool) {
+	itemID, err := strconv.ParseUint(c.Param("item_id"), 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid checklist item ID")
+		return nil, false
+	}
+
+	item, err := h.itemRepo.FindByIDAndTodoID(uint(itemID), todoID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Checklist item not found")
+		return nil, false
+	}
+	return item, true
+}
+
+// checklistChanged publishes the todo as updated after a change to its
+// checklist, completing it first if it opted in and the whole checklist is
+// done. It returns false after writing an error response.
+func (h *ChecklistHandler) checklistChanged(c *gin.Context, todoID uint) bool {
+	todo, err := h.todoRepo.FindByID(todoID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to load todo")
+		return false
+	}
+
+	progress := todo.Progress()
+	if !todo.AutoComplete || todo.Status == models.StatusCompleted ||
+		progress.Total == 0 || progress.Done < progress.Total {
+		publishTodo(h.access, models.EventTodoUpdated, todo)
+		return true
+	}
+
+	todo.Status = models.StatusCompleted
+	if err := h.todoRepo.Update(todo); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to complete todo")
+		return false
+	}
+	publishTodo(h.access, models.EventTodoUpdated, todo)
+	notifyCompleted(h.access, todo)
+
+	if _, err := scheduleNext(h.access, h.todoRepo, h.seriesRepo, todo); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to schedule next occurrence")
+		return false
+	}
+	return true
+}
+
+// respondWithTodo reloads the todo so the response reflects its checklist
+func (h *ChecklistHandler) respondWithTodo(c *gin.Context, status int, message string, todoID uint) {
+	todo, err := h.todoRepo.FindByID(todoID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to load todo")
+		return
+	}
+	utils.SuccessResponse(c, status, message, todo.ToResponse())
+}
@@ -0,0 +1,521 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/go-todo-api/internal/access"
+	"github.com/user/go-todo-api/internal/middleware"
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository"
+	"github.com/user/go-todo-api/pkg/utils"
+)
+
+type ProjectHandler struct {
+	projectRepo repository.ProjectRepository
+	todoRepo    repository.TodoRepository
+	userRepo    repository.UserRepository
+	access      *access.Checker
+}
+
+func NewProjectHandler(projectRepo repository.ProjectRepository, todoRepo repository.TodoRepository, userRepo repository.UserRepository) *ProjectHandler {
+	return &ProjectHandler{
+		projectRepo: projectRepo,
+		todoRepo:    todoRepo,
+		userRepo:    userRepo,
+		access:      access.NewChecker(todoRepo, projectRepo),
+	}
+}
+
+// GetAll returns the projects of the authenticated user
+// @Summary      Get all projects
+// @Description  Get the authenticated user's projects in their order, followed by the projects shared with them by name,
+// @Description  each with the user's role and its todos counted by status.
+// @Description  Archived todos are not counted; archived projects are left out unless include_archived is set.
+// @Tags         projects
+// @Security     Bearer
+// @Produce      json
+// @Param        include_archived  query     bool  false  "Also list archived projects"
+// @Success      200               {object}  utils.APIResponse{data=[]models.ProjectResponse}
+// @Failure      400               {object}  utils.APIResponse
+// @Failure      401               {object}  utils.APIResponse
+// @Router       /projects [get]
+func (h *ProjectHandler) GetAll(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+
+	includeArchived, err := strconv.ParseBool(c.DefaultQuery("include_archived", "false"))
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid include_archived: expected true or false")
+		return
+	}
+
+	h.respondWithList(c, "Projects retrieved", userID, includeArchived)
+}
+
+// GetByID returns a single project by ID
+// @Summary      Get project by ID
+// @Description  Get a project the authenticated user owns or is a member of, with its todos counted by status
+// @Tags         projects
+// @Security     Bearer
+// @Produce      json
+// @Param        id   path      int  true  "Project ID"
+// @Success      200  {object}  utils.APIResponse{data=models.ProjectResponse}
+// @Failure      401  {object}  utils.APIResponse
+// @Failure      404  {object}  utils.APIResponse
+// @Router       /projects/{id} [get]
+func (h *ProjectHandler) GetByID(c *gin.Context) {
+	project, role, ok := h.findProject(c, models.RoleViewer)
+	if !ok {
+		return
+	}
+	h.respond(c, http.StatusOK, "Project retrieved", project, role)
+}
+
+// Create creates a new project
+// @Summary      Create a project
+// @Description  Create a project for the authenticated user after their existing ones. Names are unique per user; color is a hex code.
+// @Tags         projects
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.CreateProjectRequest  true  "Project Information"
+// @Success      201      {object}  utils.APIResponse{data=models.ProjectResponse}
+// @Failure      400      {object}  utils.APIResponse
+// @Failure      401      {object}  utils.APIResponse
+// @Failure      409      {object}  utils.APIResponse
+// @Router       /projects [post]
+func (h *ProjectHandler) Create(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+
+	var req models.CreateProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid input: "+err.Error())
+		return
+	}
+
+	project := &models.Project{
+		UserID:      userID,
+		Name:        req.Name,
+		Color:       req.Color,
+		Description: req.Description,
+	}
+
+	err := h.projectRepo.Create(project)
+	if errors.Is(err, repository.ErrDuplicate) {
+		utils.ErrorResponse(c, http.StatusConflict, "Project name already in use")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create project")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Project created", project.ToResponse(models.RoleOwner, models.StatusCounts{}))
+}
+
+// Update changes a project
+// @Summary      Update a project
+// @Description  Change the name, color or description of a project, or archive it; omitted fields are left unchanged.
+// @Description  Only owners may change a project.
+// @Tags         projects
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                          true  "Project ID"
+// @Param        request  body      models.UpdateProjectRequest  true  "Updated Project Info"
+// @Success      200      {object}  utils.APIResponse{data=models.ProjectResponse}
+// @Failure      400      {object}  utils.APIResponse
+// @Failure      401      {object}  utils.APIResponse
+// @Failure      403      {object}  utils.APIResponse
+// @Failure      404      {object}  utils.APIResponse
+// @Failure      409      {object}  utils.APIResponse
+// @Router       /projects/{id} [put]
+func (h *ProjectHandler) Update(c *gin.Context) {
+	project, role, ok := h.findProject(c, models.RoleOwner)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid input: "+err.Error())
+		return
+	}
+
+	if req.Name != "" {
+		project.Name = req.Name
+	}
+	if req.Color != "" {
+		project.Color = req.Color
+	}
+	if req.Description != nil {
+		project.Description = *req.Description
+	}
+	if req.Archived != nil {
+		project.Archived = *req.Archived
+	}
+
+	err := h.projectRepo.Update(project)
+	if errors.Is(err, repository.ErrDuplicate) {
+		utils.ErrorResponse(c, http.StatusConflict, "Project name already in use")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update project")
+		return
+	}
+
+	h.respond(c, http.StatusOK, "Project updated", project, role)
+}
+
+// Reorder sets the order of the user's projects
+// @Summary      Reorder projects
+// @Description  Reorder the projects the authenticated user created. project_ids must list every one of them, archived ones included, exactly once;
+// @Description  projects shared with the user keep their order by name. The reordered projects are returned, archived ones included.
+// @Tags         projects
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.ReorderProjectsRequest  true  "New project order"
+// @Success      200      {object}  utils.APIResponse{data=[]models.ProjectResponse}
+// @Failure      400      {object}  utils.APIResponse
+// @Failure      401      {object}  utils.APIResponse
+// @Router       /projects/order [put]
+func (h *ProjectHandler) Reorder(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+
+	var req models.ReorderProjectsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid input: "+err.Error())
+		return
+	}
+
+	projects, err := h.projectRepo.FindAllByUserID(userID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch projects")
+		return
+	}
+
+	// The new order must be a permutation of the existing projects
+	remaining := make(map[uint]bool, len(projects))
+	for _, project := range projects {
+		remaining[project.ID] = true
+	}
+	for _, id := range req.ProjectIDs {
+		if !remaining[id] {
+			utils.ValidationErrorResponse(c, "project_ids must list every project exactly once")
+			return
+		}
+		delete(remaining, id)
+	}
+	if len(remaining) > 0 {
+		utils.ValidationErrorResponse(c, "project_ids must list every project exactly once")
+		return
+	}
+
+	if err := h.projectRepo.Reorder(userID, req.ProjectIDs); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to reorder projects")
+		return
+	}
+
+	h.respondWithList(c, "Projects reordered", userID, true)
+}
+
+// Delete deletes a project
+// @Summary      Delete a project
+// @Description  Delete a project and its memberships. Its todos are kept by their creators and left without a project.
+// @Description  Only owners may delete a project.
+// @Tags         projects
+// @Security     Bearer
+// @Produce      json
+// @Param        id   path      int  true  "Project ID"
+// @Success      200  {object}  utils.APIResponse
+// @Failure      401  {object}  utils.APIResponse
+// @Failure      403  {object}  utils.APIResponse
+// @Failure      404  {object}  utils.APIResponse
+// @Router       /projects/{id} [delete]
+func (h *ProjectHandler) Delete(c *gin.Context) {
+	project, _, ok := h.findProject(c, models.RoleOwner)
+	if !ok {
+		return
+	}
+
+	if err := h.projectRepo.Delete(project.ID, project.UserID); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to delete project")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Project deleted", nil)
+}
+
+// GetMembers lists who a project is shared with
+// @Summary      Get project members
+// @Description  List everyone with access to a project: its creator first, then the members in the order they joined
+// @Tags         projects
+// @Security     Bearer
+// @Produce      json
+// @Param        id   path      int  true  "Project ID"
+// @Success      200  {object}  utils.APIResponse{data=[]models.ProjectMemberResponse}
+// @Failure      401  {object}  utils.APIResponse
+// @Failure      404  {object}  utils.APIResponse
+// @Router       /projects/{id}/members [get]
+func (h *ProjectHandler) GetMembers(c *gin.Context) {
+	project, _, ok := h.findProject(c, models.RoleViewer)
+	if !ok {
+		return
+	}
+	h.respondWithMembers(c, http.StatusOK, "Project members retrieved", project)
+}
+
+// AddMember shares a project with another user
+// @Summary      Add a project member
+// @Description  Share a project with the registered user with the given email as an owner, editor or viewer.
+// @Description  Editors may change the project's todos and viewers only read them; owners also manage the project and its members.
+// @Description  Only owners may add members.
+// @Tags         projects
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                             true  "Project ID"
+// @Param        request  body      models.AddProjectMemberRequest  true  "Member email and role"
+// @Success      201      {object}  utils.APIResponse{data=[]models.ProjectMemberResponse}
+// @Failure      400      {object}  utils.APIResponse
+// @Failure      401      {object}  utils.APIResponse
+// @Failure      403      {object}  utils.APIResponse
+// @Failure      404      {object}  utils.APIResponse
+// @Failure      409      {object}  utils.APIResponse
+// @Router       /projects/{id}/members [post]
+func (h *ProjectHandler) AddMember(c *gin.Context) {
+	project, _, ok := h.findProject(c, models.RoleOwner)
+	if !ok {
+		return
+	}
+
+	var req models.AddProjectMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid input: "+err.Error())
+		return
+	}
+	if !req.Role.Valid() {
+		utils.ValidationErrorResponse(c, "role must be one of owner, editor, viewer")
+		return
+	}
+
+	user, err := h.userRepo.FindByEmail(req.Email)
+	if errors.Is(err, repository.ErrNotFound) {
+		utils.ErrorResponse(c, http.StatusNotFound, "No user registered with that email")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to find user")
+		return
+	}
+	if user.ID == project.UserID {
+		utils.ErrorResponse(c, http.StatusConflict, "User already has access to this project")
+		return
+	}
+
+	err = h.projectRepo.AddMember(&models.ProjectMember{ProjectID: project.ID, UserID: user.ID, Role: req.Role})
+	if errors.Is(err, repository.ErrDuplicate) {
+		utils.ErrorResponse(c, http.StatusConflict, "User already has access to this project")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to add member")
+		return
+	}
+
+	h.respondWithMembers(c, http.StatusCreated, "Member added", project)
+}
+
+// UpdateMember changes a member's role
+// @Summary      Update a project member
+// @Description  Change the role of a project member. The project's creator always stays an owner. Only owners may change roles.
+// @Tags         projects
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                                true  "Project ID"
+// @Param        user_id  path      int                                true  "Member user ID"
+// @Param        request  body      models.UpdateProjectMemberRequest  true  "New role"
+// @Success      200      {object}  utils.APIResponse{data=[]models.ProjectMemberResponse}
+// @Failure      400      {object}  utils.APIResponse
+// @Failure      401      {object}  utils.APIResponse
+// @Failure      403      {object}  utils.APIResponse
+// @Failure      404      {object}  utils.APIResponse
+// @Router       /projects/{id}/members/{user_id} [put]
+func (h *ProjectHandler) UpdateMember(c *gin.Context) {
+	project, _, ok := h.findProject(c, models.RoleOwner)
+	if !ok {
+		return
+	}
+	member, ok := h.findMember(c, project)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateProjectMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid input: "+err.Error())
+		return
+	}
+	if !req.Role.Valid() {
+		utils.ValidationErrorResponse(c, "role must be one of owner, editor, viewer")
+		return
+	}
+
+	member.Role = req.Role
+	if err := h.projectRepo.UpdateMember(member); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update member")
+		return
+	}
+
+	h.respondWithMembers(c, http.StatusOK, "Member updated", project)
+}
+
+// RemoveMember stops sharing a project with a member
+// @Summary      Remove a project member
+// @Description  Remove a member from a project. Owners may remove anyone but the project's creator; any member may leave.
+// @Description  The todos the member created in the project stay in it.
+// @Tags         projects
+// @Security     Bearer
+// @Produce      json
+// @Param        id       path      int  true  "Project ID"
+// @Param        user_id  path      int  true  "Member user ID"
+// @Success      200      {object}  utils.APIResponse
+// @Failure      400      {object}  utils.APIResponse
+// @Failure      401      {object}  utils.APIResponse
+// @Failure      403      {object}  utils.APIResponse
+// @Failure      404      {object}  utils.APIResponse
+// @Router       /projects/{id}/members/{user_id} [delete]
+func (h *ProjectHandler) RemoveMember(c *gin.Context) {
+	project, role, ok := h.findProject(c, models.RoleViewer)
+	if !ok {
+		return
+	}
+	member, ok := h.findMember(c, project)
+	if !ok {
+		return
+	}
+	if member.UserID != middleware.GetUserIDFromContext(c) && !role.Allows(models.RoleOwner) {
+		accessErrorResponse(c, access.ErrForbidden, "Project not found")
+		return
+	}
+
+	if err := h.projectRepo.RemoveMember(project.ID, member.UserID); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to remove member")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Member removed", nil)
+}
+
+// findProject loads the project in the :id parameter, writing the error
+// response unless the user holds at least need on it
+func (h *ProjectHandler) findProject(c *gin.Context, need models.ProjectRole) (*models.Project, models.ProjectRole, bool) {
+	userID := middleware.GetUserIDFromContext(c)
+	projectID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid project ID")
+		return nil, "", false
+	}
+
+	project, role, err := h.access.Project(uint(projectID), userID, need)
+	if err != nil {
+		accessErrorResponse(c, err, "Project not found")
+		return nil, "", false
+	}
+	return project, role, true
+}
+
+// findMember loads the member in the :user_id parameter. The project's
+// creator is not a member and cannot be changed or removed.
+func (h *ProjectHandler) findMember(c *gin.Context, project *models.Project) (*models.ProjectMember, bool) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid user ID")
+		return nil, false
+	}
+	if uint(userID) == project.UserID {
+		utils.ValidationErrorResponse(c, "The project's creator cannot be changed or removed")
+		return nil, false
+	}
+
+	member, err := h.projectRepo.FindMember(project.ID, uint(userID))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Member not found")
+		return nil, false
+	}
+	return member, true
+}
+
+// respondWithList writes the user's own projects followed by those shared
+// with them, with their current todo counts
+func (h *ProjectHandler) respondWithList(c *gin.Context, message string, userID uint, includeArchived bool) {
+	owned, err := h.projectRepo.FindAllByUserID(userID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch projects")
+		return
+	}
+	shared, err := h.projectRepo.FindSharedWithUser(userID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch projects")
+		return
+	}
+	counts, err := h.todoRepo.CountByProject(userID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to count todos")
+		return
+	}
+
+	projectsResponse := make([]models.ProjectResponse, 0, len(owned)+len(shared))
+	for _, project := range append(owned, shared...) {
+		if project.Archived && !includeArchived {
+			continue
+		}
+		role, err := h.access.Role(&project, userID)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch projects")
+			return
+		}
+		projectsResponse = append(projectsResponse, project.ToResponse(role, counts[project.ID]))
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, message, projectsResponse)
+}
+
+// respond writes the project with its current todo counts
+func (h *ProjectHandler) respond(c *gin.Context, status int, message string, project *models.Project, role models.ProjectRole) {
+	counts, err := h.todoRepo.CountByProject(middleware.GetUserIDFromContext(c))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to count todos")
+		return
+	}
+	utils.SuccessResponse(c, status, message, project.ToResponse(role, counts[project.ID]))
+}
+
+// respondWithMembers writes the project's creator and members
+func (h *ProjectHandler) respondWithMembers(c *gin.Context, status int, message string, project *models.Project) {
+	members, err := h.projectRepo.FindMembers(project.ID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch members")
+		return
+	}
+
+	creator := models.ProjectMember{UserID: project.UserID, Role: models.RoleOwner, CreatedAt: project.CreatedAt}
+	membersResponse := make([]models.ProjectMemberResponse, 0, len(members)+1)
+	for i, member := range append([]models.ProjectMember{creator}, members...) {
+		user, err := h.userRepo.FindByID(member.UserID)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch members")
+			return
+		}
+		response := member.ToResponse(user)
+		response.Creator = i == 0
+		membersResponse = append(membersResponse, response)
+	}
+
+	utils.SuccessResponse(c, status, message, membersResponse)
+}
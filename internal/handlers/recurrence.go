@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/go-todo-api/internal/access"
+	"github.com/user/go-todo-api/internal/middleware"
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/recurrence"
+	"github.com/user/go-todo-api/internal/repository"
+	"github.com/user/go-todo-api/pkg/utils"
+)
+
+// Edit scopes for recurring todos: change only this instance, or this
+// instance and every instance generated after it
+const (
+	scopeThis   = "this"
+	scopeFuture = "future"
+)
+
+// maxOccurrencePreview caps the n parameter of the occurrences preview
+const maxOccurrencePreview = 50
+
+// Occurrences previews the upcoming instances of a recurring todo
+// @Summary      Preview occurrences
+// @Description  List the due dates of the next n instances of a recurring todo after this one
+// @Tags         todos
+// @Security     Bearer
+// @Produce      json
+// @Param        id   path      int  true   "Todo ID"
+// @Param        n    query     int  false  "Number of occurrences (default: 5, max: 50)"
+// @Success      200  {object}  utils.APIResponse{data=models.OccurrencesResponse}
+// @Failure      400  {object}  utils.APIResponse
+// @Failure      401  {object}  utils.APIResponse
+// @Failure      404  {object}  utils.APIResponse
+// @Router       /todos/{id}/occurrences [get]
+func (h *TodoHandler) Occurrences(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	todoID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid todo ID")
+		return
+	}
+
+	n, err := strconv.Atoi(c.DefaultQuery("n", "5"))
+	if err != nil || n < 1 || n > maxOccurrencePreview {
+		utils.ValidationErrorResponse(c, "n must be between 1 and "+strconv.Itoa(maxOccurrencePreview))
+		return
+	}
+
+	todo, err := h.access.Todo(uint(todoID), userID, models.RoleViewer)
+	if err != nil {
+		accessErrorResponse(c, err, "Todo not found")
+		return
+	}
+	if todo.Series == nil || todo.DueDate == nil {
+		utils.ValidationErrorResponse(c, "Todo is not recurring")
+		return
+	}
+
+	rule, err := recurrence.Parse(todo.Series.RRule)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Stored recurrence rule is invalid")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Occurrences retrieved", models.OccurrencesResponse{
+		RRule:       todo.Series.RRule,
+		Occurrences: rule.Next(todo.Series.DTStart, *todo.DueDate, n),
+	})
+}
+
+// startSeries makes a saved todo the first instance of a new series that
+// follows rule from the todo's due date. The caller saves the todo to store
+// the link.
+func startSeries(seriesRepo repository.SeriesRepository, todo *models.Todo, rule *recurrence.Rule) error {
+	series := &models.RecurrenceSeries{
+		UserID:       todo.UserID,
+		RRule:        rule.String(),
+		DTStart:      *todo.DueDate,
+		Title:        todo.Title,
+		Description:  todo.Description,
+		Priority:     todo.Priority,
+		LatestTodoID: todo.ID,
+	}
+	if err := seriesRepo.Create(series); err != nil {
+		return err
+	}
+	todo.SeriesID = &series.ID
+	todo.Series = series
+	return nil
+}
+
+// scheduleNext creates the instance that follows a completed todo, due at
+// the rule's next occurrence after the todo's due date. It does nothing
+// once the series has ended, or when todo is not the series' newest
+// instance, so completing an instance twice never schedules two.
+func scheduleNext(checker *access.Checker, todoRepo repository.TodoRepository, seriesRepo repository.SeriesRepository, todo *models.Todo) (*models.Todo, error) {
+	series := todo.Series
+	if series == nil || series.LatestTodoID != todo.ID || todo.DueDate == nil {
+		return nil, nil
+	}
+
+	rule, err := recurrence.Parse(series.RRule)
+	if err != nil {
+		return nil, err
+	}
+	due, ok := rule.After(series.DTStart, *todo.DueDate)
+	if !ok {
+		return nil, nil
+	}
+
+	next := &models.Todo{
+		Title:        series.Title,
+		Description:  series.Description,
+		Status:       models.StatusPending,
+		Priority:     series.Priority,
+		DueDate:      &due,
+		Reminders:    todo.Reminders,
+		AutoComplete: todo.AutoComplete,
+		Pinned:       todo.Pinned,
+		ProjectID:    todo.ProjectID,
+		AssigneeID:   todo.AssigneeID,
+		UserID:       todo.UserID,
+		Tags:         todo.Tags,
+		SeriesID:     &series.ID,
+	}
+	if err := todoRepo.Create(next); err != nil {
+		return nil, err
+	}
+
+	series.LatestTodoID = next.ID
+	if err := seriesRepo.Update(series); err != nil {
+		return nil, err
+	}
+	next.Series = series
+	publishTodo(checker, models.EventTodoCreated, next)
+	return next, nil
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/go-todo-api/internal/events"
+	"github.com/user/go-todo-api/internal/middleware"
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/pkg/utils"
+	"golang.org/x/net/websocket"
+)
+
+// streamReset tells a client that its Last-Event-ID could not be resumed
+// from, so it must refetch its todos
+const streamReset = "reset"
+
+// streamHeartbeat keeps idle SSE connections from being cut by proxies
+const streamHeartbeat = 25 * time.Second
+
+type StreamHandler struct {
+	broker  *events.Broker
+	tickets *middleware.StreamTickets
+}
+
+func NewStreamHandler(broker *events.Broker, tickets *middleware.StreamTickets) *StreamHandler {
+	return &StreamHandler{
+		broker:  broker,
+		tickets: tickets,
+	}
+}
+
+// Ticket issues a ticket for opening a stream without the Authorization header
+// @Summary      Get a stream ticket
+// @Description  Issue a ticket to pass as ?ticket= to GET /stream or /stream/ws, since EventSource and browser
+// @Description  WebSockets cannot set headers. A ticket opens one stream and expires after 30 seconds, so reconnects need a new one.
+// @Tags         stream
+// @Security     Bearer
+// @Produce      json
+// @Success      201  {object}  utils.APIResponse{data=models.StreamTicketResponse}
+// @Failure      401  {object}  utils.APIResponse
+// @Router       /stream/ticket [post]
+func (h *StreamHandler) Ticket(c *gin.Context) {
+	ticket, expiresAt := h.tickets.Issue(middleware.GetUserIDFromContext(c), c.GetString("userEmail"))
+	utils.SuccessResponse(c, http.StatusCreated, "Stream ticket issued", models.StreamTicketResponse{
+		Ticket:    ticket,
+		ExpiresAt: expiresAt.UTC(),
+	})
+}
+
+// SSE streams the authenticated user's todo events
+// @Summary      Stream todo events (SSE)
+// @Description  Server-Sent Events stream of the user's todo.created, todo.updated, todo.completed, todo.deleted and todo.restored events,
+// @Description  each with the event as JSON data. After a reconnect, the events missed since Last-Event-ID are sent first;
+// @Description  a "reset" event means they are no longer available and the client should refetch its todos.
+// @Description  Browsers pass a ticket from POST /stream/ticket instead, since EventSource cannot set headers.
+// @Tags         stream
+// @Security     Bearer
+// @Produce      text/event-stream
+// @Param        Last-Event-ID  header    string  false  "ID of the last event received"
+// @Param        ticket         query     string  false  "Stream ticket, instead of the Authorization header"
+// @Success      200            {string}  string  "event stream"
+// @Failure      401            {object}  utils.APIResponse
+// @Router       /stream [get]
+func (h *StreamHandler) SSE(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+
+	sub, missed, resumed := h.broker.Subscribe(userID, lastEventID)
+	defer h.broker.Unsubscribe(sub)
+
+	// The stream outlives the server's read and write timeouts
+	rc := http.NewResponseController(c.Writer)
+	rc.SetReadDeadline(time.Time{})
+	rc.SetWriteDeadline(time.Time{})
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	if !resumed {
+		io.WriteString(c.Writer, "event: "+streamReset+"\ndata: {}\n\n")
+	}
+	for _, e := range missed {
+		writeSSE(c.Writer, e)
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case e, ok := <-sub.Events:
+			if !ok {
+				return
+			}
+			if err := writeSSE(c.Writer, e); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := io.WriteString(c.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
+
+// WebSocket streams the same events as SSE over a WebSocket
+// @Summary      Stream todo events (WebSocket)
+// @Description  WebSocket alternative to GET /stream: every event is sent as a JSON text message, and
+// @Description  {"type":"reset"} replaces the reset event. Resume with the last_event_id query parameter.
+// @Tags         stream
+// @Security     Bearer
+// @Param        last_event_id  query  string  false  "ID of the last event received"
+// @Param        ticket         query  string  false  "Stream ticket, instead of the Authorization header"
+// @Success      101  {string}  string  "switching protocols"
+// @Failure      401  {object}  utils.APIResponse
+// @Router       /stream/ws [get]
+func (h *StreamHandler) WebSocket(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	lastEventID := c.Query("last_event_id")
+
+	server := websocket.Server{
+		// Any origin may connect: requests carry a JWT or ticket, not cookies
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(ws *websocket.Conn) {
+			// The connection outlives the server's timeouts
+			ws.SetDeadline(time.Time{})
+			h.streamWebSocket(ws, userID, lastEventID)
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+func (h *StreamHandler) streamWebSocket(ws *websocket.Conn, userID uint, lastEventID string) {
+	sub, missed, resumed := h.broker.Subscribe(userID, lastEventID)
+	defer h.broker.Unsubscribe(sub)
+
+	// Clients only listen; reading notices when they go away
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+		var discard string
+		for websocket.Message.Receive(ws, &discard) == nil {
+		}
+	}()
+
+	if !resumed {
+		if err := websocket.JSON.Send(ws, gin.H{"type": streamReset}); err != nil {
+			return
+		}
+	}
+	for _, e := range missed {
+		if err := websocket.JSON.Send(ws, e); err != nil {
+			return
+		}
+	}
+	for {
+		select {
+		case <-gone:
+			return
+		case e, ok := <-sub.Events:
+			if !ok {
+				return
+			}
+			if err := websocket.JSON.Send(ws, e); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// writeSSE writes e as a Server-Sent Event whose ID is the event's
+func writeSSE(w io.Writer, e events.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+	return err
+}
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/go-todo-api/internal/middleware"
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository"
+	"github.com/user/go-todo-api/pkg/utils"
+)
+
+type TagHandler struct {
+	tagRepo repository.TagRepository
+}
+
+func NewTagHandler(tagRepo repository.TagRepository) *TagHandler {
+	return &TagHandler{
+		tagRepo: tagRepo,
+	}
+}
+
+// GetAll returns all tags of the authenticated user
+// @Summary      Get all tags
+// @Description  Get the authenticated user's tags ordered by name
+// @Tags         tags
+// @Security     Bearer
+// @Produce      json
+// @Success      200  {object}  utils.APIResponse{data=[]models.TagResponse}
+// @Failure      401  {object}  utils.APIResponse
+// @Router       /tags [get]
+func (h *TagHandler) GetAll(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+
+	tags, err := h.tagRepo.FindAllByUserID(userID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch tags")
+		return
+	}
+
+	tagsResponse := make([]models.TagResponse, 0, len(tags))
+	for _, tag := range tags {
+		tagsResponse = append(tagsResponse, tag.ToResponse())
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Tags retrieved", tagsResponse)
+}
+
+// GetByID returns a single tag by ID
+// @Summary      Get tag by ID
+// @Description  Get a specific tag of the authenticated user
+// @Tags         tags
+// @Security     Bearer
+// @Produce      json
+// @Param        id   path      int  true  "Tag ID"
+// @Success      200  {object}  utils.APIResponse{data=models.TagResponse}
+// @Failure      401  {object}  utils.APIResponse
+// @Failure      404  {object}  utils.APIResponse
+// @Router       /tags/{id} [get]
+func (h *TagHandler) GetByID(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	tagID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid tag ID")
+		return
+	}
+
+	tag, err := h.tagRepo.FindByIDAndUserID(uint(tagID), userID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Tag not found")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Tag retrieved", tag.ToResponse())
+}
+
+// Create creates a new tag
+// @Summary      Create a tag
+// @Description  Create a tag for the authenticated user. Names are unique per user; color is a hex code.
+// @Tags         tags
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.CreateTagRequest  true  "Tag Information"
+// @Success      201      {object}  utils.APIResponse{data=models.TagResponse}
+// @Failure      400      {object}  utils.APIResponse
+// @Failure      401      {object}  utils.APIResponse
+// @Failure      409      {object}  utils.APIResponse
+// @Router       /tags [post]
+func (h *TagHandler) Create(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+
+	var req models.CreateTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid input: "+err.Error())
+		return
+	}
+
+	tag := &models.Tag{
+		UserID: userID,
+		Name:   req.Name,
+		Color:  req.Color,
+	}
+
+	err := h.tagRepo.Create(tag)
+	if errors.Is(err, repository.ErrDuplicate) {
+		utils.ErrorResponse(c, http.StatusConflict, "Tag name already in use")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create tag")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Tag created", tag.ToResponse())
+}
+
+// Update renames or recolors a tag
+// @Summary      Update a tag
+// @Description  Change the name or color of a tag; omitted fields are left unchanged
+// @Tags         tags
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                      true  "Tag ID"
+// @Param        request  body      models.UpdateTagRequest  true  "Updated Tag Info"
+// @Success      200      {object}  utils.APIResponse{data=models.TagResponse}
+// @Failure      400      {object}  utils.APIResponse
+// @Failure      401      {object}  utils.APIResponse
+// @Failure      404      {object}  utils.APIResponse
+// @Failure      409      {object}  utils.APIResponse
+// @Router       /tags/{id} [put]
+func (h *TagHandler) Update(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	tagID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid tag ID")
+		return
+	}
+
+	tag, err := h.tagRepo.FindByIDAndUserID(uint(tagID), userID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Tag not found")
+		return
+	}
+
+	var req models.UpdateTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid input: "+err.Error())
+		return
+	}
+
+	if req.Name != "" {
+		tag.Name = req.Name
+	}
+	if req.Color != "" {
+		tag.Color = req.Color
+	}
+
+	err = h.tagRepo.Update(tag)
+	if errors.Is(err, repository.ErrDuplicate) {
+		utils.ErrorResponse(c, http.StatusConflict, "Tag name already in use")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update tag")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Tag updated", tag.ToResponse())
+}
+
+// Delete deletes a tag
+// @Summary      Delete a tag
+// @Description  Delete a tag and remove it from every todo it was attached to
+// @Tags         tags
+// @Security     Bearer
+// @Produce      json
+// @Param        id   path      int  true  "Tag ID"
+// @Success      200  {object}  utils.APIResponse
+// @Failure      401  {object}  utils.APIResponse
+// @Failure      404  {object}  utils.APIResponse
+// @Router       /tags/{id} [delete]
+func (h *TagHandler) Delete(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	tagID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid tag ID")
+		return
+	}
+
+	// Verify tag exists and belongs to user
+	_, err = h.tagRepo.FindByIDAndUserID(uint(tagID), userID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Tag not found")
+		return
+	}
+
+	if err := h.tagRepo.Delete(uint(tagID), userID); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to delete tag")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Tag deleted", nil)
+}
@@ -1,41 +1,82 @@
 package handlers
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/user/go-todo-api/internal/access"
+	"github.com/user/go-todo-api/internal/events"
+	"github.com/user/go-todo-api/internal/jsonpatch"
 	"github.com/user/go-todo-api/internal/middleware"
 	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/recurrence"
 	"github.com/user/go-todo-api/internal/repository"
 	"github.com/user/go-todo-api/internal/worker"
 	"github.com/user/go-todo-api/pkg/utils"
 )
 
 type TodoHandler struct {
-	todoRepo *repository.TodoRepository
+	todoRepo    repository.TodoRepository
+	tagRepo     repository.TagRepository
+	projectRepo repository.ProjectRepository
+	seriesRepo  repository.SeriesRepository
+	access      *access.Checker
 }
 
-func NewTodoHandler() *TodoHandler {
+func NewTodoHandler(todoRepo repository.TodoRepository, tagRepo repository.TagRepository, projectRepo repository.ProjectRepository, seriesRepo repository.SeriesRepository) *TodoHandler {
 	return &TodoHandler{
-		todoRepo: repository.NewTodoRepository(),
+		todoRepo:    todoRepo,
+		tagRepo:     tagRepo,
+		projectRepo: projectRepo,
+		seriesRepo:  seriesRepo,
+		access:      access.NewChecker(todoRepo, projectRepo),
 	}
 }
 
+// errUnknownTag is returned when a request references a tag the user does not own
+var errUnknownTag = errors.New("unknown tag")
+
+// errUnknownProject is returned when a request references a project the user does not own
+var errUnknownProject = errors.New("unknown project")
+
 // GetAll returns all todos for the authenticated user with pagination, filtering, and sorting
 // @Summary      Get all todos
-// @Description  Get a paginated list of todos for the authenticated user with optional filtering and sorting
+// @Description  Get a list of todos for the authenticated user with optional filtering and sorting.
+// @Description  Page mode (page/page_size) returns totals. Passing a cursor from next_cursor/prev_cursor
+// @Description  switches to keyset pagination, which skips the count and stays stable while todos are added.
+// @Description  Pinned todos are listed first whatever the sort order, and archived todos are left out unless include_archived is set.
+// @Description  project_id limits the listing to one project, or with none to the todos without a project.
 // @Tags         todos
 // @Security     Bearer
 // @Produce      json
-// @Param        page       query     int     false  "Page number (default: 1)"
-// @Param        page_size  query     int     false  "Items per page (default: 10, max: 100)"
-// @Param        status     query     string  false  "Filter by status (pending, in_progress, completed)"
-// @Param        search     query     string  false  "Search in title and description"
-// @Param        sort_by    query     string  false  "Sort field (created_at, title, status, etc.)"
-// @Param        sort_dir   query     string  false  "Sort direction (ASC, DESC)"
-// @Success      200        {object}  utils.APIResponse{data=map[string]interface{}}
-// @Failure      401        {object}  utils.APIResponse
+// @Param        page              query     int     false  "Page number (default: 1)"
+// @Param        page_size         query     int     false  "Items per page (default: 10, max: 100)"
+// @Param        cursor            query     string  false  "Opaque cursor from a previous response; overrides page, sort_by and sort_dir"
+// @Param        status            query     string  false  "Filter by status (pending, in_progress, completed)"
+// @Param        search            query     string  false  "Full-text search in title and description; words match by prefix, \"quoted text\" matches a phrase"
+// @Param        tags              query     string  false  "Comma-separated tag IDs to filter by"
+// @Param        tag_mode          query     string  false  "Tag filter mode: any (default) matches todos with at least one of the tags, all requires every tag"
+// @Param        priority_min      query     string  false  "Lowest priority to include (none, low, medium, high, urgent)"
+// @Param        priority_max      query     string  false  "Highest priority to include (none, low, medium, high, urgent)"
+// @Param        include_archived  query     bool    false  "Also list archived todos"
+// @Param        project_id        query     string  false  "Project ID to filter by, or none for todos without a project"
+// @Param        assigned_to_me    query     bool    false  "Only list todos assigned to the authenticated user"
+// @Param        sort_by           query     string  false  "Sort field (relevance, smart, created_at, updated_at, title, status, priority, due_date); defaults to relevance when searching, created_at otherwise. smart puts overdue todos first, then orders by priority and due date"
+// @Param        sort_dir          query     string  false  "Sort direction (ASC, DESC)"
+// @Success      200               {object}  utils.APIResponse{data=map[string]interface{}}
+// @Failure      400               {object}  utils.APIResponse
+// @Failure      401               {object}  utils.APIResponse
 // @Router       /todos [get]
 func (h *TodoHandler) GetAll(c *gin.Context) {
 	userID := middleware.GetUserIDFromContext(c)
@@ -45,19 +86,84 @@ func (h *TodoHandler) GetAll(c *gin.Context) {
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
 	status := c.Query("status")
 	search := c.Query("search")
-	sortBy := c.DefaultQuery("sort_by", "created_at")
+	sortBy := c.Query("sort_by")
 	sortDir := c.DefaultQuery("sort_dir", "DESC")
+	tagMode := c.DefaultQuery("tag_mode", repository.TagModeAny)
+
+	tagIDs, err := parseIDList(c.QueryArray("tags"))
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid tags: expected comma-separated tag IDs")
+		return
+	}
+	if tagMode != repository.TagModeAny && tagMode != repository.TagModeAll {
+		utils.ValidationErrorResponse(c, "Invalid tag_mode: expected any or all")
+		return
+	}
+	priorityMin, err := priorityParam(c, "priority_min")
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid priority_min: "+err.Error())
+		return
+	}
+	priorityMax, err := priorityParam(c, "priority_max")
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid priority_max: "+err.Error())
+		return
+	}
+	includeArchived, err := strconv.ParseBool(c.DefaultQuery("include_archived", "false"))
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid include_archived: expected true or false")
+		return
+	}
+	projectID, err := projectParam(c)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid project_id: expected a project ID or none")
+		return
+	}
+	assignedToMe, err := strconv.ParseBool(c.DefaultQuery("assigned_to_me", "false"))
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid assigned_to_me: expected true or false")
+		return
+	}
 
 	params := repository.QueryParams{
-		Page:     page,
-		PageSize: pageSize,
-		Status:   status,
-		Search:   search,
-		SortBy:   sortBy,
-		SortDir:  sortDir,
+		Page:        page,
+		PageSize:    pageSize,
+		Status:      status,
+		Search:      search,
+		TagIDs:      tagIDs,
+		TagMode:     tagMode,
+		PriorityMin: priorityMin,
+		PriorityMax: priorityMax,
+		SortBy:      sortBy,
+		SortDir:     sortDir,
+
+		IncludeArchived: includeArchived,
+		ProjectID:       projectID,
+	}
+	if assignedToMe {
+		params.AssigneeID = &userID
+	}
+
+	// A cursor is only valid for the filters it was issued with
+	filterKey := listFilterKey(c)
+	if token := c.Query("cursor"); token != "" {
+		var cursor repository.Cursor
+		if err := utils.DecodeCursor(token, &cursor); err != nil {
+			utils.ValidationErrorResponse(c, "Invalid cursor")
+			return
+		}
+		if cursor.Filter != filterKey {
+			utils.ValidationErrorResponse(c, "Cursor does not match the current filters")
+			return
+		}
+		params.Cursor = &cursor
 	}
 
 	result, err := h.todoRepo.FindAllWithFilters(userID, params)
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		utils.ValidationErrorResponse(c, "Invalid cursor")
+		return
+	}
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch todos")
 		return
@@ -69,12 +175,27 @@ func (h *TodoHandler) GetAll(c *gin.Context) {
 		todosResponse = append(todosResponse, todo.ToResponse())
 	}
 
+	nextCursor := encodeCursor(result.NextCursor, filterKey)
+	prevCursor := encodeCursor(result.PrevCursor, filterKey)
+
+	if params.Cursor != nil {
+		utils.SuccessResponse(c, http.StatusOK, "Todos retrieved", gin.H{
+			"todos":       todosResponse,
+			"page_size":   result.PageSize,
+			"next_cursor": nextCursor,
+			"prev_cursor": prevCursor,
+		})
+		return
+	}
+
 	utils.SuccessResponse(c, http.StatusOK, "Todos retrieved", gin.H{
 		"todos":       todosResponse,
 		"total":       result.Total,
 		"page":        result.Page,
 		"page_size":   result.PageSize,
 		"total_pages": result.TotalPages,
+		"next_cursor": nextCursor,
+		"prev_cursor": prevCursor,
 	})
 }
 
@@ -84,39 +205,45 @@ func (h *TodoHandler) GetAll(c *gin.Context) {
 // @Tags         todos
 // @Security     Bearer
 // @Produce      json
-// @Param        id   path      int  true  "Todo ID"
-// @Success      200  {object}  utils.APIResponse{data=models.TodoResponse}
-// @Failure      401  {object}  utils.APIResponse
-// @Failure      404  {object}  utils.APIResponse
+// @Param        id             path      int     true   "Todo ID"
+// @Param        If-None-Match  header    string  false  "ETag of a cached copy; 304 when it is still current"
+// @Success      200            {object}  utils.APIResponse{data=models.TodoResponse}
+// @Success      304            "Not Modified"
+// @Failure      401            {object}  utils.APIResponse
+// @Failure      404            {object}  utils.APIResponse
+// @Header       200,304        {string}  ETag  "Entity tag of the todo's current state"
 // @Router       /todos/{id} [get]
 func (h *TodoHandler) GetByID(c *gin.Context) {
-	userID := middleware.GetUserIDFromContext(c)
-	todoID, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		utils.ValidationErrorResponse(c, "Invalid todo ID")
+	todo, ok := findAccessibleTodo(c, h.access, models.RoleViewer)
+	if !ok {
 		return
 	}
 
-	todo, err := h.todoRepo.FindByIDAndUserID(uint(todoID), userID)
-	if err != nil {
-		utils.ErrorResponse(c, http.StatusNotFound, "Todo not found")
+	etag := todoETag(todo)
+	c.Header("ETag", etag)
+	if header := c.GetHeader("If-None-Match"); header != "" && etagMatches(header, etag, true) {
+		c.Status(http.StatusNotModified)
 		return
 	}
-
 	utils.SuccessResponse(c, http.StatusOK, "Todo retrieved", todo.ToResponse())
 }
 
 // Create creates a new todo
 // @Summary      Create a todo
-// @Description  Create a new todo item for the authenticated user
+// @Description  Create a new todo item for the authenticated user.
+// @Description  Setting rrule (an RFC 5545 rule such as FREQ=WEEKLY;BYDAY=MO) with a due_date makes it recurring:
+// @Description  completing it creates the next instance, due at the next occurrence.
 // @Tags         todos
 // @Security     Bearer
 // @Accept       json
 // @Produce      json
-// @Param        request  body      models.CreateTodoRequest  true  "Todo Information"
-// @Success      201      {object}  utils.APIResponse{data=models.TodoResponse}
-// @Failure      400      {object}  utils.APIResponse
-// @Failure      401      {object}  utils.APIResponse
+// @Param        request          body      models.CreateTodoRequest  true   "Todo Information"
+// @Param        Idempotency-Key  header    string                    false  "Unique key that makes a retry return the first response"
+// @Success      201              {object}  utils.APIResponse{data=models.TodoResponse}
+// @Failure      400              {object}  utils.APIResponse
+// @Failure      401              {object}  utils.APIResponse
+// @Failure      409              {object}  utils.APIResponse
+// @Failure      422              {object}  utils.APIResponse
 // @Router       /todos [post]
 func (h *TodoHandler) Create(c *gin.Context) {
 	userID := middleware.GetUserIDFromContext(c)
@@ -133,12 +260,45 @@ func (h *TodoHandler) Create(c *gin.Context) {
 		status = models.StatusPending
 	}
 
+	tags, err := h.findTags(req.TagIDs, userID)
+	if err != nil {
+		h.tagErrorResponse(c, err)
+		return
+	}
+	if err := h.checkProject(req.ProjectID, userID); err != nil {
+		projectErrorResponse(c, err)
+		return
+	}
+
+	reminders, err := models.ParseReminderOffsets(req.Reminders)
+	if err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	var rule *recurrence.Rule
+	if req.RRule != "" {
+		if rule, err = recurrence.Parse(req.RRule); err != nil {
+			utils.ValidationErrorResponse(c, err.Error())
+			return
+		}
+		if req.DueDate == nil {
+			utils.ValidationErrorResponse(c, "A recurring todo needs a due_date")
+			return
+		}
+	}
+
 	todo := &models.Todo{
-		Title:       req.Title,
-		Description: req.Description,
-		Status:      status,
-		DueDate:     req.DueDate,
-		UserID:      userID,
+		Title:        req.Title,
+		Description:  req.Description,
+		Status:       status,
+		Priority:     req.Priority,
+		DueDate:      req.DueDate,
+		Reminders:    reminders,
+		AutoComplete: req.AutoComplete,
+		UserID:       userID,
+		Tags:         tags,
+		ProjectID:    req.ProjectID,
 	}
 
 	if err := h.todoRepo.Create(todo); err != nil {
@@ -146,87 +306,293 @@ func (h *TodoHandler) Create(c *gin.Context) {
 		return
 	}
 
+	if rule != nil {
+		if err := startSeries(h.seriesRepo, todo, rule); err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create recurrence")
+			return
+		}
+		if err := h.todoRepo.Update(todo); err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create recurrence")
+			return
+		}
+	}
+
+	publishTodo(h.access, models.EventTodoCreated, todo)
+	c.Header("ETag", todoETag(todo))
 	utils.SuccessResponse(c, http.StatusCreated, "Todo created", todo.ToResponse())
 }
 
-// Update updates an existing todo
-// @Summary      Update a todo
-// @Description  Update the details of an existing todo item
+// Update replaces an existing todo
+// @Summary      Replace a todo
+// @Description  Replace the editable fields of an existing todo item. Omitted fields take their defaults, as on creation;
+// @Description  use PATCH to change only some of them. For recurring todos, scope=this (default) changes only this instance;
+// @Description  scope=future also applies title, description, priority and rrule to the instances created after it.
+// @Description  An rrule that differs from the current one, including an empty one, needs scope=future.
+// @Description  With If-Match, the todo is only replaced if its ETag still matches, otherwise 412 is returned.
 // @Tags         todos
 // @Security     Bearer
 // @Accept       json
 // @Produce      json
-// @Param        id       path      int                       true  "Todo ID"
-// @Param        request  body      models.UpdateTodoRequest  true  "Updated Todo Info"
-// @Success      200      {object}  utils.APIResponse{data=models.TodoResponse}
-// @Failure      400      {object}  utils.APIResponse
-// @Failure      401      {object}  utils.APIResponse
-// @Failure      404      {object}  utils.APIResponse
+// @Param        id        path      int                       true   "Todo ID"
+// @Param        scope     query     string                    false  "this (default) or future"
+// @Param        If-Match  header    string                    false  "ETag the change is based on"
+// @Param        request   body      models.UpdateTodoRequest  true   "Complete Todo Info"
+// @Success      200       {object}  utils.APIResponse{data=models.TodoResponse}
+// @Failure      400       {object}  utils.APIResponse
+// @Failure      401       {object}  utils.APIResponse
+// @Failure      404       {object}  utils.APIResponse
+// @Failure      412       {object}  utils.APIResponse
+// @Header       200       {string}  ETag  "Entity tag of the updated todo"
 // @Router       /todos/{id} [put]
 func (h *TodoHandler) Update(c *gin.Context) {
-	userID := middleware.GetUserIDFromContext(c)
-	todoID, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		utils.ValidationErrorResponse(c, "Invalid todo ID")
+	todo, scope, ok := h.findForUpdate(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateTodoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid input: "+err.Error())
+		return
+	}
+
+	h.save(c, todo, req, scope)
+}
+
+// Patch changes some fields of an existing todo
+// @Summary      Patch a todo
+// @Description  Change some fields of an existing todo item. The body is either an RFC 7396 merge patch
+// @Description  (application/merge-patch+json, or application/json), where null clears a field, or an RFC 6902
+// @Description  JSON Patch (application/json-patch+json) such as [{"op": "remove", "path": "/due_date"}].
+// @Description  Patches apply to the fields of UpdateTodoRequest and the result is validated as a PUT would be.
+// @Description  A failed JSON Patch test operation returns 409. scope and If-Match work as for PUT.
+// @Tags         todos
+// @Security     Bearer
+// @Accept       json
+// @Accept       application/merge-patch+json
+// @Accept       application/json-patch+json
+// @Produce      json
+// @Param        id        path      int     true   "Todo ID"
+// @Param        scope     query     string  false  "this (default) or future"
+// @Param        If-Match  header    string  false  "ETag the change is based on"
+// @Param        request   body      object  true   "Merge patch or JSON Patch"
+// @Success      200       {object}  utils.APIResponse{data=models.TodoResponse}
+// @Failure      400       {object}  utils.APIResponse
+// @Failure      401       {object}  utils.APIResponse
+// @Failure      404       {object}  utils.APIResponse
+// @Failure      409       {object}  utils.APIResponse
+// @Failure      412       {object}  utils.APIResponse
+// @Failure      415       {object}  utils.APIResponse
+// @Header       200       {string}  ETag  "Entity tag of the updated todo"
+// @Router       /todos/{id} [patch]
+func (h *TodoHandler) Patch(c *gin.Context) {
+	todo, scope, ok := h.findForUpdate(c)
+	if !ok {
 		return
 	}
 
-	// Find existing todo
-	todo, err := h.todoRepo.FindByIDAndUserID(uint(todoID), userID)
+	var apply func(doc, patch []byte) ([]byte, error)
+	switch c.ContentType() {
+	case jsonpatch.MergePatchType, binding.MIMEJSON:
+		apply = jsonpatch.Merge
+	case jsonpatch.JSONPatchType:
+		apply = jsonpatch.Apply
+	default:
+		utils.ErrorResponse(c, http.StatusUnsupportedMediaType,
+			"Content-Type must be "+jsonpatch.MergePatchType+" or "+jsonpatch.JSONPatchType)
+		return
+	}
+
+	patch, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid input: "+err.Error())
+		return
+	}
+	doc, err := json.Marshal(todo.ToUpdateRequest())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update todo")
+		return
+	}
+	patched, err := apply(doc, patch)
+	if errors.Is(err, jsonpatch.ErrTestFailed) {
+		utils.ErrorResponse(c, http.StatusConflict, err.Error())
+		return
+	}
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusNotFound, "Todo not found")
+		utils.ValidationErrorResponse(c, err.Error())
 		return
 	}
 
 	var req models.UpdateTodoRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid input: "+err.Error())
+		return
+	}
+	if err := binding.Validator.ValidateStruct(&req); err != nil {
 		utils.ValidationErrorResponse(c, "Invalid input: "+err.Error())
 		return
 	}
 
-	// Update fields if provided
-	if req.Title != "" {
-		todo.Title = req.Title
+	h.save(c, todo, req, scope)
+}
+
+// findForUpdate loads the todo named in the path, checks it against
+// If-Match and reads the scope of an update. It returns false after
+// writing an error response.
+func (h *TodoHandler) findForUpdate(c *gin.Context) (*models.Todo, string, bool) {
+	scope := c.DefaultQuery("scope", scopeThis)
+	if scope != scopeThis && scope != scopeFuture {
+		utils.ValidationErrorResponse(c, "Invalid scope: expected this or future")
+		return nil, "", false
+	}
+
+	todo, ok := h.findMatching(c)
+	return todo, scope, ok
+}
+
+// findMatching loads the todo named in the path, which the user must be
+// allowed to edit, and checks it against If-Match. It returns false after
+// writing an error response.
+func (h *TodoHandler) findMatching(c *gin.Context) (*models.Todo, bool) {
+	todo, ok := findAccessibleTodo(c, h.access, models.RoleEditor)
+	if !ok {
+		return nil, false
+	}
+	if !checkIfMatch(c, todo) {
+		return nil, false
+	}
+	return todo, true
+}
+
+// save replaces the editable fields of todo with req and stores it,
+// writing the response
+func (h *TodoHandler) save(c *gin.Context, todo *models.Todo, req models.UpdateTodoRequest, scope string) {
+	wasCompleted := todo.Status == models.StatusCompleted
+
+	status := req.Status
+	if status == "" {
+		status = models.StatusPending
+	}
+	if !status.Valid() {
+		utils.ValidationErrorResponse(c, "Invalid status: expected pending, in_progress or completed")
+		return
+	}
+	if !req.Priority.Valid() {
+		utils.ValidationErrorResponse(c, models.ErrInvalidPriority.Error())
+		return
+	}
+	reminders, err := models.ParseReminderOffsets(req.Reminders)
+	if err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
 	}
-	if req.Description != "" {
-		todo.Description = req.Description
+	tags, err := h.findTags(req.TagIDs, todo.UserID)
+	if err != nil {
+		h.tagErrorResponse(c, err)
+		return
 	}
-	if req.Status != "" {
-		todo.Status = req.Status
+	if !sameID(req.ProjectID, todo.ProjectID) {
+		if err := h.checkProject(req.ProjectID, middleware.GetUserIDFromContext(c)); err != nil {
+			projectErrorResponse(c, err)
+			return
+		}
 	}
-	if req.DueDate != nil {
-		todo.DueDate = req.DueDate
+
+	todo.Title = req.Title
+	todo.Description = req.Description
+	todo.Status = status
+	todo.Priority = req.Priority
+	todo.DueDate = req.DueDate
+	todo.Reminders = reminders
+	todo.AutoComplete = req.AutoComplete
+	todo.Tags = tags
+	todo.ProjectID = req.ProjectID
+
+	// A new rule starts a series; changing or stopping an existing one
+	// affects later instances and so needs scope=future
+	var rule *recurrence.Rule
+	if req.RRule != "" {
+		if rule, err = recurrence.Parse(req.RRule); err != nil {
+			utils.ValidationErrorResponse(c, err.Error())
+			return
+		}
+	}
+	if todo.Series != nil && (rule == nil || rule.String() != todo.Series.RRule) && scope != scopeFuture {
+		utils.ValidationErrorResponse(c, "Changing the recurrence of a recurring todo needs scope=future")
+		return
+	}
+	switch {
+	case rule == nil:
+		todo.SeriesID, todo.Series = nil, nil
+	case todo.DueDate == nil:
+		utils.ValidationErrorResponse(c, "A recurring todo needs a due_date")
+		return
+	case todo.Series == nil:
+		if err := startSeries(h.seriesRepo, todo, rule); err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create recurrence")
+			return
+		}
+	case rule.String() != todo.Series.RRule:
+		// Re-anchor the new rule on this instance
+		todo.Series.RRule = rule.String()
+		todo.Series.DTStart = *todo.DueDate
 	}
 
-	if err := h.todoRepo.Update(todo); err != nil {
+	err = h.todoRepo.Update(todo)
+	if errors.Is(err, repository.ErrConflict) {
+		preconditionFailed(c)
+		return
+	}
+	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update todo")
 		return
 	}
 
-	// Enqueue notification if todo is completed
-	if todo.Status == "completed" {
-		worker.GlobalWorker.Enqueue(worker.Task{
-			Type: "TODO_COMPLETED_NOTIFICATION",
-			Payload: map[string]interface{}{
-				"id":    todo.ID,
-				"title": todo.Title,
-			},
-		})
+	// Future instances are created from the series template, which only
+	// changes once the version-checked todo update has gone through
+	if scope == scopeFuture && todo.Series != nil {
+		todo.Series.Title = todo.Title
+		todo.Series.Description = todo.Description
+		todo.Series.Priority = todo.Priority
+		if err := h.seriesRepo.Update(todo.Series); err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update recurrence")
+			return
+		}
 	}
 
+	publishTodo(h.access, models.EventTodoUpdated, todo)
+
+	// Enqueue notification when the todo has just been completed
+	if todo.Status == models.StatusCompleted && !wasCompleted {
+		notifyCompleted(h.access, todo)
+
+		if _, err := scheduleNext(h.access, h.todoRepo, h.seriesRepo, todo); err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to schedule next occurrence")
+			return
+		}
+	}
+
+	c.Header("ETag", todoETag(todo))
 	utils.SuccessResponse(c, http.StatusOK, "Todo updated", todo.ToResponse())
 }
 
 // Delete deletes a todo
 // @Summary      Delete a todo
-// @Description  Remove a specific todo item permanently
+// @Description  Move a todo to the trash, from which it can be restored until it is purged after the retention period.
+// @Description  With permanent=true the todo is deleted for good, whether or not it is already in the trash.
 // @Tags         todos
 // @Security     Bearer
 // @Produce      json
-// @Param        id   path      int  true  "Todo ID"
-// @Success      200  {object}  utils.APIResponse
-// @Failure      401  {object}  utils.APIResponse
-// @Failure      404  {object}  utils.APIResponse
+// @Param        id         path      int     true   "Todo ID"
+// @Param        permanent  query     bool    false  "Delete for good instead of moving to the trash"
+// @Param        If-Match   header    string  false  "ETag the deletion is based on"
+// @Success      200        {object}  utils.APIResponse
+// @Failure      400        {object}  utils.APIResponse
+// @Failure      401        {object}  utils.APIResponse
+// @Failure      404        {object}  utils.APIResponse
+// @Failure      412        {object}  utils.APIResponse
 // @Router       /todos/{id} [delete]
 func (h *TodoHandler) Delete(c *gin.Context) {
 	userID := middleware.GetUserIDFromContext(c)
@@ -235,18 +601,261 @@ func (h *TodoHandler) Delete(c *gin.Context) {
 		utils.ValidationErrorResponse(c, "Invalid todo ID")
 		return
 	}
+	permanent, err := strconv.ParseBool(c.DefaultQuery("permanent", "false"))
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid permanent: expected true or false")
+		return
+	}
 
-	// Verify todo exists and belongs to user
-	_, err = h.todoRepo.FindByIDAndUserID(uint(todoID), userID)
+	// Editors may move a todo to the trash, but only owners may delete it
+	// for good; a permanent deletion may also empty it from the trash
+	need := models.RoleEditor
+	if permanent {
+		need = models.RoleOwner
+	}
+	todo, err := h.access.Todo(uint(todoID), userID, need)
+	trashed := false
+	if permanent && errors.Is(err, repository.ErrNotFound) {
+		todo, err = h.access.DeletedTodo(uint(todoID), userID, need)
+		trashed = err == nil
+	}
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusNotFound, "Todo not found")
+		accessErrorResponse(c, err, "Todo not found")
+		return
+	}
+	if !checkIfMatch(c, todo) {
 		return
 	}
 
-	if err := h.todoRepo.Delete(uint(todoID), userID); err != nil {
+	if !permanent {
+		if err := h.todoRepo.Delete(todo.ID, todo.UserID); err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to delete todo")
+			return
+		}
+		publishTodo(h.access, models.EventTodoDeleted, todo)
+		utils.SuccessResponse(c, http.StatusOK, "Todo moved to trash", nil)
+		return
+	}
+
+	if err := h.todoRepo.Purge(todo.ID, todo.UserID); err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to delete todo")
 		return
 	}
+	// Subscribers were told when it went to the trash
+	if !trashed {
+		publishTodo(h.access, models.EventTodoDeleted, todo)
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Todo deleted permanently", nil)
+}
+
+// publishTodo announces a change to todo to everyone who can see it, e.g.
+// to the streams and webhooks of its project's owner and members, or of its
+// creator outside projects. If the project's members cannot be loaded, only
+// those found are told.
+func publishTodo(checker *access.Checker, event string, todo *models.Todo) {
+	users, err := checker.Audience(todo)
+	if err != nil {
+		slog.Error("Failed to load who to tell about a todo", slog.Uint64("todo_id", uint64(todo.ID)), slog.Any("error", err))
+	}
+	data := todo.ToResponse()
+	for _, userID := range users {
+		events.Publish(events.New(event, userID, data))
+	}
+}
+
+// notifyCompleted publishes the completion of todo and enqueues the
+// completion email
+func notifyCompleted(checker *access.Checker, todo *models.Todo) {
+	publishTodo(checker, models.EventTodoCompleted, todo)
+	worker.GlobalWorker.Enqueue(worker.Task{
+		Type: worker.TaskTodoCompleted,
+		Payload: worker.TodoCompletedPayload{
+			ID:     todo.ID,
+			UserID: todo.UserID,
+			Title:  todo.Title,
+		},
+	})
+}
 
-	utils.SuccessResponse(c, http.StatusOK, "Todo deleted", nil)
+// findTags loads the tags to assign to a todo, failing with errUnknownTag
+// if any ID does not belong to the user
+func (h *TodoHandler) findTags(ids []uint, userID uint) ([]models.Tag, error) {
+	tags, err := h.tagRepo.FindByIDsAndUserID(ids, userID)
+	if err != nil {
+		return nil, err
+	}
+	requested := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		requested[id] = true
+	}
+	if len(tags) != len(requested) {
+		return nil, errUnknownTag
+	}
+	return tags, nil
+}
+
+func (h *TodoHandler) tagErrorResponse(c *gin.Context, err error) {
+	if errors.Is(err, errUnknownTag) {
+		utils.ValidationErrorResponse(c, "Unknown tag in tag_ids")
+		return
+	}
+	utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to load tags")
+}
+
+// checkProject checks that the user may add todos to a project, failing
+// with errUnknownProject if they cannot see it and access.ErrForbidden if
+// they may not edit it; nil means no project
+func (h *TodoHandler) checkProject(id *uint, userID uint) error {
+	if id == nil {
+		return nil
+	}
+	_, _, err := h.access.Project(*id, userID, models.RoleEditor)
+	if errors.Is(err, repository.ErrNotFound) {
+		return errUnknownProject
+	}
+	return err
+}
+
+func projectErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errUnknownProject):
+		utils.ValidationErrorResponse(c, "Unknown project in project_id")
+	case errors.Is(err, access.ErrForbidden):
+		utils.ErrorResponse(c, http.StatusForbidden, "Your role on the project in project_id does not allow adding todos")
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to load project")
+	}
+}
+
+// sameID reports whether two optional IDs are both unset or equal
+func sameID(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// todoETag is the entity tag of the todo as GET /todos/:id returns it.
+// The version changes with the todo's own fields; the hash covers what it
+// embeds, such as checklist items and tag names. Timestamps are left out:
+// the todo just written still holds them at the client's precision and
+// offset, not as the database returns them.
+func todoETag(todo *models.Todo) string {
+	hash := sha256.New()
+	for _, tag := range todo.Tags {
+		fmt.Fprintf(hash, "tag %d %q %q\n", tag.ID, tag.Name, tag.Color)
+	}
+	for _, item := range todo.Items {
+		fmt.Fprintf(hash, "item %d %q %t %d\n", item.ID, item.Title, item.Done, item.Position)
+	}
+	if todo.Series != nil {
+		fmt.Fprintf(hash, "rrule %q\n", todo.Series.RRule)
+	}
+	return fmt.Sprintf(`"%d-%s"`, todo.Version, hex.EncodeToString(hash.Sum(nil)[:6]))
+}
+
+// etagMatches reports whether an If-Match or If-None-Match header lists
+// etag or is "*". If-None-Match compares weakly, ignoring W/ prefixes.
+func etagMatches(header, etag string, weak bool) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if weak {
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIfMatch enforces an If-Match header against the todo's current
+// ETag. It returns false after writing a 412 response.
+func checkIfMatch(c *gin.Context, todo *models.Todo) bool {
+	header := c.GetHeader("If-Match")
+	if header == "" || etagMatches(header, todoETag(todo), false) {
+		return true
+	}
+	preconditionFailed(c)
+	return false
+}
+
+func preconditionFailed(c *gin.Context) {
+	utils.ErrorResponse(c, http.StatusPreconditionFailed, "Todo was changed by another request; fetch it again and retry")
+}
+
+// priorityParam reads an optional priority name from the query string
+func priorityParam(c *gin.Context, key string) (*models.Priority, error) {
+	name := c.Query(key)
+	if name == "" {
+		return nil, nil
+	}
+	priority, err := models.ParsePriority(name)
+	if err != nil {
+		return nil, err
+	}
+	return &priority, nil
+}
+
+// projectParam reads the project_id filter, where "none" selects the
+// todos without a project and is returned as 0
+func projectParam(c *gin.Context) (*uint, error) {
+	value := c.Query("project_id")
+	switch value {
+	case "":
+		return nil, nil
+	case "none":
+		var none uint
+		return &none, nil
+	}
+	id, err := strconv.ParseUint(value, 10, 0)
+	if err != nil || id == 0 {
+		return nil, errors.New("invalid project ID")
+	}
+	projectID := uint(id)
+	return &projectID, nil
+}
+
+// parseIDList reads IDs given as repeated and/or comma-separated query values
+func parseIDList(values []string) ([]uint, error) {
+	var ids []uint
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			id, err := strconv.ParseUint(part, 10, 32)
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, uint(id))
+		}
+	}
+	return ids, nil
+}
+
+// listFilterKey fingerprints the filter parameters of a listing request so a
+// cursor cannot be replayed against a different result set
+func listFilterKey(c *gin.Context) string {
+	filters := c.Request.URL.Query()
+	for _, key := range []string{"cursor", "page", "page_size", "sort_by", "sort_dir"} {
+		filters.Del(key)
+	}
+	sum := sha256.Sum256([]byte(filters.Encode()))
+	return hex.EncodeToString(sum[:8])
+}
+
+// encodeCursor signs a repository cursor for the response, returning nil
+// when there is no neighbouring page
+func encodeCursor(cursor *repository.Cursor, filterKey string) interface{} {
+	if cursor == nil {
+		return nil
+	}
+	cursor.Filter = filterKey
+	token, err := utils.EncodeCursor(cursor)
+	if err != nil {
+		return nil
+	}
+	return token
 }
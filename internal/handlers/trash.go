@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/go-todo-api/internal/middleware"
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository"
+	"github.com/user/go-todo-api/pkg/utils"
+)
+
+// Trash returns the deleted todos the user can see
+// @Summary      List deleted todos
+// @Description  Get the todos in the trash, most recently deleted first: the user's own and those deleted
+// @Description  from the projects they own or are a member of. They are purged for good once
+// @Description  the retention period (30 days by default) has passed since their deletion.
+// @Tags         todos
+// @Security     Bearer
+// @Produce      json
+// @Success      200  {object}  utils.APIResponse{data=[]models.TodoResponse}
+// @Failure      401  {object}  utils.APIResponse
+// @Router       /todos/trash [get]
+func (h *TodoHandler) Trash(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+
+	todos, err := h.todoRepo.FindDeletedByUserID(userID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch deleted todos")
+		return
+	}
+
+	responses := make([]models.TodoResponse, 0, len(todos))
+	for i := range todos {
+		responses = append(responses, todos[i].ToResponse())
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Deleted todos retrieved", responses)
+}
+
+// Restore takes a todo out of the trash
+// @Summary      Restore a deleted todo
+// @Description  Move a todo from the trash back to its creator's todos, with its checklist and tags.
+// @Description  Todos deleted from a project can be restored by its owner and editors.
+// @Tags         todos
+// @Security     Bearer
+// @Produce      json
+// @Param        id   path      int  true  "Todo ID"
+// @Success      200  {object}  utils.APIResponse{data=models.TodoResponse}
+// @Header       200  {string}  ETag  "Entity tag of the restored todo"
+// @Failure      400  {object}  utils.APIResponse
+// @Failure      401  {object}  utils.APIResponse
+// @Failure      403  {object}  utils.APIResponse
+// @Failure      404  {object}  utils.APIResponse
+// @Router       /todos/{id}/restore [post]
+func (h *TodoHandler) Restore(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	todoID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid todo ID")
+		return
+	}
+
+	// Whoever may move a todo to the trash may take it out again
+	todo, err := h.access.DeletedTodo(uint(todoID), userID, models.RoleEditor)
+	if err != nil {
+		accessErrorResponse(c, err, "Todo not found in trash")
+		return
+	}
+
+	err = h.todoRepo.Restore(todo.ID, todo.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		utils.ErrorResponse(c, http.StatusNotFound, "Todo not found in trash")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to restore todo")
+		return
+	}
+
+	todo, err = h.todoRepo.FindByID(todo.ID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch restored todo")
+		return
+	}
+	publishTodo(h.access, models.EventTodoRestored, todo)
+
+	c.Header("ETag", todoETag(todo))
+	utils.SuccessResponse(c, http.StatusOK, "Todo restored", todo.ToResponse())
+}
@@ -0,0 +1,323 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/go-todo-api/internal/events"
+	"github.com/user/go-todo-api/internal/middleware"
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository"
+	"github.com/user/go-todo-api/internal/worker"
+	"github.com/user/go-todo-api/pkg/utils"
+)
+
+// Delivery log page sizes
+const (
+	defaultDeliveryLimit = 20
+	maxDeliveryLimit     = 100
+)
+
+type WebhookHandler struct {
+	webhookRepo repository.WebhookRepository
+}
+
+func NewWebhookHandler(webhookRepo repository.WebhookRepository) *WebhookHandler {
+	return &WebhookHandler{
+		webhookRepo: webhookRepo,
+	}
+}
+
+// GetAll returns all webhooks of the authenticated user
+// @Summary      Get all webhooks
+// @Description  Get the authenticated user's webhook subscriptions. Secrets are not included.
+// @Tags         webhooks
+// @Security     Bearer
+// @Produce      json
+// @Success      200  {object}  utils.APIResponse{data=[]models.WebhookResponse}
+// @Failure      401  {object}  utils.APIResponse
+// @Router       /webhooks [get]
+func (h *WebhookHandler) GetAll(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+
+	hooks, err := h.webhookRepo.FindAllByUserID(userID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch webhooks")
+		return
+	}
+
+	hooksResponse := make([]models.WebhookResponse, 0, len(hooks))
+	for _, hook := range hooks {
+		hooksResponse = append(hooksResponse, hook.ToResponse())
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Webhooks retrieved", hooksResponse)
+}
+
+// GetByID returns a single webhook by ID
+// @Summary      Get webhook by ID
+// @Description  Get a specific webhook of the authenticated user
+// @Tags         webhooks
+// @Security     Bearer
+// @Produce      json
+// @Param        id   path      int  true  "Webhook ID"
+// @Success      200  {object}  utils.APIResponse{data=models.WebhookResponse}
+// @Failure      401  {object}  utils.APIResponse
+// @Failure      404  {object}  utils.APIResponse
+// @Router       /webhooks/{id} [get]
+func (h *WebhookHandler) GetByID(c *gin.Context) {
+	hook, ok := h.findWebhook(c)
+	if !ok {
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Webhook retrieved", hook.ToResponse())
+}
+
+// Create subscribes a URL to todo events
+// @Summary      Create a webhook
+// @Description  Subscribe a URL to todo events (todo.created, todo.updated, todo.completed, todo.deleted, todo.restored; all of them
+// @Description  when events is empty). Deliveries are signed with the secret, which is generated when omitted and only
+// @Description  returned by this call. The URL must not point at localhost or a loopback, private, link-local or otherwise reserved address.
+// @Tags         webhooks
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.CreateWebhookRequest  true  "Webhook Information"
+// @Success      201      {object}  utils.APIResponse{data=models.WebhookResponse}
+// @Failure      400      {object}  utils.APIResponse
+// @Failure      401      {object}  utils.APIResponse
+// @Router       /webhooks [post]
+func (h *WebhookHandler) Create(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+
+	var req models.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid input: "+err.Error())
+		return
+	}
+	if err := checkWebhookURL(req.URL); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid url: "+err.Error())
+		return
+	}
+
+	subscribed, err := models.ParseWebhookEvents(req.Events)
+	if err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		secret = newWebhookSecret()
+	}
+
+	hook := &models.Webhook{
+		UserID: userID,
+		URL:    req.URL,
+		Secret: secret,
+		Events: subscribed,
+		Active: req.Active == nil || *req.Active,
+	}
+	if err := h.webhookRepo.Create(hook); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create webhook")
+		return
+	}
+
+	response := hook.ToResponse()
+	response.Secret = hook.Secret
+	utils.SuccessResponse(c, http.StatusCreated, "Webhook created", response)
+}
+
+// Update changes a webhook
+// @Summary      Update a webhook
+// @Description  Change the URL, events, secret or active flag of a webhook; omitted fields are left unchanged.
+// @Description  A paused (inactive) webhook receives no events.
+// @Tags         webhooks
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                          true  "Webhook ID"
+// @Param        request  body      models.UpdateWebhookRequest  true  "Updated Webhook Info"
+// @Success      200      {object}  utils.APIResponse{data=models.WebhookResponse}
+// @Failure      400      {object}  utils.APIResponse
+// @Failure      401      {object}  utils.APIResponse
+// @Failure      404      {object}  utils.APIResponse
+// @Router       /webhooks/{id} [put]
+func (h *WebhookHandler) Update(c *gin.Context) {
+	hook, ok := h.findWebhook(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid input: "+err.Error())
+		return
+	}
+
+	if req.URL != nil {
+		if err := checkWebhookURL(*req.URL); err != nil {
+			utils.ValidationErrorResponse(c, "Invalid url: "+err.Error())
+			return
+		}
+		hook.URL = *req.URL
+	}
+	if req.Events != nil {
+		subscribed, err := models.ParseWebhookEvents(*req.Events)
+		if err != nil {
+			utils.ValidationErrorResponse(c, err.Error())
+			return
+		}
+		hook.Events = subscribed
+	}
+	if req.Secret != nil {
+		hook.Secret = *req.Secret
+	}
+	if req.Active != nil {
+		hook.Active = *req.Active
+	}
+
+	if err := h.webhookRepo.Update(hook); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update webhook")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Webhook updated", hook.ToResponse())
+}
+
+// Delete deletes a webhook
+// @Summary      Delete a webhook
+// @Description  Stop delivering events to a webhook and delete its delivery log
+// @Tags         webhooks
+// @Security     Bearer
+// @Produce      json
+// @Param        id   path      int  true  "Webhook ID"
+// @Success      200  {object}  utils.APIResponse
+// @Failure      401  {object}  utils.APIResponse
+// @Failure      404  {object}  utils.APIResponse
+// @Router       /webhooks/{id} [delete]
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	hook, ok := h.findWebhook(c)
+	if !ok {
+		return
+	}
+
+	if err := h.webhookRepo.Delete(hook.ID, hook.UserID); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to delete webhook")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Webhook deleted", nil)
+}
+
+// Deliveries returns the delivery log of a webhook
+// @Summary      List webhook deliveries
+// @Description  Get the latest delivery attempts of a webhook, newest first. Every retry is listed separately.
+// @Tags         webhooks
+// @Security     Bearer
+// @Produce      json
+// @Param        id     path      int  true   "Webhook ID"
+// @Param        limit  query     int  false  "Number of deliveries (default 20, max 100)"
+// @Success      200    {object}  utils.APIResponse{data=[]models.WebhookDeliveryResponse}
+// @Failure      400    {object}  utils.APIResponse
+// @Failure      401    {object}  utils.APIResponse
+// @Failure      404    {object}  utils.APIResponse
+// @Router       /webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) Deliveries(c *gin.Context) {
+	hook, ok := h.findWebhook(c)
+	if !ok {
+		return
+	}
+
+	limit := defaultDeliveryLimit
+	if s := c.Query("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxDeliveryLimit {
+			utils.ValidationErrorResponse(c, "Invalid limit: expected 1 to 100")
+			return
+		}
+		limit = n
+	}
+
+	deliveries, err := h.webhookRepo.FindDeliveries(hook.ID, limit)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch deliveries")
+		return
+	}
+
+	deliveriesResponse := make([]models.WebhookDeliveryResponse, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		deliveriesResponse = append(deliveriesResponse, delivery.ToResponse())
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Deliveries retrieved", deliveriesResponse)
+}
+
+// Test sends a test event to a webhook
+// @Summary      Send a test event
+// @Description  Queue a "ping" event for the webhook, even when it is paused. The attempt shows up in its delivery log.
+// @Tags         webhooks
+// @Security     Bearer
+// @Produce      json
+// @Param        id   path      int  true  "Webhook ID"
+// @Success      202  {object}  utils.APIResponse{data=models.WebhookTestResponse}
+// @Failure      401  {object}  utils.APIResponse
+// @Failure      404  {object}  utils.APIResponse
+// @Router       /webhooks/{id}/test [post]
+func (h *WebhookHandler) Test(c *gin.Context) {
+	hook, ok := h.findWebhook(c)
+	if !ok {
+		return
+	}
+
+	event := events.New(models.EventPing, hook.UserID, gin.H{"webhook_id": hook.ID})
+	if err := worker.GlobalWorker.EnqueueWebhook(hook, event); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to queue test event")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusAccepted, "Test event queued", models.WebhookTestResponse{EventID: event.ID})
+}
+
+// findWebhook loads the webhook named by the :id parameter for the
+// authenticated user, writing the error response when there is none
+func (h *WebhookHandler) findWebhook(c *gin.Context) (*models.Webhook, bool) {
+	userID := middleware.GetUserIDFromContext(c)
+	hookID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid webhook ID")
+		return nil, false
+	}
+
+	hook, err := h.webhookRepo.FindByIDAndUserID(uint(hookID), userID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Webhook not found")
+		return nil, false
+	}
+	return hook, true
+}
+
+// checkWebhookURL checks that s is an absolute http(s) URL whose host is
+// not a loopback, private, link-local or otherwise reserved address
+func checkWebhookURL(s string) error {
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("expected an http or https URL")
+	}
+	if err := worker.CheckWebhookHost(u.Hostname()); err != nil {
+		return errors.New("the host must not be a loopback, private, link-local or otherwise reserved address")
+	}
+	return nil
+}
+
+// newWebhookSecret returns a random signing secret
+func newWebhookSecret() string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	return "whsec_" + hex.EncodeToString(b)
+}
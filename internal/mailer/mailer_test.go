@@ -0,0 +1,185 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderEscapesHTML(t *testing.T) {
+	msg, err := Render(TemplateCompleted, "ada@example.com", map[string]string{"Name": "Ada", "Title": "<b>Taxes</b>"})
+	require.NoError(t, err)
+	assert.Equal(t, "ada@example.com", msg.To)
+	assert.Equal(t, "Completed: <b>Taxes</b>", msg.Subject)
+	assert.Contains(t, msg.Text, `You completed "<b>Taxes</b>".`)
+	assert.Contains(t, msg.HTML, "<strong>&lt;b&gt;Taxes&lt;/b&gt;</strong>")
+
+	_, err = Render("no-such-email", "ada@example.com", nil)
+	assert.Error(t, err)
+}
+
+// parse reads a built message back into its headers and text and HTML parts
+func parse(t *testing.T, data []byte) (*mail.Message, map[string]string) {
+	t.Helper()
+	m, err := mail.ReadMessage(strings.NewReader(string(data)))
+	require.NoError(t, err)
+	mediaType, params, err := mime.ParseMediaType(m.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	require.Equal(t, "multipart/alternative", mediaType)
+
+	parts := make(map[string]string)
+	r := multipart.NewReader(m.Body, params["boundary"])
+	for {
+		p, err := r.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		body, err := io.ReadAll(quotedprintable.NewReader(p))
+		require.NoError(t, err)
+		contentType, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		parts[contentType] = string(body)
+	}
+	return m, parts
+}
+
+func TestOutboxWritesEmlFiles(t *testing.T) {
+	dir := t.TempDir()
+	outbox := NewOutbox(dir, "Todo API <no-reply@example.com>")
+	msg := Message{To: "Zoë <zoe@example.com>", Subject: "Grüße", Text: "plain", HTML: "<p>html</p>"}
+	require.NoError(t, outbox.Send(context.Background(), msg))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+
+	m, parts := parse(t, data)
+	subject, err := new(mime.WordDecoder).DecodeHeader(m.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "Grüße", subject)
+	assert.Contains(t, m.Header.Get("Message-Id"), "@example.com>")
+	assert.Equal(t, "plain", parts["text/plain"])
+	assert.Equal(t, "<p>html</p>", parts["text/html"])
+
+	assert.Error(t, outbox.Send(context.Background(), Message{To: "not an address"}))
+}
+
+// smtpSink is a minimal SMTP server that accepts one message per
+// connection, without STARTTLS or authentication
+type smtpSink struct {
+	ln       net.Listener
+	from, to string
+	data     chan []byte
+}
+
+func newSMTPSink(t *testing.T) *smtpSink {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+	s := &smtpSink{ln: ln, data: make(chan []byte, 1)}
+	go s.serve()
+	return s
+}
+
+func (s *smtpSink) port() int { return s.ln.Addr().(*net.TCPAddr).Port }
+
+func (s *smtpSink) serve() {
+	conn, err := s.ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+	reply("220 sink ready")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimSpace(line)
+		switch verb := strings.ToUpper(strings.Fields(cmd + " ")[0]); verb {
+		case "EHLO", "HELO":
+			reply("250 sink")
+		case "MAIL":
+			s.from = cmd
+			reply("250 OK")
+		case "RCPT":
+			s.to = cmd
+			reply("250 OK")
+		case "DATA":
+			reply("354 go ahead")
+			var msg strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				msg.WriteString(strings.TrimPrefix(line, "."))
+			}
+			s.data <- []byte(msg.String())
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func TestSMTPMailerDeliversToSink(t *testing.T) {
+	sink := newSMTPSink(t)
+	m := NewSMTPMailer(SMTPConfig{Host: "127.0.0.1", Port: sink.port(), From: "Todo API <no-reply@example.com>"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	msg, err := Render(TemplateWelcome, `"Ada" <ada@example.com>`, map[string]string{"Name": "Ada"})
+	require.NoError(t, err)
+	require.NoError(t, m.Send(ctx, msg))
+
+	got, parts := parse(t, <-sink.data)
+	assert.Equal(t, "MAIL FROM:<no-reply@example.com>", sink.from)
+	assert.Equal(t, "RCPT TO:<ada@example.com>", sink.to)
+	assert.Equal(t, "Welcome to Todo, Ada!", got.Header.Get("Subject"))
+	assert.Contains(t, parts["text/plain"], "Hi Ada,")
+	assert.Contains(t, parts["text/html"], "<p>Hi Ada,</p>")
+}
+
+func TestSMTPMailerRequiresTLS(t *testing.T) {
+	sink := newSMTPSink(t)
+	m := NewSMTPMailer(SMTPConfig{Host: "127.0.0.1", Port: sink.port(), From: "no-reply@example.com", RequireTLS: true})
+	err := m.Send(context.Background(), Message{To: "ada@example.com", Subject: "Hi"})
+	assert.ErrorIs(t, err, ErrTLSUnavailable)
+}
+
+func TestSMTPMailerDialFailure(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	m := NewSMTPMailer(SMTPConfig{Host: "127.0.0.1", Port: port, From: "no-reply@example.com"})
+	err = m.Send(context.Background(), Message{To: "ada@example.com"})
+	assert.ErrorContains(t, err, strconv.Itoa(port))
+}
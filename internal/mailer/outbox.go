@@ -0,0 +1,61 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Outbox is the development mailer: it writes each message to an .eml
+// file in Dir, which any mail client can open, or only logs it when Dir
+// is empty
+type Outbox struct {
+	Dir  string
+	From string
+}
+
+func NewOutbox(dir, from string) *Outbox {
+	return &Outbox{Dir: dir, From: from}
+}
+
+func (o *Outbox) Send(ctx context.Context, msg Message) error {
+	data, err := build(o.From, msg)
+	if err != nil {
+		return err
+	}
+
+	if o.Dir == "" {
+		slog.Info("EMAIL (outbox)",
+			slog.String("to", msg.To),
+			slog.String("subject", msg.Subject),
+			slog.String("text", msg.Text),
+		)
+		return nil
+	}
+
+	if err := os.MkdirAll(o.Dir, 0o755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), fileSafe(msg.To))
+	path := filepath.Join(o.Dir, name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return err
+	}
+	slog.Info("EMAIL written to outbox", slog.String("to", msg.To), slog.String("subject", msg.Subject), slog.String("path", path))
+	return nil
+}
+
+// fileSafe keeps the letters, digits and a few punctuation marks of s
+func fileSafe(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '@', r == '.', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, s)
+}
@@ -0,0 +1,65 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Email templates. Each has a NAME.txt template, which also defines the
+// "subject", and a NAME.html template, both executed with the same data.
+const (
+	TemplateWelcome   = "welcome"
+	TemplateReminder  = "reminder"
+	TemplateCompleted = "completed"
+	TemplateAssigned  = "assigned"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+type emailTemplate struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// templates are parsed one email at a time, since they all define "subject"
+var templates = func() map[string]emailTemplate {
+	m := make(map[string]emailTemplate)
+	for _, name := range []string{TemplateWelcome, TemplateReminder, TemplateCompleted, TemplateAssigned} {
+		m[name] = emailTemplate{
+			text: texttemplate.Must(texttemplate.ParseFS(templateFS, "templates/"+name+".txt")),
+			html: htmltemplate.Must(htmltemplate.ParseFS(templateFS, "templates/"+name+".html")),
+		}
+	}
+	return m
+}()
+
+// Render builds the message for template name addressed to to, with data
+// available to the templates as the dot
+func Render(name, to string, data interface{}) (Message, error) {
+	tmpl, ok := templates[name]
+	if !ok {
+		return Message{}, fmt.Errorf("unknown email template %q", name)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, err
+	}
+	if err := tmpl.text.Execute(&text, data); err != nil {
+		return Message{}, err
+	}
+	if err := tmpl.html.Execute(&html, data); err != nil {
+		return Message{}, err
+	}
+	return Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    html.String(),
+	}, nil
+}
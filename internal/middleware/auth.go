@@ -9,23 +9,47 @@ import (
 )
 
 func AuthMiddleware() gin.HandlerFunc {
+	return authenticate(nil)
+}
+
+// StreamAuthMiddleware is AuthMiddleware for the streaming endpoints. As
+// browsers cannot set headers on EventSource and WebSocket requests, it
+// also accepts one of tickets in the ticket query parameter.
+func StreamAuthMiddleware(tickets *StreamTickets) gin.HandlerFunc {
+	return authenticate(tickets)
+}
+
+func authenticate(tickets *StreamTickets) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			utils.ErrorResponse(c, http.StatusUnauthorized, "Authorization header is required")
-			c.Abort()
+		tokenString := ""
+		switch {
+		case authHeader != "":
+			// Check Bearer prefix
+			parts := strings.Split(authHeader, " ")
+			if len(parts) != 2 || parts[0] != "Bearer" {
+				utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid authorization header format")
+				c.Abort()
+				return
+			}
+			tokenString = parts[1]
+		case tickets != nil && c.Query("ticket") != "":
+			ticket, ok := tickets.redeem(c.Query("ticket"))
+			if !ok {
+				utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid or expired ticket")
+				c.Abort()
+				return
+			}
+			c.Set("userID", ticket.userID)
+			c.Set("userEmail", ticket.email)
+			c.Next()
 			return
-		}
-
-		// Check Bearer prefix
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid authorization header format")
+		default:
+			utils.ErrorResponse(c, http.StatusUnauthorized, "Authorization header is required")
 			c.Abort()
 			return
 		}
 
-		tokenString := parts[1]
 		claims, err := utils.ValidateToken(tokenString)
 		if err != nil {
 			utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid or expired token")
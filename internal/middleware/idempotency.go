@@ -0,0 +1,205 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository"
+	"github.com/user/go-todo-api/pkg/utils"
+)
+
+// IdempotencyHeader is the request header that makes a mutating request
+// safe to retry
+const IdempotencyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength bounds the keys clients may send
+const maxIdempotencyKeyLength = 255
+
+// replayedHeaders are the response headers stored with a response and sent
+// again when it is replayed
+var replayedHeaders = []string{"Content-Type", "Location", "ETag"}
+
+// IdempotencyMiddleware makes POST, PUT, PATCH and DELETE requests that
+// carry an Idempotency-Key header safe to retry. The first request with a
+// key runs and its response is kept for ttl; retries get that response
+// back, marked with an Idempotent-Replayed header, instead of running
+// again. Keys are scoped to the user, method and path.
+//
+// Reusing a key with a different request fails with 422, and a retry that
+// arrives while the first request is still running fails with 409. Server
+// errors are not kept, so the request can be retried. It must run after
+// AuthMiddleware.
+func IdempotencyMiddleware(keys repository.IdempotencyRepository, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyHeader)
+		if key == "" || !isMutating(c.Request.Method) {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			utils.ValidationErrorResponse(c, "Idempotency-Key must be at most 255 characters")
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			utils.ValidationErrorResponse(c, "Failed to read request body")
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		record := &models.IdempotencyKey{
+			UserID:      GetUserIDFromContext(c),
+			Method:      c.Request.Method,
+			Path:        c.Request.URL.Path,
+			Key:         key,
+			RequestHash: requestHash(c.Request, body),
+			ExpiresAt:   models.Now().Add(ttl),
+		}
+		existing, err := reserve(keys, record)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to check Idempotency-Key")
+			c.Abort()
+			return
+		}
+		if existing != nil {
+			switch {
+			case existing.RequestHash != record.RequestHash:
+				utils.ErrorResponse(c, http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request")
+			case !existing.Completed():
+				c.Header("Retry-After", "1")
+				utils.ErrorResponse(c, http.StatusConflict, "A request with this Idempotency-Key is still being processed")
+			default:
+				replay(c, existing)
+			}
+			c.Abort()
+			return
+		}
+
+		// Until the response is stored the key is released on every way
+		// out, panics included, so a retry can run the request again
+		stored := false
+		defer func() {
+			if !stored {
+				if err := keys.Delete(record.ID); err != nil {
+					slog.Error("Failed to release idempotency key", slog.Any("error", err))
+				}
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		if recorder.Status() >= http.StatusInternalServerError {
+			return
+		}
+		record.StatusCode = recorder.Status()
+		record.Header = encodeHeaders(recorder.Header())
+		record.Body = recorder.body.String()
+		if err := keys.Update(record); err != nil {
+			slog.Error("Failed to store idempotent response", slog.Any("error", err))
+			return
+		}
+		stored = true
+	}
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// requestHash fingerprints a request so a key cannot be reused for a different one
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// reserve records the request, or returns the key already recorded for it.
+// An expired key that has not been cleaned up yet is replaced.
+func reserve(keys repository.IdempotencyRepository, record *models.IdempotencyKey) (*models.IdempotencyKey, error) {
+	var existing *models.IdempotencyKey
+	for attempt := 0; attempt < 2; attempt++ {
+		err := keys.Create(record)
+		if !errors.Is(err, repository.ErrDuplicate) {
+			return nil, err
+		}
+		existing, err = keys.Find(record.UserID, record.Method, record.Path, record.Key)
+		if errors.Is(err, repository.ErrNotFound) {
+			// Released by a failed first request in the meantime
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if existing.ExpiresAt.After(time.Now()) {
+			return existing, nil
+		}
+		if err := keys.Delete(existing.ID); err != nil {
+			return nil, err
+		}
+	}
+	return existing, nil
+}
+
+// replay writes a stored response
+func replay(c *gin.Context, stored *models.IdempotencyKey) {
+	var header http.Header
+	if stored.Header != "" {
+		if err := json.Unmarshal([]byte(stored.Header), &header); err != nil {
+			slog.Error("Failed to decode stored response headers", slog.Any("error", err))
+		}
+	}
+	for name, values := range header {
+		for _, value := range values {
+			c.Writer.Header().Add(name, value)
+		}
+	}
+	c.Header("Idempotent-Replayed", "true")
+	c.Writer.WriteHeader(stored.StatusCode)
+	c.Writer.WriteString(stored.Body)
+}
+
+func encodeHeaders(header http.Header) string {
+	kept := make(http.Header)
+	for _, name := range replayedHeaders {
+		if values := header.Values(name); len(values) > 0 {
+			kept[name] = values
+		}
+	}
+	encoded, _ := json.Marshal(kept)
+	return string(encoded)
+}
+
+// responseRecorder keeps a copy of the response body as it is written
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -1,7 +1,11 @@
 package middleware
 
 import (
+	"fmt"
+	"io"
 	"log"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -29,3 +33,43 @@ func LoggerMiddleware() gin.HandlerFunc {
 		)
 	}
 }
+
+// redactedParams are query parameters that carry credentials
+var redactedParams = []string{"access_token", "ticket"}
+
+// RequestLogger is gin's request logger writing to out, with credentials
+// in the query string replaced by REDACTED
+func RequestLogger(out io.Writer) gin.HandlerFunc {
+	return gin.LoggerWithConfig(gin.LoggerConfig{
+		Output: out,
+		Formatter: func(p gin.LogFormatterParams) string {
+			return fmt.Sprintf("[GIN] %v | %3d | %13v | %15s | %-7s %#v\n%s",
+				p.TimeStamp.Format("2006/01/02 - 15:04:05"),
+				p.StatusCode,
+				p.Latency,
+				p.ClientIP,
+				p.Method,
+				redactQuery(p.Path),
+				p.ErrorMessage,
+			)
+		},
+	})
+}
+
+// redactQuery replaces the values of redactedParams in a logged path
+func redactQuery(path string) string {
+	base, rawQuery, ok := strings.Cut(path, "?")
+	if !ok {
+		return path
+	}
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return base + "?REDACTED"
+	}
+	for _, name := range redactedParams {
+		if query.Has(name) {
+			query.Set(name, "REDACTED")
+		}
+	}
+	return base + "?" + query.Encode()
+}
@@ -15,6 +15,9 @@ type RateLimiter struct {
 	mu       sync.RWMutex
 	rate     int           // requests per window
 	window   time.Duration // time window
+
+	done     chan struct{} // closed by Stop to end the cleanup goroutine
+	stopOnce sync.Once
 }
 
 type visitor struct {
@@ -27,6 +30,7 @@ func NewRateLimiter(rate int, window time.Duration) *RateLimiter {
 		visitors: make(map[string]*visitor),
 		rate:     rate,
 		window:   window,
+		done:     make(chan struct{}),
 	}
 
 	// Cleanup old visitors every minute
@@ -35,9 +39,22 @@ func NewRateLimiter(rate int, window time.Duration) *RateLimiter {
 	return rl
 }
 
+// Stop ends the background cleanup. The limiter keeps limiting, so it is
+// safe to call while requests are still being served.
+func (rl *RateLimiter) Stop() {
+	rl.stopOnce.Do(func() { close(rl.done) })
+}
+
 func (rl *RateLimiter) cleanup() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
 	for {
-		time.Sleep(time.Minute)
+		select {
+		case <-rl.done:
+			return
+		case <-ticker.C:
+		}
 		rl.mu.Lock()
 		for ip, v := range rl.visitors {
 			if time.Since(v.lastReset) > rl.window*2 {
@@ -84,14 +101,19 @@ func (rl *RateLimiter) allow(ip string) bool {
 }
 
 // RateLimitMiddleware creates a rate limiting middleware
-// rate: max requests, window: time window for the rate limit
+// rate: max requests, window: time window for the rate limit.
+// Its limiter's cleanup runs for the life of the process; use
+// NewRateLimiter and Middleware to be able to stop it.
 func RateLimitMiddleware(rate int, window time.Duration) gin.HandlerFunc {
-	limiter := NewRateLimiter(rate, window)
+	return NewRateLimiter(rate, window).Middleware()
+}
 
+// Middleware returns the gin middleware enforcing this limiter per client IP
+func (rl *RateLimiter) Middleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ip := c.ClientIP()
 
-		if !limiter.allow(ip) {
+		if !rl.allow(ip) {
 			utils.ErrorResponse(c, http.StatusTooManyRequests, "Rate limit exceeded. Please try again later.")
 			c.Abort()
 			return
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// StreamTicketTTL is how long a stream ticket may be redeemed after it is
+// issued
+const StreamTicketTTL = 30 * time.Second
+
+// StreamTickets hands out the short-lived, single-use tickets that stand
+// in for the JWT in stream URLs. Query strings end up in access logs and
+// browser history, so they must not carry a token that stays valid.
+type StreamTickets struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	tickets map[string]streamTicket
+}
+
+type streamTicket struct {
+	userID    uint
+	email     string
+	expiresAt time.Time
+}
+
+func NewStreamTickets(ttl time.Duration) *StreamTickets {
+	return &StreamTickets{ttl: ttl, tickets: make(map[string]streamTicket)}
+}
+
+// Issue returns a new ticket for the user and when it expires
+func (s *StreamTickets) Issue(userID uint, email string) (string, time.Time) {
+	id := make([]byte, 24)
+	rand.Read(id)
+	ticket := hex.EncodeToString(id)
+	now := time.Now()
+	expiresAt := now.Add(s.ttl)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Tickets nobody redeemed are dropped here rather than by a goroutine
+	for key, t := range s.tickets {
+		if !now.Before(t.expiresAt) {
+			delete(s.tickets, key)
+		}
+	}
+	s.tickets[ticket] = streamTicket{userID: userID, email: email, expiresAt: expiresAt}
+	return ticket, expiresAt
+}
+
+// redeem uses up ticket, reporting false if it is unknown, already used or
+// expired
+func (s *StreamTickets) redeem(ticket string) (streamTicket, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tickets[ticket]
+	delete(s.tickets, ticket)
+	return t, ok && time.Now().Before(t.expiresAt)
+}
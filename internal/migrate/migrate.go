@@ -0,0 +1,362 @@
+// Package migrate applies the numbered SQL migrations embedded under sql/<dialect>/.
+//
+// Each migration is a pair of files named NNNN_description.up.sql and
+// NNNN_description.down.sql. Applied versions are recorded in the
+// schema_migrations table together with a checksum of both files, so a
+// migration that is edited after it has been applied is reported instead of
+// silently diverging from the database.
+package migrate
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed sql
+var files embed.FS
+
+const tableName = "schema_migrations"
+
+var (
+	ErrChecksumMismatch = errors.New("migration has been modified after it was applied")
+	ErrMissingMigration = errors.New("applied migration is missing from this build")
+	ErrUnknownVersion   = errors.New("unknown migration version")
+)
+
+// Migration is a single numbered schema change
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// Status describes a migration and whether it has been applied
+type Status struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+// record is a row in schema_migrations
+type record struct {
+	Version   int
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+func (record) TableName() string {
+	return tableName
+}
+
+// Migrator runs migrations for a single database connection
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// New loads the migrations for the dialect of db
+func New(db *gorm.DB) (*Migrator, error) {
+	migrations, err := Load(db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Load reads and validates the embedded migrations for a dialect
+func Load(dialect string) ([]Migration, error) {
+	dir := path.Join("sql", dialect)
+	entries, err := fs.ReadDir(files, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for dialect %q: %w", dialect, err)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		version, name, direction, err := parseFilename(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		content, err := fs.ReadFile(files, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		}
+		if m.Name != name {
+			return nil, fmt.Errorf("migration %04d has conflicting names %q and %q", version, m.Name, name)
+		}
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both an up and a down file", m.Version, m.Name)
+		}
+		sum := sha256.Sum256([]byte(m.Up + "\x00" + m.Down))
+		m.Checksum = hex.EncodeToString(sum[:])
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// parseFilename splits "0001_initial_schema.up.sql" into its parts
+func parseFilename(filename string) (int, string, string, error) {
+	base, ok := strings.CutSuffix(filename, ".sql")
+	if !ok {
+		return 0, "", "", fmt.Errorf("unexpected file %q in migrations", filename)
+	}
+
+	var direction string
+	switch {
+	case strings.HasSuffix(base, ".up"):
+		direction = "up"
+	case strings.HasSuffix(base, ".down"):
+		direction = "down"
+	default:
+		return 0, "", "", fmt.Errorf("migration %q must end in .up.sql or .down.sql", filename)
+	}
+	base = strings.TrimSuffix(base, "."+direction)
+
+	number, name, ok := strings.Cut(base, "_")
+	if !ok {
+		return 0, "", "", fmt.Errorf("migration %q must be named NNNN_description", filename)
+	}
+	version, err := strconv.Atoi(number)
+	if err != nil || version <= 0 {
+		return 0, "", "", fmt.Errorf("migration %q has an invalid version number", filename)
+	}
+
+	return version, name, direction, nil
+}
+
+// Migrations returns the known migrations in version order
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Latest returns the highest known migration version
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version returns the highest applied migration version (0 for an empty database)
+func (m *Migrator) Version() (int, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+	version := 0
+	for v := range applied {
+		if v > version {
+			version = v
+		}
+	}
+	return version, nil
+}
+
+// Status lists every known migration along with its applied state
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		s := Status{Version: mig.Version, Name: mig.Name}
+		if rec, ok := applied[mig.Version]; ok {
+			appliedAt := rec.AppliedAt
+			s.Applied = true
+			s.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses, nil
+}
+
+// Up applies all pending migrations and returns how many were run
+func (m *Migrator) Up() (int, error) {
+	return m.migrateTo(m.Latest())
+}
+
+// Down rolls back the given number of applied migrations
+func (m *Migrator) Down(steps int) (int, error) {
+	if steps <= 0 {
+		return 0, nil
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+
+	versions := make([]int, 0, len(applied))
+	for v := range applied {
+		versions = append(versions, v)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+
+	target := 0
+	if steps < len(versions) {
+		target = versions[steps]
+	}
+	return m.migrateTo(target)
+}
+
+// To migrates up or down until version is the latest applied migration.
+// Version 0 rolls back everything.
+func (m *Migrator) To(version int) (int, error) {
+	if version != 0 && m.find(version) == nil {
+		return 0, fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+	return m.migrateTo(version)
+}
+
+func (m *Migrator) migrateTo(target int) (int, error) {
+	applied, err := m.verify()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+
+	// Roll back newest first
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		mig := m.migrations[i]
+		if mig.Version <= target {
+			break
+		}
+		if _, ok := applied[mig.Version]; !ok {
+			continue
+		}
+		if err := m.run(mig, false); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	// Apply oldest first
+	for _, mig := range m.migrations {
+		if mig.Version > target {
+			break
+		}
+		if _, ok := applied[mig.Version]; ok {
+			continue
+		}
+		if err := m.run(mig, true); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// run executes one migration and updates schema_migrations in the same transaction
+func (m *Migrator) run(mig Migration, up bool) error {
+	script, direction := mig.Down, "down"
+	if up {
+		script, direction = mig.Up, "up"
+	}
+
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(script).Error; err != nil {
+			return err
+		}
+		if !up {
+			return tx.Where("version = ?", mig.Version).Delete(&record{}).Error
+		}
+		return tx.Create(&record{
+			Version:   mig.Version,
+			Name:      mig.Name,
+			Checksum:  mig.Checksum,
+			AppliedAt: time.Now().UTC(),
+		}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("migration %04d_%s (%s) failed: %w", mig.Version, mig.Name, direction, err)
+	}
+	return nil
+}
+
+// verify checks applied migrations against the embedded files
+func (m *Migrator) verify() (map[int]record, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	for version, rec := range applied {
+		mig := m.find(version)
+		if mig == nil {
+			return nil, fmt.Errorf("%w: %04d_%s", ErrMissingMigration, version, rec.Name)
+		}
+		if mig.Checksum != rec.Checksum {
+			return nil, fmt.Errorf("%w: %04d_%s", ErrChecksumMismatch, version, mig.Name)
+		}
+	}
+	return applied, nil
+}
+
+func (m *Migrator) applied() (map[int]record, error) {
+	err := m.db.Exec(`CREATE TABLE IF NOT EXISTS ` + tableName + ` (
+		version bigint PRIMARY KEY,
+		name text NOT NULL,
+		checksum text NOT NULL,
+		applied_at timestamp NOT NULL
+	)`).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var records []record
+	if err := m.db.Order("version").Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]record, len(records))
+	for _, rec := range records {
+		applied[rec.Version] = rec
+	}
+	return applied, nil
+}
+
+func (m *Migrator) find(version int) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package migrate
+
+import (
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newTestMigrator(t *testing.T) *Migrator {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+
+	m, err := New(db)
+	require.NoError(t, err)
+	return m
+}
+
+func TestParseFilename(t *testing.T) {
+	version, name, direction, err := parseFilename("0012_add_tags.down.sql")
+	assert.NoError(t, err)
+	assert.Equal(t, 12, version)
+	assert.Equal(t, "add_tags", name)
+	assert.Equal(t, "down", direction)
+
+	for _, bad := range []string{"0001_init.sql", "init.up.sql", "abc_init.up.sql", "0001_init.up.txt"} {
+		_, _, _, err := parseFilename(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestDialectsHaveMatchingMigrations(t *testing.T) {
+	sqliteMigrations, err := Load("sqlite")
+	require.NoError(t, err)
+	postgresMigrations, err := Load("postgres")
+	require.NoError(t, err)
+
+	require.Equal(t, len(sqliteMigrations), len(postgresMigrations))
+	for i := range sqliteMigrations {
+		assert.Equal(t, sqliteMigrations[i].Version, postgresMigrations[i].Version)
+		assert.Equal(t, sqliteMigrations[i].Name, postgresMigrations[i].Name)
+	}
+}
+
+func TestUpDownRoundTrip(t *testing.T) {
+	m := newTestMigrator(t)
+
+	n, err := m.Up()
+	require.NoError(t, err)
+	assert.Equal(t, len(m.Migrations()), n)
+
+	version, err := m.Version()
+	require.NoError(t, err)
+	assert.Equal(t, m.Latest(), version)
+
+	// A second run is a no-op
+	n, err = m.Up()
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	// Every down migration must undo its up migration cleanly
+	n, err = m.To(0)
+	require.NoError(t, err)
+	assert.Equal(t, len(m.Migrations()), n)
+	assert.False(t, m.db.Migrator().HasTable("users"))
+
+	_, err = m.Up()
+	require.NoError(t, err)
+	assert.True(t, m.db.Migrator().HasTable("users"))
+}
+
+func TestDownSteps(t *testing.T) {
+	m := newTestMigrator(t)
+	_, err := m.Up()
+	require.NoError(t, err)
+
+	n, err := m.Down(1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	statuses, err := m.Status()
+	require.NoError(t, err)
+	last := statuses[len(statuses)-1]
+	assert.False(t, last.Applied)
+	assert.Nil(t, last.AppliedAt)
+}
+
+func TestChecksumGuard(t *testing.T) {
+	m := newTestMigrator(t)
+	_, err := m.Up()
+	require.NoError(t, err)
+
+	// Simulate a migration file being edited after it was applied
+	m.migrations[0].Checksum = "edited"
+
+	_, err = m.Up()
+	assert.ErrorIs(t, err, ErrChecksumMismatch)
+}
+
+func TestToUnknownVersion(t *testing.T) {
+	m := newTestMigrator(t)
+	_, err := m.To(9999)
+	assert.ErrorIs(t, err, ErrUnknownVersion)
+}
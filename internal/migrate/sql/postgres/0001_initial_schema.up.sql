@@ -0,0 +1,41 @@
+-- Baseline schema. IF NOT EXISTS lets databases created by the old
+-- AutoMigrate startup adopt the migration history without changes.
+CREATE TABLE IF NOT EXISTS users (
+    id bigserial PRIMARY KEY,
+    email text NOT NULL,
+    password text NOT NULL,
+    name text NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    CONSTRAINT uni_users_email UNIQUE (email)
+);
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE IF NOT EXISTS todos (
+    id bigserial PRIMARY KEY,
+    title text NOT NULL,
+    description text,
+    status text DEFAULT 'pending',
+    due_date timestamptz,
+    user_id bigint NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    CONSTRAINT fk_users_todos FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE INDEX IF NOT EXISTS idx_todos_deleted_at ON todos (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_todos_user_id ON todos (user_id);
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id bigserial PRIMARY KEY,
+    token text NOT NULL,
+    user_id bigint NOT NULL,
+    expires_at timestamptz,
+    revoked boolean DEFAULT false,
+    created_at timestamptz,
+    deleted_at timestamptz,
+    CONSTRAINT fk_refresh_tokens_user FOREIGN KEY (user_id) REFERENCES users (id),
+    CONSTRAINT uni_refresh_tokens_token UNIQUE (token)
+);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_deleted_at ON refresh_tokens (deleted_at);
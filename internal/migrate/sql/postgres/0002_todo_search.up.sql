@@ -0,0 +1,9 @@
+-- Weighted document for full-text search: title (A) ranks above
+-- description (B). The "simple" configuration skips stemming and stop
+-- words so results match the SQLite FTS5 backend.
+ALTER TABLE todos ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(description, '')), 'B')
+) STORED;
+
+CREATE INDEX idx_todos_search_vector ON todos USING GIN (search_vector);
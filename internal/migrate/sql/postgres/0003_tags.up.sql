@@ -0,0 +1,21 @@
+-- Per-user labels and the join table attaching them to todos. Tag names
+-- are unique per user; deleting a tag or todo drops its assignments.
+CREATE TABLE tags (
+    id bigserial PRIMARY KEY,
+    user_id bigint NOT NULL,
+    name text NOT NULL,
+    color text NOT NULL DEFAULT '#6b7280',
+    created_at timestamptz,
+    updated_at timestamptz,
+    CONSTRAINT fk_tags_user FOREIGN KEY (user_id) REFERENCES users (id),
+    CONSTRAINT uni_tags_user_name UNIQUE (user_id, name)
+);
+
+CREATE TABLE todo_tags (
+    todo_id bigint NOT NULL,
+    tag_id bigint NOT NULL,
+    PRIMARY KEY (todo_id, tag_id),
+    CONSTRAINT fk_todo_tags_todo FOREIGN KEY (todo_id) REFERENCES todos (id) ON DELETE CASCADE,
+    CONSTRAINT fk_todo_tags_tag FOREIGN KEY (tag_id) REFERENCES tags (id) ON DELETE CASCADE
+);
+CREATE INDEX idx_todo_tags_tag_id ON todo_tags (tag_id);
@@ -0,0 +1,3 @@
+-- Priority from 0 (none) to 4 (urgent); see models.Priority
+ALTER TABLE todos ADD COLUMN priority smallint NOT NULL DEFAULT 0;
+CREATE INDEX idx_todos_user_priority ON todos (user_id, priority);
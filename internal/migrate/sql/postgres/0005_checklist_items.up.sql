@@ -0,0 +1,14 @@
+-- Checklist steps under a todo, listed by position
+CREATE TABLE checklist_items (
+    id bigserial PRIMARY KEY,
+    todo_id bigint NOT NULL,
+    title text NOT NULL,
+    done boolean NOT NULL DEFAULT false,
+    position integer NOT NULL DEFAULT 0,
+    created_at timestamptz,
+    updated_at timestamptz,
+    CONSTRAINT fk_todos_items FOREIGN KEY (todo_id) REFERENCES todos (id) ON DELETE CASCADE
+);
+CREATE INDEX idx_checklist_items_todo_position ON checklist_items (todo_id, position);
+
+ALTER TABLE todos ADD COLUMN auto_complete boolean NOT NULL DEFAULT false;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_todos_series_id;
+ALTER TABLE todos DROP COLUMN IF EXISTS series_id;
+DROP TABLE IF EXISTS recurrence_series;
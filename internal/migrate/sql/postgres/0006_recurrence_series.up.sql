@@ -0,0 +1,18 @@
+-- Recurring todos: a series holds the RRULE, its anchor and the template for
+-- new instances; every instance is a todo pointing at its series
+CREATE TABLE recurrence_series (
+    id bigserial PRIMARY KEY,
+    user_id bigint NOT NULL,
+    rrule text NOT NULL,
+    dtstart timestamptz NOT NULL,
+    title text NOT NULL,
+    description text,
+    priority smallint NOT NULL DEFAULT 0,
+    latest_todo_id bigint NOT NULL DEFAULT 0,
+    created_at timestamptz,
+    updated_at timestamptz,
+    CONSTRAINT fk_recurrence_series_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+
+ALTER TABLE todos ADD COLUMN series_id bigint REFERENCES recurrence_series (id);
+CREATE INDEX idx_todos_series_id ON todos (series_id);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_todos_due_date;
+DROP TABLE IF EXISTS reminders;
+ALTER TABLE users DROP COLUMN IF EXISTS quiet_hours_end;
+ALTER TABLE users DROP COLUMN IF EXISTS quiet_hours_start;
+ALTER TABLE users DROP COLUMN IF EXISTS timezone;
+ALTER TABLE todos DROP COLUMN IF EXISTS reminder_offsets;
@@ -0,0 +1,15 @@
+-- Durable background job queue; see internal/worker
+CREATE TABLE jobs (
+    id bigserial PRIMARY KEY,
+    type text NOT NULL,
+    payload text NOT NULL,
+    status text NOT NULL DEFAULT 'pending',
+    attempts integer NOT NULL DEFAULT 0,
+    max_attempts integer NOT NULL,
+    run_at timestamptz NOT NULL,
+    locked_at timestamptz,
+    last_error text NOT NULL DEFAULT '',
+    created_at timestamptz,
+    updated_at timestamptz
+);
+CREATE INDEX idx_jobs_status_run_at ON jobs (status, run_at);
@@ -0,0 +1,28 @@
+-- Per-user webhook subscriptions and the log of delivery attempts
+CREATE TABLE webhooks (
+    id bigserial PRIMARY KEY,
+    user_id bigint NOT NULL,
+    url text NOT NULL,
+    secret text NOT NULL,
+    events text NOT NULL,
+    active boolean NOT NULL DEFAULT true,
+    created_at timestamptz,
+    updated_at timestamptz,
+    CONSTRAINT fk_webhooks_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE INDEX idx_webhooks_user_id ON webhooks (user_id);
+
+CREATE TABLE webhook_deliveries (
+    id bigserial PRIMARY KEY,
+    webhook_id bigint NOT NULL,
+    event_id text NOT NULL,
+    event text NOT NULL,
+    payload text NOT NULL,
+    status_code integer NOT NULL DEFAULT 0,
+    response text NOT NULL DEFAULT '',
+    error text NOT NULL DEFAULT '',
+    duration_ms bigint NOT NULL DEFAULT 0,
+    created_at timestamptz NOT NULL,
+    CONSTRAINT fk_webhooks_deliveries FOREIGN KEY (webhook_id) REFERENCES webhooks (id) ON DELETE CASCADE
+);
+CREATE INDEX idx_webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id, id);
@@ -0,0 +1,2 @@
+-- Incremented by every update, so concurrent edits can be detected; see repository.ErrConflict
+ALTER TABLE todos ADD COLUMN version integer NOT NULL DEFAULT 1;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_todos_user_archived;
+ALTER TABLE todos DROP COLUMN IF EXISTS pinned;
+ALTER TABLE todos DROP COLUMN IF EXISTS archived;
@@ -0,0 +1,4 @@
+-- Archived todos are left out of listings by default; pinned ones are listed first
+ALTER TABLE todos ADD COLUMN archived boolean NOT NULL DEFAULT false;
+ALTER TABLE todos ADD COLUMN pinned boolean NOT NULL DEFAULT false;
+CREATE INDEX idx_todos_user_archived ON todos (user_id, archived);
@@ -0,0 +1,14 @@
+-- Users a project is shared with and their role on it; the project's creator
+-- owns it without being listed here. Deleting a project drops its members.
+CREATE TABLE project_members (
+    id bigserial PRIMARY KEY,
+    project_id bigint NOT NULL,
+    user_id bigint NOT NULL,
+    role text NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz,
+    CONSTRAINT fk_project_members_project FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE,
+    CONSTRAINT fk_project_members_user FOREIGN KEY (user_id) REFERENCES users (id),
+    CONSTRAINT uni_project_members_project_user UNIQUE (project_id, user_id)
+);
+CREATE INDEX idx_project_members_user_id ON project_members (user_id);
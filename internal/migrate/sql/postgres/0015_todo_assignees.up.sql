@@ -0,0 +1,3 @@
+-- The user a todo is assigned to, who need not be its creator
+ALTER TABLE todos ADD COLUMN assignee_id bigint REFERENCES users (id);
+CREATE INDEX idx_todos_assignee_id ON todos (assignee_id);
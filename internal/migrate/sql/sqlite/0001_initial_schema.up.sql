@@ -0,0 +1,41 @@
+-- Baseline schema. IF NOT EXISTS lets databases created by the old
+-- AutoMigrate startup adopt the migration history without changes.
+CREATE TABLE IF NOT EXISTS `users` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `email` text NOT NULL,
+    `password` text NOT NULL,
+    `name` text NOT NULL,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    CONSTRAINT `uni_users_email` UNIQUE (`email`)
+);
+CREATE INDEX IF NOT EXISTS `idx_users_deleted_at` ON `users`(`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `todos` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `title` text NOT NULL,
+    `description` text,
+    `status` text DEFAULT 'pending',
+    `due_date` datetime,
+    `user_id` integer NOT NULL,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    CONSTRAINT `fk_users_todos` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`)
+);
+CREATE INDEX IF NOT EXISTS `idx_todos_deleted_at` ON `todos`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_todos_user_id` ON `todos`(`user_id`);
+
+CREATE TABLE IF NOT EXISTS `refresh_tokens` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `token` text NOT NULL,
+    `user_id` integer NOT NULL,
+    `expires_at` datetime,
+    `revoked` numeric DEFAULT false,
+    `created_at` datetime,
+    `deleted_at` datetime,
+    CONSTRAINT `fk_refresh_tokens_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`),
+    CONSTRAINT `uni_refresh_tokens_token` UNIQUE (`token`)
+);
+CREATE INDEX IF NOT EXISTS `idx_refresh_tokens_deleted_at` ON `refresh_tokens`(`deleted_at`);
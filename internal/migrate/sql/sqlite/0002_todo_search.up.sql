@@ -0,0 +1,27 @@
+-- Full-text index over todo titles and descriptions. The FTS table reads
+-- its content from todos and is kept in sync by triggers. unicode61 without
+-- stemming keeps prefix queries predictable and matches Postgres' "simple"
+-- configuration.
+CREATE VIRTUAL TABLE `todos_fts` USING fts5(
+    title,
+    description,
+    content = 'todos',
+    content_rowid = 'id',
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+CREATE TRIGGER `todos_fts_after_insert` AFTER INSERT ON `todos` BEGIN
+    INSERT INTO todos_fts (rowid, title, description) VALUES (new.id, new.title, new.description);
+END;
+
+CREATE TRIGGER `todos_fts_after_delete` AFTER DELETE ON `todos` BEGIN
+    INSERT INTO todos_fts (todos_fts, rowid, title, description) VALUES ('delete', old.id, old.title, old.description);
+END;
+
+CREATE TRIGGER `todos_fts_after_update` AFTER UPDATE OF title, description ON `todos` BEGIN
+    INSERT INTO todos_fts (todos_fts, rowid, title, description) VALUES ('delete', old.id, old.title, old.description);
+    INSERT INTO todos_fts (rowid, title, description) VALUES (new.id, new.title, new.description);
+END;
+
+-- Index todos that existed before this migration
+INSERT INTO todos_fts (todos_fts) VALUES ('rebuild');
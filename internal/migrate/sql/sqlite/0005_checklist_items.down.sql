@@ -0,0 +1,2 @@
+ALTER TABLE `todos` DROP COLUMN `auto_complete`;
+DROP TABLE IF EXISTS `checklist_items`;
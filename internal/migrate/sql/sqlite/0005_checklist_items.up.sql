@@ -0,0 +1,14 @@
+-- Checklist steps under a todo, listed by position
+CREATE TABLE `checklist_items` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `todo_id` integer NOT NULL,
+    `title` text NOT NULL,
+    `done` numeric NOT NULL DEFAULT false,
+    `position` integer NOT NULL DEFAULT 0,
+    `created_at` datetime,
+    `updated_at` datetime,
+    CONSTRAINT `fk_todos_items` FOREIGN KEY (`todo_id`) REFERENCES `todos`(`id`) ON DELETE CASCADE
+);
+CREATE INDEX `idx_checklist_items_todo_position` ON `checklist_items`(`todo_id`, `position`);
+
+ALTER TABLE `todos` ADD COLUMN `auto_complete` numeric NOT NULL DEFAULT false;
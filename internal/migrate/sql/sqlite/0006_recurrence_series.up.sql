@@ -0,0 +1,18 @@
+-- Recurring todos: a series holds the RRULE, its anchor and the template for
+-- new instances; every instance is a todo pointing at its series
+CREATE TABLE `recurrence_series` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `user_id` integer NOT NULL,
+    `rrule` text NOT NULL,
+    `dtstart` datetime NOT NULL,
+    `title` text NOT NULL,
+    `description` text,
+    `priority` integer NOT NULL DEFAULT 0,
+    `latest_todo_id` integer NOT NULL DEFAULT 0,
+    `created_at` datetime,
+    `updated_at` datetime,
+    CONSTRAINT `fk_recurrence_series_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`)
+);
+
+ALTER TABLE `todos` ADD COLUMN `series_id` integer REFERENCES `recurrence_series`(`id`);
+CREATE INDEX `idx_todos_series_id` ON `todos`(`series_id`);
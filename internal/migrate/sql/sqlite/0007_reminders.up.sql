@@ -0,0 +1,20 @@
+-- Due-date reminders: offsets in minutes before the due date, stored as a
+-- comma-separated list (see models.ReminderOffsets)
+ALTER TABLE `todos` ADD COLUMN `reminder_offsets` text NOT NULL DEFAULT '';
+
+-- Reminders are held back during quiet hours in the user's time zone
+ALTER TABLE `users` ADD COLUMN `timezone` text NOT NULL DEFAULT 'UTC';
+ALTER TABLE `users` ADD COLUMN `quiet_hours_start` text NOT NULL DEFAULT '';
+ALTER TABLE `users` ADD COLUMN `quiet_hours_end` text NOT NULL DEFAULT '';
+
+-- One row per reminder sent, so restarts never send one twice
+CREATE TABLE `reminders` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `todo_id` integer NOT NULL,
+    `due_date` datetime NOT NULL,
+    `offset_minutes` integer NOT NULL,
+    `sent_at` datetime NOT NULL,
+    CONSTRAINT `fk_reminders_todo` FOREIGN KEY (`todo_id`) REFERENCES `todos`(`id`) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX `idx_reminders_todo_due_offset` ON `reminders`(`todo_id`, `due_date`, `offset_minutes`);
+CREATE INDEX `idx_todos_due_date` ON `todos`(`due_date`);
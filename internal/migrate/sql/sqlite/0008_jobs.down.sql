@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS `idx_jobs_status_run_at`;
+DROP TABLE IF EXISTS `jobs`;
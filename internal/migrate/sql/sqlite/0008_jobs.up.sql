@@ -0,0 +1,15 @@
+-- Durable background job queue; see internal/worker
+CREATE TABLE `jobs` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `type` text NOT NULL,
+    `payload` text NOT NULL,
+    `status` text NOT NULL DEFAULT 'pending',
+    `attempts` integer NOT NULL DEFAULT 0,
+    `max_attempts` integer NOT NULL,
+    `run_at` datetime NOT NULL,
+    `locked_at` datetime,
+    `last_error` text NOT NULL DEFAULT '',
+    `created_at` datetime,
+    `updated_at` datetime
+);
+CREATE INDEX `idx_jobs_status_run_at` ON `jobs`(`status`, `run_at`);
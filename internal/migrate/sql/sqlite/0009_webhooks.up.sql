@@ -0,0 +1,28 @@
+-- Per-user webhook subscriptions and the log of delivery attempts
+CREATE TABLE `webhooks` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `user_id` integer NOT NULL,
+    `url` text NOT NULL,
+    `secret` text NOT NULL,
+    `events` text NOT NULL,
+    `active` numeric NOT NULL DEFAULT true,
+    `created_at` datetime,
+    `updated_at` datetime,
+    CONSTRAINT `fk_webhooks_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`)
+);
+CREATE INDEX `idx_webhooks_user_id` ON `webhooks`(`user_id`);
+
+CREATE TABLE `webhook_deliveries` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `webhook_id` integer NOT NULL,
+    `event_id` text NOT NULL,
+    `event` text NOT NULL,
+    `payload` text NOT NULL,
+    `status_code` integer NOT NULL DEFAULT 0,
+    `response` text NOT NULL DEFAULT '',
+    `error` text NOT NULL DEFAULT '',
+    `duration_ms` integer NOT NULL DEFAULT 0,
+    `created_at` datetime NOT NULL,
+    CONSTRAINT `fk_webhooks_deliveries` FOREIGN KEY (`webhook_id`) REFERENCES `webhooks`(`id`) ON DELETE CASCADE
+);
+CREATE INDEX `idx_webhook_deliveries_webhook_id` ON `webhook_deliveries`(`webhook_id`, `id`);
@@ -0,0 +1,18 @@
+-- Responses to requests sent with an Idempotency-Key header, replayed to
+-- retries until they expire
+CREATE TABLE `idempotency_keys` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `user_id` integer NOT NULL,
+    `method` text NOT NULL,
+    `path` text NOT NULL,
+    `key` text NOT NULL,
+    `request_hash` text NOT NULL,
+    `status_code` integer NOT NULL DEFAULT 0,
+    `header` text NOT NULL DEFAULT '',
+    `body` text NOT NULL DEFAULT '',
+    `created_at` datetime,
+    `expires_at` datetime NOT NULL,
+    CONSTRAINT `fk_idempotency_keys_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`)
+);
+CREATE UNIQUE INDEX `idx_idempotency_keys_scope` ON `idempotency_keys`(`user_id`, `method`, `path`, `key`);
+CREATE INDEX `idx_idempotency_keys_expires_at` ON `idempotency_keys`(`expires_at`);
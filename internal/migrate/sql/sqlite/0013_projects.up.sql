@@ -0,0 +1,18 @@
+-- Per-user projects grouping todos. Project names are unique per user;
+-- deleting a project keeps its todos, which are left without a project.
+CREATE TABLE `projects` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `user_id` integer NOT NULL,
+    `name` text NOT NULL,
+    `color` text NOT NULL DEFAULT '#3b82f6',
+    `description` text,
+    `position` integer NOT NULL DEFAULT 0,
+    `archived` numeric NOT NULL DEFAULT false,
+    `created_at` datetime,
+    `updated_at` datetime,
+    CONSTRAINT `fk_projects_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`),
+    CONSTRAINT `uni_projects_user_name` UNIQUE (`user_id`, `name`)
+);
+
+ALTER TABLE `todos` ADD COLUMN `project_id` integer REFERENCES `projects`(`id`);
+CREATE INDEX `idx_todos_project_id` ON `todos`(`project_id`);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS `idx_project_members_user_id`;
+DROP TABLE IF EXISTS `project_members`;
@@ -0,0 +1,3 @@
+-- When a todo was completed, so edits made afterwards do not delay archiving
+ALTER TABLE `todos` ADD COLUMN `completed_at` datetime;
+UPDATE `todos` SET `completed_at` = `updated_at` WHERE `status` = 'completed';
@@ -0,0 +1,49 @@
+package models
+
+// Bulk operation kinds
+const (
+	BulkCreate       = "create"
+	BulkUpdateStatus = "update_status"
+	BulkDelete       = "delete"
+	BulkShiftDueDate = "shift_due_date"
+)
+
+// Outcomes of a bulk operation
+const (
+	BulkOK         = "ok"
+	BulkFailed     = "failed"
+	BulkRolledBack = "rolled_back" // it succeeded, but an atomic batch failed elsewhere
+)
+
+// BulkTodoOperation is one step of a bulk request. ID names the todo for
+// every op except create, which takes Todo instead.
+type BulkTodoOperation struct {
+	Op      string             `json:"op" enums:"create,update_status,delete,shift_due_date"`
+	ID      uint               `json:"id"`
+	Todo    *CreateTodoRequest `json:"todo"`                   // create
+	Status  TodoStatus         `json:"status"`                 // update_status
+	Minutes int                `json:"minutes" example:"1440"` // shift_due_date; negative moves it earlier
+}
+
+type BulkTodoRequest struct {
+	// Atomic applies every operation or, if any fails, none of them
+	Atomic     bool                `json:"atomic"`
+	Operations []BulkTodoOperation `json:"operations" binding:"required,min=1,max=100"`
+}
+
+// BulkTodoResult reports one operation, by its index in the request
+type BulkTodoResult struct {
+	Index  int           `json:"index"`
+	Op     string        `json:"op"`
+	ID     uint          `json:"id,omitempty"`
+	Result string        `json:"result" enums:"ok,failed,rolled_back"`
+	Error  string        `json:"error,omitempty"`
+	Todo   *TodoResponse `json:"todo,omitempty"` // the todo as left by the operation
+}
+
+type BulkTodoResponse struct {
+	Atomic    bool             `json:"atomic"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Results   []BulkTodoResult `json:"results"`
+}
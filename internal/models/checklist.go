@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// ChecklistItem is one step of a todo. Items are listed by Position.
+type ChecklistItem struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	TodoID    uint      `json:"todo_id" gorm:"not null"`
+	Title     string    `json:"title" gorm:"not null"`
+	Done      bool      `json:"done" gorm:"not null;default:false"`
+	Position  int       `json:"position" gorm:"not null;default:0"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Request DTOs
+type CreateChecklistItemRequest struct {
+	Title string `json:"title" binding:"required,min=1,max=200"`
+}
+
+type ReorderChecklistRequest struct {
+	ItemIDs []uint `json:"item_ids" binding:"required"`
+}
+
+// Response DTOs
+type ChecklistItemResponse struct {
+	ID       uint   `json:"id"`
+	Title    string `json:"title"`
+	Done     bool   `json:"done"`
+	Position int    `json:"position"`
+}
+
+// Progress counts the completed checklist items of a todo
+type Progress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+func (i *ChecklistItem) ToResponse() ChecklistItemResponse {
+	return ChecklistItemResponse{
+		ID:       i.ID,
+		Title:    i.Title,
+		Done:     i.Done,
+		Position: i.Position,
+	}
+}
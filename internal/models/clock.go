@@ -0,0 +1,9 @@
+package models
+
+import "time"
+
+// Now is the clock for stored times. It returns UTC, since SQLite keeps
+// times as text and only compares those with the same offset correctly.
+func Now() time.Time {
+	return time.Now().UTC()
+}
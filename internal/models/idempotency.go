@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// IdempotencyKey records a request sent with an Idempotency-Key header so
+// retries of it get the first response instead of running again. The
+// response fields stay empty while the first request is in flight.
+type IdempotencyKey struct {
+	ID          uint   `gorm:"primaryKey"`
+	UserID      uint   `gorm:"not null"`
+	Method      string `gorm:"not null"`
+	Path        string `gorm:"not null"`
+	Key         string `gorm:"not null"`
+	RequestHash string `gorm:"not null"` // sha256 of the method, URL and body
+	StatusCode  int    `gorm:"not null;default:0"`
+	Header      string `gorm:"not null;default:''"` // replayed response headers, as a JSON object
+	Body        string `gorm:"not null;default:''"`
+	CreatedAt   time.Time
+	ExpiresAt   time.Time `gorm:"not null"`
+}
+
+// Completed reports whether the response has been stored
+func (k *IdempotencyKey) Completed() bool {
+	return k.StatusCode != 0
+}
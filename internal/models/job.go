@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+type JobStatus string
+
+const (
+	JobPending JobStatus = "pending" // waiting for RunAt, including retries
+	JobRunning JobStatus = "running"
+	JobDone    JobStatus = "done"
+	JobDead    JobStatus = "dead" // failed MaxAttempts times; kept for inspection
+)
+
+// Job is a background task persisted in the queue, so it survives restarts
+// and can be retried
+type Job struct {
+	ID          uint       `gorm:"primaryKey"`
+	Type        string     `gorm:"not null"`
+	Payload     string     `gorm:"not null"` // JSON object
+	Status      JobStatus  `gorm:"not null;default:pending"`
+	Attempts    int        `gorm:"not null;default:0"`
+	MaxAttempts int        `gorm:"not null"`
+	RunAt       time.Time  `gorm:"not null"` // earliest time the job may run
+	LockedAt    *time.Time // set while running
+	LastError   string     `gorm:"not null;default:''"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
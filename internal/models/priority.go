@@ -0,0 +1,65 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Priority ranks how important a todo is. It is stored as an integer so it
+// can be compared and sorted, and travels as its name in JSON.
+type Priority int
+
+const (
+	PriorityNone Priority = iota
+	PriorityLow
+	PriorityMedium
+	PriorityHigh
+	PriorityUrgent
+)
+
+var priorityNames = [...]string{"none", "low", "medium", "high", "urgent"}
+
+// ErrInvalidPriority is returned when a priority name is not recognised
+var ErrInvalidPriority = errors.New("priority must be one of none, low, medium, high, urgent")
+
+// ParsePriority converts a priority name into a Priority
+func ParsePriority(name string) (Priority, error) {
+	for i, n := range priorityNames {
+		if n == name {
+			return Priority(i), nil
+		}
+	}
+	return PriorityNone, ErrInvalidPriority
+}
+
+func (p Priority) Valid() bool {
+	return p >= PriorityNone && p <= PriorityUrgent
+}
+
+func (p Priority) String() string {
+	if !p.Valid() {
+		return fmt.Sprintf("Priority(%d)", int(p))
+	}
+	return priorityNames[p]
+}
+
+func (p Priority) MarshalJSON() ([]byte, error) {
+	if !p.Valid() {
+		return nil, ErrInvalidPriority
+	}
+	return json.Marshal(p.String())
+}
+
+func (p *Priority) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return ErrInvalidPriority
+	}
+	parsed, err := ParsePriority(name)
+	if err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
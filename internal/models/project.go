@@ -0,0 +1,156 @@
+package models
+
+import "time"
+
+// DefaultProjectColor is used when a project is created without a color
+const DefaultProjectColor = "#3b82f6"
+
+// Project is a list grouping todos. A todo belongs to at most one project;
+// projects are listed by Position. The user who creates a project owns it
+// and can share it with other users as ProjectMembers.
+type Project struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	UserID      uint      `json:"user_id" gorm:"not null"`
+	Name        string    `json:"name" gorm:"not null"`
+	Color       string    `json:"color" gorm:"not null"`
+	Description string    `json:"description"`
+	Position    int       `json:"position" gorm:"not null;default:0"`
+	Archived    bool      `json:"archived" gorm:"not null;default:false"` // left out of listings by default
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Request DTOs
+type CreateProjectRequest struct {
+	Name        string `json:"name" binding:"required,min=1,max=100"`
+	Color       string `json:"color" binding:"omitempty,hexcolor"`
+	Description string `json:"description" binding:"max=1000"`
+}
+
+// UpdateProjectRequest changes only the fields that are set
+type UpdateProjectRequest struct {
+	Name        string  `json:"name" binding:"omitempty,min=1,max=100"`
+	Color       string  `json:"color" binding:"omitempty,hexcolor"`
+	Description *string `json:"description" binding:"omitempty,max=1000"`
+	Archived    *bool   `json:"archived"`
+}
+
+type ReorderProjectsRequest struct {
+	ProjectIDs []uint `json:"project_ids" binding:"required"`
+}
+
+// StatusCounts counts todos by status
+type StatusCounts struct {
+	Pending    int `json:"pending"`
+	InProgress int `json:"in_progress"`
+	Completed  int `json:"completed"`
+	Total      int `json:"total"`
+}
+
+// Add counts n todos with status
+func (s *StatusCounts) Add(status TodoStatus, n int) {
+	switch status {
+	case StatusPending:
+		s.Pending += n
+	case StatusInProgress:
+		s.InProgress += n
+	case StatusCompleted:
+		s.Completed += n
+	}
+	s.Total += n
+}
+
+// Response DTO
+type ProjectResponse struct {
+	ID          uint         `json:"id"`
+	OwnerID     uint         `json:"owner_id"`
+	Role        ProjectRole  `json:"role"` // the requesting user's role
+	Name        string       `json:"name"`
+	Color       string       `json:"color"`
+	Description string       `json:"description"`
+	Position    int          `json:"position"`
+	Archived    bool         `json:"archived"`
+	Counts      StatusCounts `json:"counts"` // the project's listed todos, archived ones excluded
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+func (p *Project) ToResponse(role ProjectRole, counts StatusCounts) ProjectResponse {
+	return ProjectResponse{
+		ID:          p.ID,
+		OwnerID:     p.UserID,
+		Role:        role,
+		Name:        p.Name,
+		Color:       p.Color,
+		Description: p.Description,
+		Position:    p.Position,
+		Archived:    p.Archived,
+		Counts:      counts,
+		CreatedAt:   p.CreatedAt,
+		UpdatedAt:   p.UpdatedAt,
+	}
+}
+
+// ProjectRole is what a user may do with a project and its todos
+type ProjectRole string
+
+const (
+	// RoleOwner may also change the project and manage its members
+	RoleOwner ProjectRole = "owner"
+	// RoleEditor may create, change and delete the project's todos
+	RoleEditor ProjectRole = "editor"
+	// RoleViewer may only read the project and its todos
+	RoleViewer ProjectRole = "viewer"
+)
+
+var roleRanks = map[ProjectRole]int{RoleViewer: 1, RoleEditor: 2, RoleOwner: 3}
+
+func (r ProjectRole) Valid() bool {
+	return roleRanks[r] > 0
+}
+
+// Allows reports whether the role grants everything need does
+func (r ProjectRole) Allows(need ProjectRole) bool {
+	return r.Valid() && roleRanks[r] >= roleRanks[need]
+}
+
+// ProjectMember shares a project with another user. The project's creator
+// is its owner without a ProjectMember record.
+type ProjectMember struct {
+	ID        uint        `json:"id" gorm:"primaryKey"`
+	ProjectID uint        `json:"project_id" gorm:"not null"`
+	UserID    uint        `json:"user_id" gorm:"not null"`
+	Role      ProjectRole `json:"role" gorm:"not null"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// Request DTOs
+type AddProjectMemberRequest struct {
+	Email string      `json:"email" binding:"required,email"`
+	Role  ProjectRole `json:"role" binding:"required" enums:"owner,editor,viewer"`
+}
+
+type UpdateProjectMemberRequest struct {
+	Role ProjectRole `json:"role" binding:"required" enums:"owner,editor,viewer"`
+}
+
+// Response DTO
+type ProjectMemberResponse struct {
+	UserID   uint        `json:"user_id"`
+	Name     string      `json:"name"`
+	Email    string      `json:"email"`
+	Role     ProjectRole `json:"role"`
+	Creator  bool        `json:"creator"` // the owner who created the project, who cannot be removed
+	JoinedAt time.Time   `json:"joined_at"`
+}
+
+func (m *ProjectMember) ToResponse(user *User) ProjectMemberResponse {
+	return ProjectMemberResponse{
+		UserID:   m.UserID,
+		Name:     user.Name,
+		Email:    user.Email,
+		Role:     m.Role,
+		JoinedAt: m.CreatedAt,
+	}
+}
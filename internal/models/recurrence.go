@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// RecurrenceSeries links the instances of a recurring todo. It holds the
+// rule, the anchor the rule is evaluated from and the template that new
+// instances are created from; each instance is an ordinary todo.
+type RecurrenceSeries struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	UserID       uint      `json:"user_id" gorm:"not null"`
+	RRule        string    `json:"rrule" gorm:"column:rrule;not null"`
+	DTStart      time.Time `json:"dtstart" gorm:"column:dtstart;not null"`
+	Title        string    `json:"title" gorm:"not null"`
+	Description  string    `json:"description"`
+	Priority     Priority  `json:"priority" gorm:"not null;default:0"`
+	LatestTodoID uint      `json:"latest_todo_id"` // only completing the newest instance schedules the next
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func (RecurrenceSeries) TableName() string {
+	return "recurrence_series"
+}
+
+// OccurrencesResponse previews upcoming instances of a recurring todo
+type OccurrencesResponse struct {
+	RRule       string      `json:"rrule"`
+	Occurrences []time.Time `json:"occurrences"`
+}
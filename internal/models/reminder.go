@@ -0,0 +1,89 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxReminderOffset is the earliest a reminder may fire before the due date
+const MaxReminderOffset = 7 * 24 * time.Hour
+
+// ReminderOffsets lists how many minutes before its due date a todo sends a
+// reminder, e.g. [1440, 15] for a day and a quarter of an hour ahead. It is
+// stored as a comma-separated column.
+type ReminderOffsets []int
+
+// maxReminders caps how many reminders a todo may have
+const maxReminders = 5
+
+// ErrInvalidReminders is returned by ParseReminderOffsets
+var ErrInvalidReminders = errors.New("reminder_offsets must hold at most 5 distinct minute counts between 0 and 10080")
+
+// ParseReminderOffsets validates minutes and returns them earliest reminder
+// first
+func ParseReminderOffsets(minutes []int) (ReminderOffsets, error) {
+	if len(minutes) > maxReminders {
+		return nil, ErrInvalidReminders
+	}
+	offsets := slices.Clone(minutes)
+	slices.Sort(offsets)
+	slices.Reverse(offsets)
+	for i, m := range offsets {
+		if m < 0 || time.Duration(m)*time.Minute > MaxReminderOffset || (i > 0 && m == offsets[i-1]) {
+			return nil, ErrInvalidReminders
+		}
+	}
+	return offsets, nil
+}
+
+// Value implements driver.Valuer
+func (o ReminderOffsets) Value() (driver.Value, error) {
+	parts := make([]string, len(o))
+	for i, minutes := range o {
+		parts[i] = strconv.Itoa(minutes)
+	}
+	return strings.Join(parts, ","), nil
+}
+
+// Scan implements sql.Scanner
+func (o *ReminderOffsets) Scan(value interface{}) error {
+	var s string
+	switch v := value.(type) {
+	case nil:
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return fmt.Errorf("cannot scan %T into ReminderOffsets", value)
+	}
+
+	*o = nil
+	for _, part := range strings.Split(s, ",") {
+		if part == "" {
+			continue
+		}
+		minutes, err := strconv.Atoi(part)
+		if err != nil {
+			return fmt.Errorf("invalid reminder offset %q", part)
+		}
+		*o = append(*o, minutes)
+	}
+	return nil
+}
+
+// Reminder records a reminder that has been sent, so a todo is reminded
+// once per offset and due date however often the check runs. Moving the due
+// date arms the todo's reminders again.
+type Reminder struct {
+	ID            uint      `gorm:"primaryKey"`
+	TodoID        uint      `gorm:"not null"`
+	DueDate       time.Time `gorm:"not null"`
+	OffsetMinutes int       `gorm:"not null"`
+	SentAt        time.Time `gorm:"not null"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// DefaultTagColor is used when a tag is created without a color
+const DefaultTagColor = "#6b7280"
+
+// Tag is a per-user label that can be attached to any number of todos
+type Tag struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null"`
+	Name      string    `json:"name" gorm:"not null"`
+	Color     string    `json:"color" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Request DTOs
+type CreateTagRequest struct {
+	Name  string `json:"name" binding:"required,min=1,max=50"`
+	Color string `json:"color" binding:"omitempty,hexcolor"`
+}
+
+type UpdateTagRequest struct {
+	Name  string `json:"name" binding:"omitempty,min=1,max=50"`
+	Color string `json:"color" binding:"omitempty,hexcolor"`
+}
+
+// Response DTO
+type TagResponse struct {
+	ID    uint   `json:"id"`
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+func (t *Tag) ToResponse() TagResponse {
+	return TagResponse{
+		ID:    t.ID,
+		Name:  t.Name,
+		Color: t.Color,
+	}
+}
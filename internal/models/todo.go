@@ -14,53 +14,195 @@ const (
 	StatusCompleted  TodoStatus = "completed"
 )
 
+func (s TodoStatus) Valid() bool {
+	return s == StatusPending || s == StatusInProgress || s == StatusCompleted
+}
+
 type Todo struct {
-	ID          uint           `json:"id" gorm:"primaryKey"`
-	Title       string         `json:"title" gorm:"not null"`
-	Description string         `json:"description"`
-	Status      TodoStatus     `json:"status" gorm:"default:pending"`
-	DueDate     *time.Time     `json:"due_date,omitempty"`
-	UserID      uint           `json:"user_id" gorm:"not null"`
-	User        User           `json:"-" gorm:"foreignKey:UserID"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+	ID           uint              `json:"id" gorm:"primaryKey"`
+	Title        string            `json:"title" gorm:"not null"`
+	Description  string            `json:"description"`
+	Status       TodoStatus        `json:"status" gorm:"default:pending"`
+	Priority     Priority          `json:"priority" gorm:"not null;default:0"`
+	DueDate      *time.Time        `json:"due_date,omitempty"`
+	Reminders    ReminderOffsets   `json:"reminder_offsets,omitempty" gorm:"column:reminder_offsets"`
+	AutoComplete bool              `json:"auto_complete" gorm:"not null;default:false"` // complete once every checklist item is done
+	Archived     bool              `json:"archived" gorm:"not null;default:false"`      // left out of listings by default
+	Pinned       bool              `json:"pinned" gorm:"not null;default:false"`        // listed before the other todos
+	CompletedAt  *time.Time        `json:"completed_at,omitempty"`                      // when the status last became completed
+	UserID       uint              `json:"user_id" gorm:"not null"`
+	User         User              `json:"-" gorm:"foreignKey:UserID"`
+	Tags         []Tag             `json:"tags,omitempty" gorm:"many2many:todo_tags"`
+	Items        []ChecklistItem   `json:"items,omitempty" gorm:"foreignKey:TodoID"`
+	SeriesID     *uint             `json:"series_id,omitempty"`
+	Series       *RecurrenceSeries `json:"-" gorm:"foreignKey:SeriesID"`
+	ProjectID    *uint             `json:"project_id,omitempty"`
+	AssigneeID   *uint             `json:"assignee_id,omitempty"`             // the user doing the todo, who need not be its creator
+	Version      uint              `json:"version" gorm:"not null;default:1"` // incremented by every update
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt    `json:"-" gorm:"index"`
+
+	// Highlight is a search snippet with matches wrapped in <mark>; it is only
+	// populated by searches and never written back
+	Highlight string `json:"-" gorm:"->;-:migration"`
 }
 
 // Request DTOs
 type CreateTodoRequest struct {
-	Title       string     `json:"title" binding:"required,min=1"`
-	Description string     `json:"description"`
-	Status      TodoStatus `json:"status"`
-	DueDate     *time.Time `json:"due_date"`
+	Title        string     `json:"title" binding:"required,min=1"`
+	Description  string     `json:"description"`
+	Status       TodoStatus `json:"status"`
+	Priority     Priority   `json:"priority" swaggertype:"string" enums:"none,low,medium,high,urgent"`
+	DueDate      *time.Time `json:"due_date"`
+	Reminders    []int      `json:"reminder_offsets" example:"1440,15"` // minutes before due_date
+	TagIDs       []uint     `json:"tag_ids"`
+	AutoComplete bool       `json:"auto_complete"`
+	RRule        string     `json:"rrule" example:"FREQ=WEEKLY;BYDAY=MO"` // requires due_date
+	ProjectID    *uint      `json:"project_id"`
 }
 
+// UpdateTodoRequest holds every editable field of a todo. PUT replaces the
+// todo with it, so omitted fields take their defaults; PATCH requests are
+// applied to the todo's current ToUpdateRequest.
 type UpdateTodoRequest struct {
-	Title       string     `json:"title"`
-	Description string     `json:"description"`
-	Status      TodoStatus `json:"status"`
-	DueDate     *time.Time `json:"due_date"`
+	Title        string     `json:"title" binding:"required,min=1"`
+	Description  string     `json:"description"`
+	Status       TodoStatus `json:"status"` // defaults to pending
+	Priority     Priority   `json:"priority" swaggertype:"string" enums:"none,low,medium,high,urgent"`
+	DueDate      *time.Time `json:"due_date"`
+	Reminders    []int      `json:"reminder_offsets" example:"1440,15"` // minutes before due_date
+	TagIDs       []uint     `json:"tag_ids"`
+	AutoComplete bool       `json:"auto_complete"`
+	RRule        string     `json:"rrule" example:"FREQ=WEEKLY;BYDAY=MO"` // "" stops recurring; changing a series needs scope=future
+	ProjectID    *uint      `json:"project_id"`                           // null leaves the todo without a project
+}
+
+// AssignTodoRequest names the user to assign a todo to, who must be able to
+// see it
+type AssignTodoRequest struct {
+	UserID uint `json:"user_id" binding:"required"`
 }
 
 // Response DTO
 type TodoResponse struct {
-	ID          uint       `json:"id"`
-	Title       string     `json:"title"`
-	Description string     `json:"description"`
-	Status      TodoStatus `json:"status"`
-	DueDate     *time.Time `json:"due_date,omitempty"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
+	ID           uint                    `json:"id"`
+	Title        string                  `json:"title"`
+	Description  string                  `json:"description"`
+	Status       TodoStatus              `json:"status"`
+	Priority     Priority                `json:"priority" swaggertype:"string" enums:"none,low,medium,high,urgent"`
+	DueDate      *time.Time              `json:"due_date,omitempty"`
+	Reminders    []int                   `json:"reminder_offsets"`
+	Tags         []TagResponse           `json:"tags"`
+	Items        []ChecklistItemResponse `json:"items"`
+	Progress     Progress                `json:"progress"`
+	AutoComplete bool                    `json:"auto_complete"`
+	Archived     bool                    `json:"archived"`
+	Pinned       bool                    `json:"pinned"`
+	RRule        string                  `json:"rrule,omitempty"`
+	SeriesID     *uint                   `json:"series_id,omitempty"`
+	ProjectID    *uint                   `json:"project_id,omitempty"`
+	AssigneeID   *uint                   `json:"assignee_id,omitempty"`
+	CompletedAt  *time.Time              `json:"completed_at,omitempty"`
+	Version      uint                    `json:"version"` // also sent as the ETag
+	Highlight    string                  `json:"highlight,omitempty"`
+	CreatedAt    time.Time               `json:"created_at"`
+	UpdatedAt    time.Time               `json:"updated_at"`
+	DeletedAt    *time.Time              `json:"deleted_at,omitempty"` // set for todos in the trash
+}
+
+// StampCompletion records now as the completion time of a todo that has
+// just been completed, and clears it for one that is not completed
+func (t *Todo) StampCompletion(now time.Time) {
+	switch {
+	case t.Status != StatusCompleted:
+		t.CompletedAt = nil
+	case t.CompletedAt == nil:
+		t.CompletedAt = &now
+	}
 }
 
 func (t *Todo) ToResponse() TodoResponse {
+	tags := make([]TagResponse, 0, len(t.Tags))
+	for _, tag := range t.Tags {
+		tags = append(tags, tag.ToResponse())
+	}
+
+	items := make([]ChecklistItemResponse, 0, len(t.Items))
+	for _, item := range t.Items {
+		items = append(items, item.ToResponse())
+	}
+
+	var rrule string
+	if t.Series != nil {
+		rrule = t.Series.RRule
+	}
+
+	var deletedAt *time.Time
+	if t.DeletedAt.Valid {
+		deletedAt = &t.DeletedAt.Time
+	}
+
 	return TodoResponse{
-		ID:          t.ID,
-		Title:       t.Title,
-		Description: t.Description,
-		Status:      t.Status,
-		DueDate:     t.DueDate,
-		CreatedAt:   t.CreatedAt,
-		UpdatedAt:   t.UpdatedAt,
+		ID:           t.ID,
+		Title:        t.Title,
+		Description:  t.Description,
+		Status:       t.Status,
+		Priority:     t.Priority,
+		DueDate:      t.DueDate,
+		Reminders:    append([]int{}, t.Reminders...),
+		Tags:         tags,
+		Items:        items,
+		Progress:     t.Progress(),
+		AutoComplete: t.AutoComplete,
+		Archived:     t.Archived,
+		Pinned:       t.Pinned,
+		RRule:        rrule,
+		CompletedAt:  t.CompletedAt,
+		SeriesID:     t.SeriesID,
+		ProjectID:    t.ProjectID,
+		AssigneeID:   t.AssigneeID,
+		Version:      t.Version,
+		Highlight:    t.Highlight,
+		CreatedAt:    t.CreatedAt,
+		UpdatedAt:    t.UpdatedAt,
+		DeletedAt:    deletedAt,
+	}
+}
+
+// ToUpdateRequest returns the todo's editable fields as they stand
+func (t *Todo) ToUpdateRequest() UpdateTodoRequest {
+	tagIDs := make([]uint, 0, len(t.Tags))
+	for _, tag := range t.Tags {
+		tagIDs = append(tagIDs, tag.ID)
+	}
+
+	var rrule string
+	if t.Series != nil {
+		rrule = t.Series.RRule
+	}
+
+	return UpdateTodoRequest{
+		Title:        t.Title,
+		Description:  t.Description,
+		Status:       t.Status,
+		Priority:     t.Priority,
+		DueDate:      t.DueDate,
+		Reminders:    append([]int{}, t.Reminders...),
+		TagIDs:       tagIDs,
+		AutoComplete: t.AutoComplete,
+		RRule:        rrule,
+		ProjectID:    t.ProjectID,
+	}
+}
+
+// Progress counts the todo's completed checklist items
+func (t *Todo) Progress() Progress {
+	progress := Progress{Total: len(t.Items)}
+	for _, item := range t.Items {
+		if item.Done {
+			progress.Done++
+		}
 	}
+	return progress
 }
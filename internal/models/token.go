@@ -25,6 +25,13 @@ type TokenPair struct {
 	ExpiresIn    int    `json:"expires_in"` // seconds until access token expires
 }
 
+// StreamTicketResponse holds a single-use ticket for opening an event
+// stream from a browser, which cannot send the Authorization header there
+type StreamTicketResponse struct {
+	Ticket    string    `json:"ticket" example:"3f8a9c0d5e7b1a2c4d6e8f0a1b3c5d7e9f0a2b4c6d8e0f1a"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
 // RefreshTokenRequest for token refresh endpoint
 type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token" binding:"required"`
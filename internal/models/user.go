@@ -7,14 +7,17 @@ import (
 )
 
 type User struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	Email     string         `json:"email" gorm:"unique;not null"`
-	Password  string         `json:"-" gorm:"not null"` // "-" excludes from JSON
-	Name      string         `json:"name" gorm:"not null"`
-	Todos     []Todo         `json:"todos,omitempty" gorm:"foreignKey:UserID"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID              uint           `json:"id" gorm:"primaryKey"`
+	Email           string         `json:"email" gorm:"unique;not null"`
+	Password        string         `json:"-" gorm:"not null"` // "-" excludes from JSON
+	Name            string         `json:"name" gorm:"not null"`
+	Timezone        string         `json:"timezone" gorm:"not null;default:UTC"` // IANA name, e.g. Europe/Berlin
+	QuietHoursStart string         `json:"quiet_hours_start"`                    // "HH:MM" local time, "" when unset
+	QuietHoursEnd   string         `json:"quiet_hours_end"`                      // before the start to span midnight
+	Todos           []Todo         `json:"todos,omitempty" gorm:"foreignKey:UserID"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
 // Request DTOs
@@ -29,19 +32,75 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// UpdateProfileRequest changes the given fields; quiet hours are set or
+// cleared ("") together
+type UpdateProfileRequest struct {
+	Name            *string `json:"name" binding:"omitempty,min=2"`
+	Timezone        *string `json:"timezone" example:"Europe/Berlin"`
+	QuietHoursStart *string `json:"quiet_hours_start" example:"22:00"`
+	QuietHoursEnd   *string `json:"quiet_hours_end" example:"07:00"`
+}
+
 // Response DTO
 type UserResponse struct {
-	ID        uint      `json:"id"`
-	Email     string    `json:"email"`
-	Name      string    `json:"name"`
-	CreatedAt time.Time `json:"created_at"`
+	ID              uint      `json:"id"`
+	Email           string    `json:"email"`
+	Name            string    `json:"name"`
+	Timezone        string    `json:"timezone"`
+	QuietHoursStart string    `json:"quiet_hours_start,omitempty"`
+	QuietHoursEnd   string    `json:"quiet_hours_end,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
 }
 
 func (u *User) ToResponse() UserResponse {
 	return UserResponse{
-		ID:        u.ID,
-		Email:     u.Email,
-		Name:      u.Name,
-		CreatedAt: u.CreatedAt,
+		ID:              u.ID,
+		Email:           u.Email,
+		Name:            u.Name,
+		Timezone:        u.Location().String(),
+		QuietHoursStart: u.QuietHoursStart,
+		QuietHoursEnd:   u.QuietHoursEnd,
+		CreatedAt:       u.CreatedAt,
+	}
+}
+
+// Location returns the user's time zone, UTC when unset or unknown
+func (u *User) Location() *time.Location {
+	if u.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(u.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// InQuietHours reports whether t falls within the user's quiet hours, in
+// the user's time zone
+func (u *User) InQuietHours(t time.Time) bool {
+	start, err := ParseClock(u.QuietHoursStart)
+	if err != nil {
+		return false
+	}
+	end, err := ParseClock(u.QuietHoursEnd)
+	if err != nil {
+		return false
+	}
+
+	local := t.In(u.Location())
+	now := local.Hour()*60 + local.Minute()
+	if start <= end {
+		return start <= now && now < end
+	}
+	return now >= start || now < end
+}
+
+// ParseClock reads an "HH:MM" time of day as minutes after midnight
+func ParseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
 	}
+	return t.Hour()*60 + t.Minute(), nil
 }
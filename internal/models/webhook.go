@@ -0,0 +1,180 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Todo events, delivered to webhooks subscribed to them
+const (
+	EventTodoCreated   = "todo.created"
+	EventTodoUpdated   = "todo.updated"
+	EventTodoCompleted = "todo.completed"
+	EventTodoDeleted   = "todo.deleted"  // moved to the trash, or deleted for good
+	EventTodoRestored  = "todo.restored" // taken out of the trash
+	// EventPing is only sent by the "send test event" endpoint
+	EventPing = "ping"
+)
+
+// TodoEvents lists the events a webhook may subscribe to
+var TodoEvents = []string{EventTodoCreated, EventTodoUpdated, EventTodoCompleted, EventTodoDeleted, EventTodoRestored}
+
+// WebhookEvents is the set of events a webhook is subscribed to, stored as
+// a comma-separated column
+type WebhookEvents []string
+
+// ParseWebhookEvents validates a subscription; an empty list subscribes to
+// every todo event
+func ParseWebhookEvents(names []string) (WebhookEvents, error) {
+	if len(names) == 0 {
+		return slices.Clone(TodoEvents), nil
+	}
+	events := make(WebhookEvents, 0, len(names))
+	for _, name := range names {
+		if !slices.Contains(TodoEvents, name) {
+			return nil, fmt.Errorf("unknown event %q: expected one of %s", name, strings.Join(TodoEvents, ", "))
+		}
+		if !slices.Contains(events, name) {
+			events = append(events, name)
+		}
+	}
+	return events, nil
+}
+
+// Has reports whether event is in the set
+func (e WebhookEvents) Has(event string) bool {
+	return slices.Contains(e, event)
+}
+
+// Value implements driver.Valuer
+func (e WebhookEvents) Value() (driver.Value, error) {
+	return strings.Join(e, ","), nil
+}
+
+// Scan implements sql.Scanner
+func (e *WebhookEvents) Scan(value interface{}) error {
+	var s string
+	switch v := value.(type) {
+	case nil:
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return fmt.Errorf("cannot scan %T into WebhookEvents", value)
+	}
+
+	*e = nil
+	for _, name := range strings.Split(s, ",") {
+		if name != "" {
+			*e = append(*e, name)
+		}
+	}
+	return nil
+}
+
+// Webhook is a user's subscription to todo events: every matching event
+// is POSTed to URL, signed with Secret
+type Webhook struct {
+	ID        uint          `json:"id" gorm:"primaryKey"`
+	UserID    uint          `json:"user_id" gorm:"not null"`
+	URL       string        `json:"url" gorm:"not null"`
+	Secret    string        `json:"-" gorm:"not null"`
+	Events    WebhookEvents `json:"events" gorm:"not null"`
+	Active    bool          `json:"active" gorm:"not null"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+// WebhookDelivery logs one attempt to deliver an event to a webhook
+type WebhookDelivery struct {
+	ID         uint      `gorm:"primaryKey"`
+	WebhookID  uint      `gorm:"not null"`
+	EventID    string    `gorm:"not null"`
+	Event      string    `gorm:"not null"`
+	Payload    string    `gorm:"not null"`
+	StatusCode int       `gorm:"not null"` // 0 when no response was received
+	Response   string    `gorm:"not null"` // start of the response body
+	Error      string    `gorm:"not null"`
+	DurationMS int64     `gorm:"column:duration_ms;not null"`
+	CreatedAt  time.Time `gorm:"not null"`
+}
+
+// Succeeded reports whether the endpoint accepted the event
+func (d *WebhookDelivery) Succeeded() bool {
+	return d.StatusCode >= 200 && d.StatusCode < 300
+}
+
+// Request DTOs
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" binding:"required,url,max=2048" example:"https://example.com/hooks/todo"`
+	Events []string `json:"events" example:"todo.created,todo.completed"` // empty subscribes to every todo event
+	Secret string   `json:"secret" binding:"omitempty,min=16,max=128"`    // generated when empty
+	Active *bool    `json:"active"`                                       // defaults to true
+}
+
+// UpdateWebhookRequest changes the given fields
+type UpdateWebhookRequest struct {
+	URL    *string   `json:"url" binding:"omitempty,url,max=2048"`
+	Events *[]string `json:"events"`
+	Secret *string   `json:"secret" binding:"omitempty,min=16,max=128"`
+	Active *bool     `json:"active"`
+}
+
+// Response DTOs
+type WebhookResponse struct {
+	ID        uint      `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	Secret    string    `json:"secret,omitempty"` // only returned when it is set
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (w *Webhook) ToResponse() WebhookResponse {
+	return WebhookResponse{
+		ID:        w.ID,
+		URL:       w.URL,
+		Events:    w.Events,
+		Active:    w.Active,
+		CreatedAt: w.CreatedAt,
+		UpdatedAt: w.UpdatedAt,
+	}
+}
+
+type WebhookDeliveryResponse struct {
+	ID         uint            `json:"id"`
+	EventID    string          `json:"event_id"`
+	Event      string          `json:"event"`
+	Payload    json.RawMessage `json:"payload" swaggertype:"object"`
+	StatusCode int             `json:"status_code"`
+	Response   string          `json:"response"`
+	Error      string          `json:"error,omitempty"`
+	Success    bool            `json:"success"`
+	DurationMS int64           `json:"duration_ms"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+func (d *WebhookDelivery) ToResponse() WebhookDeliveryResponse {
+	return WebhookDeliveryResponse{
+		ID:         d.ID,
+		EventID:    d.EventID,
+		Event:      d.Event,
+		Payload:    json.RawMessage(d.Payload),
+		StatusCode: d.StatusCode,
+		Response:   d.Response,
+		Error:      d.Error,
+		Success:    d.Succeeded(),
+		DurationMS: d.DurationMS,
+		CreatedAt:  d.CreatedAt,
+	}
+}
+
+type WebhookTestResponse struct {
+	EventID string `json:"event_id"`
+}
@@ -0,0 +1,364 @@
+// Package recurrence implements the subset of RFC 5545 recurrence rules
+// used by recurring todos:
+//
+//	FREQ=DAILY;INTERVAL=2              every other day
+//	FREQ=WEEKLY;BYDAY=MO,WE,FR         three times a week
+//	FREQ=MONTHLY;BYDAY=-1FR            last Friday of every month
+//	FREQ=MONTHLY;COUNT=6               same day of the month, six times
+//	FREQ=YEARLY;UNTIL=20301231T000000Z yearly until the end of 2030
+//
+// Supported parts are FREQ (DAILY, WEEKLY, MONTHLY, YEARLY), INTERVAL,
+// BYDAY, COUNT and UNTIL; anything else is rejected so a rule never means
+// less than the client asked for. Weeks start on Monday.
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidRule is wrapped by every parse error
+var ErrInvalidRule = errors.New("invalid recurrence rule")
+
+// Frequency is the base period a rule repeats on
+type Frequency int
+
+const (
+	Daily Frequency = iota
+	Weekly
+	Monthly
+	Yearly
+)
+
+var frequencyNames = [...]string{"DAILY", "WEEKLY", "MONTHLY", "YEARLY"}
+
+func (f Frequency) String() string {
+	if f < Daily || f > Yearly {
+		return "Frequency(" + strconv.Itoa(int(f)) + ")"
+	}
+	return frequencyNames[f]
+}
+
+// ByDay is one BYDAY entry. N picks the Nth weekday of the month (negative
+// counts from the end) and is only allowed with FREQ=MONTHLY; 0 means every
+// such weekday in the period.
+type ByDay struct {
+	N       int
+	Weekday time.Weekday
+}
+
+var weekdayNames = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// Rule is a parsed recurrence rule
+type Rule struct {
+	Freq     Frequency
+	Interval int
+	ByDay    []ByDay
+	Count    int       // 0 when unbounded
+	Until    time.Time // zero when unbounded; inclusive
+}
+
+// maxIdlePeriods bounds how many consecutive periods may pass without an
+// occurrence (e.g. FREQ=MONTHLY from the 31st skips short months) before
+// iteration gives up
+const maxIdlePeriods = 1000
+
+// Parse reads a rule such as "FREQ=WEEKLY;BYDAY=MO,TH". An "RRULE:" prefix
+// is accepted and part names are case-insensitive.
+func Parse(input string) (*Rule, error) {
+	input = strings.TrimSpace(input)
+	if len(input) >= 6 && strings.EqualFold(input[:6], "RRULE:") {
+		input = input[6:]
+	}
+
+	rule := &Rule{Interval: 1}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(input, ";") {
+		if part == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(part, "=")
+		name = strings.ToUpper(strings.TrimSpace(name))
+		value = strings.ToUpper(strings.TrimSpace(value))
+		if !ok || value == "" {
+			return nil, invalid("malformed part %q", part)
+		}
+		if seen[name] {
+			return nil, invalid("%s given twice", name)
+		}
+		seen[name] = true
+
+		var err error
+		switch name {
+		case "FREQ":
+			freq := slices.Index(frequencyNames[:], value)
+			if freq < 0 {
+				return nil, invalid("unsupported FREQ %q", value)
+			}
+			rule.Freq = Frequency(freq)
+		case "INTERVAL":
+			rule.Interval, err = strconv.Atoi(value)
+			if err != nil || rule.Interval < 1 {
+				return nil, invalid("INTERVAL must be a positive integer")
+			}
+		case "COUNT":
+			rule.Count, err = strconv.Atoi(value)
+			if err != nil || rule.Count < 1 {
+				return nil, invalid("COUNT must be a positive integer")
+			}
+		case "UNTIL":
+			rule.Until, err = parseUntil(value)
+			if err != nil {
+				return nil, invalid("UNTIL must be a date or UTC date-time such as 20301231T235959Z")
+			}
+		case "BYDAY":
+			rule.ByDay, err = parseByDay(value)
+			if err != nil {
+				return nil, err
+			}
+		case "WKST":
+			if value != "MO" {
+				return nil, invalid("only WKST=MO is supported")
+			}
+		default:
+			return nil, invalid("unsupported part %s", name)
+		}
+	}
+
+	if !seen["FREQ"] {
+		return nil, invalid("FREQ is required")
+	}
+	if seen["COUNT"] && seen["UNTIL"] {
+		return nil, invalid("COUNT and UNTIL cannot be combined")
+	}
+	for _, day := range rule.ByDay {
+		if rule.Freq == Yearly {
+			return nil, invalid("BYDAY is not supported with FREQ=YEARLY")
+		}
+		if day.N != 0 && rule.Freq != Monthly {
+			return nil, invalid("ordinal BYDAY values need FREQ=MONTHLY")
+		}
+	}
+	return rule, nil
+}
+
+func invalid(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidRule, fmt.Sprintf(format, args...))
+}
+
+func parseUntil(value string) (time.Time, error) {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("20060102T150405", value); err == nil {
+		return t, nil
+	}
+	// A bare date includes the whole day
+	t, err := time.Parse("20060102", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.Add(24*time.Hour - time.Second), nil
+}
+
+func parseByDay(value string) ([]ByDay, error) {
+	var days []ByDay
+	for _, entry := range strings.Split(value, ",") {
+		if len(entry) < 2 {
+			return nil, invalid("malformed BYDAY %q", entry)
+		}
+		prefix, name := entry[:len(entry)-2], entry[len(entry)-2:]
+		weekday := slices.Index(weekdayNames[:], name)
+		if weekday < 0 {
+			return nil, invalid("unknown weekday %q", name)
+		}
+		day := ByDay{Weekday: time.Weekday(weekday)}
+		if prefix != "" {
+			n, err := strconv.Atoi(prefix)
+			if err != nil || n == 0 || n < -5 || n > 5 {
+				return nil, invalid("malformed BYDAY %q", entry)
+			}
+			day.N = n
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+// String renders the rule in canonical form, e.g. "FREQ=WEEKLY;BYDAY=MO,FR"
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + r.Freq.String()}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			days[i] = weekdayNames[day.Weekday]
+			if day.N != 0 {
+				days[i] = strconv.Itoa(day.N) + days[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// All yields the occurrences of the rule anchored at dtstart, in order.
+// As in RFC 5545, dtstart is always the first occurrence and counts
+// towards COUNT. Occurrences keep dtstart's time of day and location.
+func (r *Rule) All(dtstart time.Time) iter.Seq[time.Time] {
+	return func(yield func(time.Time) bool) {
+		emitted := 0
+		emit := func(t time.Time) bool {
+			if r.Count > 0 && emitted >= r.Count {
+				return false
+			}
+			if !r.Until.IsZero() && t.After(r.Until) {
+				return false
+			}
+			emitted++
+			return yield(t)
+		}
+
+		if !emit(dtstart) {
+			return
+		}
+		idle := 0
+		for period := 0; idle < maxIdlePeriods; period++ {
+			found := false
+			for _, t := range r.candidates(dtstart, period) {
+				if !t.After(dtstart) {
+					continue
+				}
+				found = true
+				if !emit(t) {
+					return
+				}
+			}
+			if found {
+				idle = 0
+			} else {
+				idle++
+			}
+		}
+	}
+}
+
+// After returns the first occurrence strictly after t
+func (r *Rule) After(dtstart, t time.Time) (time.Time, bool) {
+	for occurrence := range r.All(dtstart) {
+		if occurrence.After(t) {
+			return occurrence, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Next returns up to n occurrences strictly after t
+func (r *Rule) Next(dtstart, t time.Time, n int) []time.Time {
+	occurrences := make([]time.Time, 0, n)
+	if n <= 0 {
+		return occurrences
+	}
+	for occurrence := range r.All(dtstart) {
+		if !occurrence.After(t) {
+			continue
+		}
+		occurrences = append(occurrences, occurrence)
+		if len(occurrences) == n {
+			break
+		}
+	}
+	return occurrences
+}
+
+// candidates lists the occurrences in the given period (0 is the period
+// containing dtstart) in chronological order
+func (r *Rule) candidates(dtstart time.Time, period int) []time.Time {
+	step := period * r.Interval
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, dtstart.Hour(), dtstart.Minute(), dtstart.Second(), dtstart.Nanosecond(), dtstart.Location())
+	}
+	y, m, d := dtstart.Date()
+
+	switch r.Freq {
+	case Daily:
+		t := at(y, m, d+step)
+		if len(r.ByDay) > 0 && !r.hasWeekday(t.Weekday()) {
+			return nil
+		}
+		return []time.Time{t}
+
+	case Weekly:
+		if len(r.ByDay) == 0 {
+			return []time.Time{at(y, m, d+7*step)}
+		}
+		// Monday of dtstart's week, moved on by the period
+		monday := d - (int(dtstart.Weekday())+6)%7 + 7*step
+		var days []time.Time
+		for offset := 0; offset < 7; offset++ {
+			t := at(y, m, monday+offset)
+			if r.hasWeekday(t.Weekday()) {
+				days = append(days, t)
+			}
+		}
+		return days
+
+	case Monthly:
+		first := at(y, m+time.Month(step), 1)
+		if len(r.ByDay) == 0 {
+			t := at(first.Year(), first.Month(), d)
+			if t.Month() != first.Month() {
+				return nil // no such day this month
+			}
+			return []time.Time{t}
+		}
+		return r.monthDays(first)
+
+	default: // Yearly
+		t := at(y+step, m, d)
+		if t.Month() != m {
+			return nil // Feb 29 outside leap years
+		}
+		return []time.Time{t}
+	}
+}
+
+// monthDays lists the BYDAY matches in the month starting at first
+func (r *Rule) monthDays(first time.Time) []time.Time {
+	daysInMonth := time.Date(first.Year(), first.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	var days []time.Time
+	for day := 1; day <= daysInMonth; day++ {
+		t := first.AddDate(0, 0, day-1)
+		nth := (day-1)/7 + 1
+		nthFromEnd := -((daysInMonth-day)/7 + 1)
+		for _, by := range r.ByDay {
+			if by.Weekday == t.Weekday() && (by.N == 0 || by.N == nth || by.N == nthFromEnd) {
+				days = append(days, t)
+				break
+			}
+		}
+	}
+	return days
+}
+
+func (r *Rule) hasWeekday(weekday time.Weekday) bool {
+	for _, day := range r.ByDay {
+		if day.Weekday == weekday {
+			return true
+		}
+	}
+	return false
+}
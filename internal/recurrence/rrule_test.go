@@ -0,0 +1,88 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func dates(times []time.Time) []string {
+	out := make([]string, len(times))
+	for i, t := range times {
+		out[i] = t.Format("2006-01-02 Mon")
+	}
+	return out
+}
+
+func TestParse(t *testing.T) {
+	rule, err := Parse("RRULE:freq=weekly;interval=2;byday=MO,fr;count=4")
+	require.NoError(t, err)
+	assert.Equal(t, Weekly, rule.Freq)
+	assert.Equal(t, 2, rule.Interval)
+	assert.Equal(t, []ByDay{{Weekday: time.Monday}, {Weekday: time.Friday}}, rule.ByDay)
+	assert.Equal(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR;COUNT=4", rule.String())
+
+	rule, err = Parse("FREQ=MONTHLY;BYDAY=-1FR;UNTIL=20301231")
+	require.NoError(t, err)
+	assert.Equal(t, []ByDay{{N: -1, Weekday: time.Friday}}, rule.ByDay)
+	assert.Equal(t, "FREQ=MONTHLY;BYDAY=-1FR;UNTIL=20301231T235959Z", rule.String())
+
+	for _, bad := range []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=3;UNTIL=20300101",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=WEEKLY;BYDAY=2MO",
+		"FREQ=YEARLY;BYDAY=MO",
+		"FREQ=DAILY;BYHOUR=9",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;UNTIL=tomorrow",
+	} {
+		_, err := Parse(bad)
+		assert.ErrorIs(t, err, ErrInvalidRule, bad)
+	}
+}
+
+func TestOccurrences(t *testing.T) {
+	// Wednesday 2025-01-15 09:00 UTC
+	start := time.Date(2025, 1, 15, 9, 0, 0, 0, time.UTC)
+	next := func(rule string, n int) []string {
+		t.Helper()
+		r, err := Parse(rule)
+		require.NoError(t, err)
+		return dates(r.Next(start, start, n))
+	}
+
+	assert.Equal(t, []string{"2025-01-17 Fri", "2025-01-19 Sun", "2025-01-21 Tue"}, next("FREQ=DAILY;INTERVAL=2", 3))
+	assert.Equal(t, []string{"2025-01-16 Thu", "2025-01-17 Fri", "2025-01-20 Mon"}, next("FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR", 3))
+	assert.Equal(t, []string{"2025-01-22 Wed", "2025-01-29 Wed"}, next("FREQ=WEEKLY", 2))
+	assert.Equal(t, []string{"2025-01-17 Fri", "2025-01-27 Mon", "2025-01-31 Fri"}, next("FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR", 3))
+	assert.Equal(t, []string{"2025-01-31 Fri", "2025-02-28 Fri", "2025-03-28 Fri"}, next("FREQ=MONTHLY;BYDAY=-1FR", 3))
+	assert.Equal(t, []string{"2025-02-04 Tue", "2025-03-04 Tue"}, next("FREQ=MONTHLY;BYDAY=1TU", 2))
+	assert.Equal(t, []string{"2025-02-15 Sat", "2025-03-15 Sat"}, next("FREQ=MONTHLY", 2))
+	assert.Equal(t, []string{"2026-01-15 Thu"}, next("FREQ=YEARLY", 1))
+
+	// dtstart counts towards COUNT, and UNTIL is inclusive
+	assert.Equal(t, []string{"2025-01-16 Thu", "2025-01-17 Fri"}, next("FREQ=DAILY;COUNT=3", 10))
+	assert.Equal(t, []string{"2025-01-22 Wed"}, next("FREQ=WEEKLY;UNTIL=20250122T090000Z", 10))
+
+	// Months without the start day are skipped
+	jan31 := time.Date(2025, 1, 31, 8, 0, 0, 0, time.UTC)
+	rule, err := Parse("FREQ=MONTHLY")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2025-03-31 Mon", "2025-05-31 Sat"}, dates(rule.Next(jan31, jan31, 2)))
+
+	// After skips past the given time and keeps the time of day
+	after, ok := rule.After(jan31, time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC))
+	require.True(t, ok)
+	assert.Equal(t, time.Date(2025, 5, 31, 8, 0, 0, 0, time.UTC), after)
+
+	rule, err = Parse("FREQ=DAILY;COUNT=2")
+	require.NoError(t, err)
+	_, ok = rule.After(start, start.Add(24*time.Hour))
+	assert.False(t, ok)
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"github.com/user/go-todo-api/internal/models"
+	"gorm.io/gorm"
+)
+
+// checklistRepository is the GORM implementation of ChecklistRepository
+type checklistRepository struct {
+	db *gorm.DB
+}
+
+func NewChecklistRepository(db *gorm.DB) ChecklistRepository {
+	return &checklistRepository{db: db}
+}
+
+// Create appends the item after the todo's last item
+func (r *checklistRepository) Create(item *models.ChecklistItem) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var last struct{ Position *int }
+		err := tx.Model(&models.ChecklistItem{}).Select("MAX(position) AS position").
+			Where("todo_id = ?", item.TodoID).Scan(&last).Error
+		if err != nil {
+			return err
+		}
+		item.Position = 0
+		if last.Position != nil {
+			item.Position = *last.Position + 1
+		}
+		return tx.Create(item).Error
+	})
+}
+
+func (r *checklistRepository) FindByTodoID(todoID uint) ([]models.ChecklistItem, error) {
+	items := make([]models.ChecklistItem, 0)
+	err := r.db.Where("todo_id = ?", todoID).Order("position, id").Find(&items).Error
+	return items, err
+}
+
+func (r *checklistRepository) FindByIDAndTodoID(id, todoID uint) (*models.ChecklistItem, error) {
+	var item models.ChecklistItem
+	err := r.db.Where("id = ? AND todo_id = ?", id, todoID).First(&item).Error
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (r *checklistRepository) Update(item *models.ChecklistItem) error {
+	return r.db.Save(item).Error
+}
+
+// Reorder numbers the todo's items in the order of ids
+func (r *checklistRepository) Reorder(todoID uint, ids []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for position, id := range ids {
+			err := tx.Model(&models.ChecklistItem{}).
+				Where("id = ? AND todo_id = ?", id, todoID).
+				Update("position", position).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *checklistRepository) Delete(id, todoID uint) error {
+	return r.db.Where("id = ? AND todo_id = ?", id, todoID).Delete(&models.ChecklistItem{}).Error
+}
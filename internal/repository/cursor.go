@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/user/go-todo-api/internal/models"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidCursor is returned when a cursor's sort key cannot be used
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor marks a position in a keyset-paginated todo listing: the sort key of
+// the boundary row plus its id as a tie-breaker. Handlers sign and encode it
+// so clients treat it as opaque.
+type Cursor struct {
+	SortBy  string  `json:"s"`
+	SortDir string  `json:"d"`
+	Pinned  bool    `json:"p,omitempty"` // the boundary row is pinned, so listed ahead of the rest
+	Value   *string `json:"v,omitempty"` // sort column of the boundary row; nil when NULL
+	ID      uint    `json:"i"`
+	Before  bool    `json:"b,omitempty"` // page backwards from the boundary
+	Filter  string  `json:"f,omitempty"` // fingerprint of the filters the cursor was issued for
+}
+
+// timeSortFields are compared as timestamps rather than strings
+var timeSortFields = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+	"due_date":   true,
+}
+
+// NewCursor returns the cursor positioned at todo for the given ordering
+func NewCursor(todo *models.Todo, sortBy, sortDir string, before bool) *Cursor {
+	return &Cursor{
+		SortBy:  sortBy,
+		SortDir: sortDir,
+		Pinned:  todo.Pinned,
+		Value:   sortValue(todo, sortBy),
+		ID:      todo.ID,
+		Before:  before,
+	}
+}
+
+// Validate checks that the cursor refers to a sortable column with a
+// parseable value
+func (c *Cursor) Validate() error {
+	if !allowedSortFields[c.SortBy] || (c.SortDir != "ASC" && c.SortDir != "DESC") {
+		return ErrInvalidCursor
+	}
+	if c.Value == nil && c.SortBy != "due_date" {
+		return ErrInvalidCursor
+	}
+	if _, err := c.boundValue(); err != nil {
+		return ErrInvalidCursor
+	}
+	return nil
+}
+
+// Boundary returns a todo carrying the cursor's sort key, for in-process
+// comparisons against real rows
+func (c *Cursor) Boundary() models.Todo {
+	boundary := models.Todo{ID: c.ID, Pinned: c.Pinned}
+	value, _ := c.boundValue()
+	switch v := value.(type) {
+	case time.Time:
+		switch c.SortBy {
+		case "created_at":
+			boundary.CreatedAt = v
+		case "updated_at":
+			boundary.UpdatedAt = v
+		case "due_date":
+			boundary.DueDate = &v
+		}
+	case int:
+		boundary.Priority = models.Priority(v)
+	case string:
+		switch c.SortBy {
+		case "title":
+			boundary.Title = v
+		case "status":
+			boundary.Status = models.TodoStatus(v)
+		}
+	}
+	return boundary
+}
+
+// boundValue converts the cursor value into the type stored in the column
+func (c *Cursor) boundValue() (interface{}, error) {
+	if c.Value == nil {
+		return nil, nil
+	}
+	if timeSortFields[c.SortBy] {
+		return time.Parse(time.RFC3339Nano, *c.Value)
+	}
+	if c.SortBy == "priority" {
+		return strconv.Atoi(*c.Value)
+	}
+	return *c.Value, nil
+}
+
+func sortValue(todo *models.Todo, sortBy string) *string {
+	var value string
+	switch sortBy {
+	case "created_at":
+		value = todo.CreatedAt.Format(time.RFC3339Nano)
+	case "updated_at":
+		value = todo.UpdatedAt.Format(time.RFC3339Nano)
+	case "due_date":
+		if todo.DueDate == nil {
+			return nil
+		}
+		value = todo.DueDate.Format(time.RFC3339Nano)
+	case "title":
+		value = todo.Title
+	case "status":
+		value = string(todo.Status)
+	case "priority":
+		value = strconv.Itoa(int(todo.Priority))
+	}
+	return &value
+}
+
+// applyKeyset narrows query to rows after (or before) the cursor and orders
+// it so the rows closest to the boundary come first. The ordering matches
+// FindAllWithFilters: pinned rows, then the sort column with NULLs last,
+// then id.
+func applyKeyset(query *gorm.DB, c *Cursor) *gorm.DB {
+	col := "todos." + c.SortBy
+	nullable := c.SortBy == "due_date"
+
+	// Walking backwards reverses the ordering; results are flipped afterwards
+	ascending := (c.SortDir == "ASC") != c.Before
+	op, dir := "<", "DESC"
+	if ascending {
+		op, dir = ">", "ASC"
+	}
+
+	var cond string
+	var args []interface{}
+	value, _ := c.boundValue()
+	switch {
+	case value == nil && !c.Before:
+		// Inside the trailing NULL block: only later NULL rows remain
+		cond, args = col+" IS NULL AND todos.id "+op+" ?", []interface{}{c.ID}
+	case value == nil:
+		cond, args = col+" IS NOT NULL OR ("+col+" IS NULL AND todos.id "+op+" ?)", []interface{}{c.ID}
+	case nullable && !c.Before:
+		cond, args = col+" "+op+" ? OR ("+col+" = ? AND todos.id "+op+" ?) OR "+col+" IS NULL", []interface{}{value, value, c.ID}
+	default:
+		cond, args = col+" "+op+" ? OR ("+col+" = ? AND todos.id "+op+" ?)", []interface{}{value, value, c.ID}
+	}
+
+	// The rows of the boundary's own pinned group are narrowed by the sort
+	// key; the other group lies wholly after the boundary when walking
+	// forward from a pinned row or backwards from an unpinned one
+	if c.Pinned != c.Before {
+		query = query.Where("(todos.pinned = ? OR (todos.pinned = ? AND ("+cond+")))", append([]interface{}{!c.Pinned, c.Pinned}, args...)...)
+	} else {
+		query = query.Where("(todos.pinned = ? AND ("+cond+"))", append([]interface{}{c.Pinned}, args...)...)
+	}
+
+	if c.Before {
+		query = query.Order("todos.pinned ASC")
+	} else {
+		query = query.Order("todos.pinned DESC")
+	}
+	if nullable {
+		if c.Before {
+			query = query.Order(col + " IS NULL DESC")
+		} else {
+			query = query.Order(col + " IS NULL")
+		}
+	}
+	return query.Order(col + " " + dir).Order("todos.id " + dir)
+}
+
+// PageFromCursor trims a keyset query result fetched with limit+1 rows,
+// restores display order and works out the neighbouring cursors
+func PageFromCursor(rows []models.Todo, c *Cursor, limit int) *PaginatedResult {
+	hasMore := len(rows) > limit
+	if hasMore {
+		rows = rows[:limit]
+	}
+	if c.Before {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	result := &PaginatedResult{Data: rows, PageSize: limit}
+	if len(rows) == 0 {
+		return result
+	}
+
+	first, last := &rows[0], &rows[len(rows)-1]
+	if !c.Before || hasMore {
+		result.PrevCursor = NewCursor(first, c.SortBy, c.SortDir, true)
+	}
+	if c.Before || hasMore {
+		result.NextCursor = NewCursor(last, c.SortBy, c.SortDir, false)
+	}
+	return result
+}
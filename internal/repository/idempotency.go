@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/user/go-todo-api/internal/models"
+	"gorm.io/gorm"
+)
+
+// idempotencyRepository is the GORM implementation of IdempotencyRepository
+type idempotencyRepository struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyRepository(db *gorm.DB) IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+// Create relies on the unique index over (user_id, method, path, key), so
+// of two concurrent requests with the same key only one is recorded
+func (r *idempotencyRepository) Create(key *models.IdempotencyKey) error {
+	return r.db.Create(key).Error
+}
+
+func (r *idempotencyRepository) Find(userID uint, method, path, key string) (*models.IdempotencyKey, error) {
+	var found models.IdempotencyKey
+	err := r.db.Where("user_id = ? AND method = ? AND path = ? AND key = ?", userID, method, path, key).
+		Take(&found).Error
+	if err != nil {
+		return nil, err
+	}
+	return &found, nil
+}
+
+func (r *idempotencyRepository) Update(key *models.IdempotencyKey) error {
+	return r.db.Save(key).Error
+}
+
+func (r *idempotencyRepository) Delete(id uint) error {
+	return r.db.Delete(&models.IdempotencyKey{}, id).Error
+}
+
+func (r *idempotencyRepository) DeleteExpired(now time.Time) (int64, error) {
+	result := r.db.Where("expires_at < ?", now).Delete(&models.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
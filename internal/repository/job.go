@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/user/go-todo-api/internal/models"
+	"gorm.io/gorm"
+)
+
+// jobRepository is the GORM implementation of JobRepository
+type jobRepository struct {
+	db *gorm.DB
+}
+
+func NewJobRepository(db *gorm.DB) JobRepository {
+	return &jobRepository{db: db}
+}
+
+func (r *jobRepository) Create(job *models.Job) error {
+	return r.db.Create(job).Error
+}
+
+func (r *jobRepository) FindByID(id uint) (*models.Job, error) {
+	var job models.Job
+	err := r.db.First(&job, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Claim picks the next due job and takes it with a conditional UPDATE, so
+// it works the same on every dialect: when another worker got there first
+// no row changes and the next candidate is tried
+func (r *jobRepository) Claim(now time.Time) (*models.Job, error) {
+	for {
+		var job models.Job
+		err := r.db.Where("status = ? AND run_at <= ?", models.JobPending, now).
+			Order("run_at").Order("id").Take(&job).Error
+		if err != nil {
+			return nil, err
+		}
+
+		result := r.db.Model(&models.Job{}).
+			Where("id = ? AND status = ?", job.ID, models.JobPending).
+			Updates(map[string]interface{}{
+				"status":    models.JobRunning,
+				"attempts":  gorm.Expr("attempts + 1"),
+				"locked_at": now,
+			})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			job.Status = models.JobRunning
+			job.Attempts++
+			job.LockedAt = &now
+			return &job, nil
+		}
+	}
+}
+
+func (r *jobRepository) Update(job *models.Job) error {
+	return r.db.Save(job).Error
+}
+
+func (r *jobRepository) RequeueStale(lockedBefore time.Time) (int64, error) {
+	result := r.db.Model(&models.Job{}).
+		Where("status = ? AND locked_at < ?", models.JobRunning, lockedBefore).
+		Updates(map[string]interface{}{"status": models.JobPending, "locked_at": nil})
+	return result.RowsAffected, result.Error
+}
+
+func (r *jobRepository) DeleteFinishedBefore(before time.Time) (int64, error) {
+	result := r.db.Where("status = ? AND updated_at < ?", models.JobDone, before).Delete(&models.Job{})
+	return result.RowsAffected, result.Error
+}
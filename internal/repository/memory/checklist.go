@@ -0,0 +1,104 @@
+package memory
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository"
+)
+
+type checklistRepository struct {
+	mu     sync.RWMutex
+	nextID uint
+	items  map[uint]models.ChecklistItem
+}
+
+func NewChecklistRepository() repository.ChecklistRepository {
+	return &checklistRepository{items: make(map[uint]models.ChecklistItem)}
+}
+
+func (r *checklistRepository) Create(item *models.ChecklistItem) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	item.Position = 0
+	for _, existing := range r.items {
+		if existing.TodoID == item.TodoID && existing.Position >= item.Position {
+			item.Position = existing.Position + 1
+		}
+	}
+
+	now := time.Now()
+	r.nextID++
+	item.ID = r.nextID
+	item.CreatedAt = now
+	item.UpdatedAt = now
+	r.items[item.ID] = *item
+	return nil
+}
+
+func (r *checklistRepository) FindByTodoID(todoID uint) ([]models.ChecklistItem, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	items := make([]models.ChecklistItem, 0)
+	for _, item := range r.items {
+		if item.TodoID == todoID {
+			items = append(items, item)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Position != items[j].Position {
+			return items[i].Position < items[j].Position
+		}
+		return items[i].ID < items[j].ID
+	})
+	return items, nil
+}
+
+func (r *checklistRepository) FindByIDAndTodoID(id, todoID uint) (*models.ChecklistItem, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	item, ok := r.items[id]
+	if !ok || item.TodoID != todoID {
+		return nil, repository.ErrNotFound
+	}
+	return &item, nil
+}
+
+func (r *checklistRepository) Update(item *models.ChecklistItem) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	item.UpdatedAt = time.Now()
+	r.items[item.ID] = *item
+	return nil
+}
+
+func (r *checklistRepository) Reorder(todoID uint, ids []uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for position, id := range ids {
+		item, ok := r.items[id]
+		if !ok || item.TodoID != todoID {
+			continue
+		}
+		item.Position = position
+		r.items[id] = item
+	}
+	return nil
+}
+
+func (r *checklistRepository) Delete(id, todoID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if item, ok := r.items[id]; ok && item.TodoID == todoID {
+		delete(r.items, id)
+	}
+	return nil
+}
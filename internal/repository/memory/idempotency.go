@@ -0,0 +1,89 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository"
+)
+
+// idempotencyScope mirrors the unique index on idempotency_keys
+type idempotencyScope struct {
+	userID       uint
+	method, path string
+	key          string
+}
+
+type idempotencyRepository struct {
+	mu     sync.Mutex
+	nextID uint
+	keys   map[idempotencyScope]models.IdempotencyKey
+}
+
+func NewIdempotencyRepository() repository.IdempotencyRepository {
+	return &idempotencyRepository{keys: make(map[idempotencyScope]models.IdempotencyKey)}
+}
+
+func scopeOf(key *models.IdempotencyKey) idempotencyScope {
+	return idempotencyScope{key.UserID, key.Method, key.Path, key.Key}
+}
+
+func (r *idempotencyRepository) Create(key *models.IdempotencyKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.keys[scopeOf(key)]; ok {
+		return repository.ErrDuplicate
+	}
+	r.nextID++
+	key.ID = r.nextID
+	key.CreatedAt = time.Now()
+	r.keys[scopeOf(key)] = *key
+	return nil
+}
+
+func (r *idempotencyRepository) Find(userID uint, method, path, key string) (*models.IdempotencyKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	found, ok := r.keys[idempotencyScope{userID, method, path, key}]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &found, nil
+}
+
+func (r *idempotencyRepository) Update(key *models.IdempotencyKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.keys[scopeOf(key)] = *key
+	return nil
+}
+
+func (r *idempotencyRepository) Delete(id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for scope, key := range r.keys {
+		if key.ID == id {
+			delete(r.keys, scope)
+		}
+	}
+	return nil
+}
+
+func (r *idempotencyRepository) DeleteExpired(now time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var n int64
+	for scope, key := range r.keys {
+		if key.ExpiresAt.Before(now) {
+			delete(r.keys, scope)
+			n++
+		}
+	}
+	return n, nil
+}
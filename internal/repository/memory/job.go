@@ -0,0 +1,122 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository"
+)
+
+type jobRepository struct {
+	mu     sync.Mutex
+	nextID uint
+	jobs   map[uint]models.Job
+}
+
+func NewJobRepository() repository.JobRepository {
+	return &jobRepository{jobs: make(map[uint]models.Job)}
+}
+
+func (r *jobRepository) Create(job *models.Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.nextID++
+	job.ID = r.nextID
+	if job.Status == "" {
+		job.Status = models.JobPending
+	}
+	job.CreatedAt = now
+	job.UpdatedAt = now
+	r.jobs[job.ID] = cloneJob(job)
+	return nil
+}
+
+func (r *jobRepository) FindByID(id uint) (*models.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	found := cloneJob(&job)
+	return &found, nil
+}
+
+func (r *jobRepository) Claim(now time.Time) (*models.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var next *models.Job
+	for id := range r.jobs {
+		job := r.jobs[id]
+		if job.Status != models.JobPending || job.RunAt.After(now) {
+			continue
+		}
+		if next == nil || job.RunAt.Before(next.RunAt) || (job.RunAt.Equal(next.RunAt) && job.ID < next.ID) {
+			next = &job
+		}
+	}
+	if next == nil {
+		return nil, repository.ErrNotFound
+	}
+
+	next.Status = models.JobRunning
+	next.Attempts++
+	next.LockedAt = &now
+	next.UpdatedAt = time.Now()
+	r.jobs[next.ID] = cloneJob(next)
+	claimed := cloneJob(next)
+	return &claimed, nil
+}
+
+func (r *jobRepository) Update(job *models.Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job.UpdatedAt = time.Now()
+	r.jobs[job.ID] = cloneJob(job)
+	return nil
+}
+
+func (r *jobRepository) RequeueStale(lockedBefore time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var n int64
+	for id, job := range r.jobs {
+		if job.Status == models.JobRunning && job.LockedAt != nil && job.LockedAt.Before(lockedBefore) {
+			job.Status = models.JobPending
+			job.LockedAt = nil
+			r.jobs[id] = job
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *jobRepository) DeleteFinishedBefore(before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var n int64
+	for id, job := range r.jobs {
+		if job.Status == models.JobDone && job.UpdatedAt.Before(before) {
+			delete(r.jobs, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func cloneJob(job *models.Job) models.Job {
+	clone := *job
+	if job.LockedAt != nil {
+		lockedAt := *job.LockedAt
+		clone.LockedAt = &lockedAt
+	}
+	return clone
+}
@@ -0,0 +1,30 @@
+// Package memory provides thread-safe, in-process implementations of the
+// repository interfaces. They behave like the GORM repositories (soft
+// deletes, timestamps, not-found and duplicate errors) so handlers can be
+// exercised and demoed without a database.
+package memory
+
+import (
+	"github.com/user/go-todo-api/internal/repository"
+)
+
+// NewRepositories returns a fresh, empty set of in-memory repositories
+func NewRepositories() repository.Repositories {
+	tags := NewTagRepository()
+	items := NewChecklistRepository()
+	series := NewSeriesRepository()
+	projects := NewProjectRepository()
+	return repository.Repositories{
+		Todos:       NewTodoRepository(tags, items, series, projects),
+		Tags:        tags,
+		Projects:    projects,
+		Items:       items,
+		Series:      series,
+		Reminders:   NewReminderRepository(),
+		Jobs:        NewJobRepository(),
+		Webhooks:    NewWebhookRepository(),
+		Idempotency: NewIdempotencyRepository(),
+		Users:       NewUserRepository(),
+		Tokens:      NewTokenRepository(),
+	}
+}
@@ -0,0 +1,14 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/user/go-todo-api/internal/repository"
+	"github.com/user/go-todo-api/internal/repository/repotest"
+)
+
+func TestConformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repository.Repositories {
+		return NewRepositories()
+	})
+}
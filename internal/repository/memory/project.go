@@ -0,0 +1,242 @@
+package memory
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository"
+)
+
+type projectRepository struct {
+	mu           sync.RWMutex
+	nextID       uint
+	projects     map[uint]models.Project
+	nextMemberID uint
+	members      map[uint]models.ProjectMember
+}
+
+func NewProjectRepository() repository.ProjectRepository {
+	return &projectRepository{
+		projects: make(map[uint]models.Project),
+		members:  make(map[uint]models.ProjectMember),
+	}
+}
+
+func (r *projectRepository) Create(project *models.Project) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.nameTaken(project.UserID, project.Name, 0) {
+		return repository.ErrDuplicate
+	}
+
+	position := 0
+	for _, existing := range r.projects {
+		if existing.UserID == project.UserID && existing.Position >= position {
+			position = existing.Position + 1
+		}
+	}
+
+	now := time.Now()
+	r.nextID++
+	project.ID = r.nextID
+	if project.Color == "" {
+		project.Color = models.DefaultProjectColor
+	}
+	project.Position = position
+	project.CreatedAt = now
+	project.UpdatedAt = now
+	r.projects[project.ID] = *project
+	return nil
+}
+
+func (r *projectRepository) FindAllByUserID(userID uint) ([]models.Project, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	projects := make([]models.Project, 0)
+	for _, project := range r.projects {
+		if project.UserID == userID {
+			projects = append(projects, project)
+		}
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		if projects[i].Position != projects[j].Position {
+			return projects[i].Position < projects[j].Position
+		}
+		return projects[i].ID < projects[j].ID
+	})
+	return projects, nil
+}
+
+func (r *projectRepository) FindSharedWithUser(userID uint) ([]models.Project, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	projects := make([]models.Project, 0)
+	for _, member := range r.members {
+		if member.UserID == userID {
+			projects = append(projects, r.projects[member.ProjectID])
+		}
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		if projects[i].Name != projects[j].Name {
+			return projects[i].Name < projects[j].Name
+		}
+		return projects[i].ID < projects[j].ID
+	})
+	return projects, nil
+}
+
+func (r *projectRepository) FindByID(id uint) (*models.Project, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	project, ok := r.projects[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &project, nil
+}
+
+func (r *projectRepository) FindByIDAndUserID(id, userID uint) (*models.Project, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	project, ok := r.projects[id]
+	if !ok || project.UserID != userID {
+		return nil, repository.ErrNotFound
+	}
+	return &project, nil
+}
+
+func (r *projectRepository) Update(project *models.Project) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.nameTaken(project.UserID, project.Name, project.ID) {
+		return repository.ErrDuplicate
+	}
+
+	project.UpdatedAt = time.Now()
+	r.projects[project.ID] = *project
+	return nil
+}
+
+func (r *projectRepository) Reorder(userID uint, ids []uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for position, id := range ids {
+		project, ok := r.projects[id]
+		if !ok || project.UserID != userID {
+			continue
+		}
+		project.Position = position
+		r.projects[id] = project
+	}
+	return nil
+}
+
+// Delete removes the project and its members; its todos drop it because
+// their project is resolved against the live project set
+func (r *projectRepository) Delete(id, userID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	project, ok := r.projects[id]
+	if !ok || project.UserID != userID {
+		return nil
+	}
+	delete(r.projects, id)
+	for memberID, member := range r.members {
+		if member.ProjectID == id {
+			delete(r.members, memberID)
+		}
+	}
+	return nil
+}
+
+func (r *projectRepository) AddMember(member *models.ProjectMember) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.findMember(member.ProjectID, member.UserID); ok {
+		return repository.ErrDuplicate
+	}
+
+	now := time.Now()
+	r.nextMemberID++
+	member.ID = r.nextMemberID
+	member.CreatedAt = now
+	member.UpdatedAt = now
+	r.members[member.ID] = *member
+	return nil
+}
+
+func (r *projectRepository) FindMembers(projectID uint) ([]models.ProjectMember, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	members := make([]models.ProjectMember, 0)
+	for _, member := range r.members {
+		if member.ProjectID == projectID {
+			members = append(members, member)
+		}
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].ID < members[j].ID })
+	return members, nil
+}
+
+func (r *projectRepository) FindMember(projectID, userID uint) (*models.ProjectMember, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	member, ok := r.findMember(projectID, userID)
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &member, nil
+}
+
+func (r *projectRepository) UpdateMember(member *models.ProjectMember) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	member.UpdatedAt = time.Now()
+	r.members[member.ID] = *member
+	return nil
+}
+
+func (r *projectRepository) RemoveMember(projectID, userID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if member, ok := r.findMember(projectID, userID); ok {
+		delete(r.members, member.ID)
+	}
+	return nil
+}
+
+// findMember must be called with the lock held
+func (r *projectRepository) findMember(projectID, userID uint) (models.ProjectMember, bool) {
+	for _, member := range r.members {
+		if member.ProjectID == projectID && member.UserID == userID {
+			return member, true
+		}
+	}
+	return models.ProjectMember{}, false
+}
+
+// nameTaken reports whether another project of userID is called name.
+// It must be called with the lock held.
+func (r *projectRepository) nameTaken(userID uint, name string, exceptID uint) bool {
+	for _, project := range r.projects {
+		if project.UserID == userID && project.Name == name && project.ID != exceptID {
+			return true
+		}
+	}
+	return false
+}
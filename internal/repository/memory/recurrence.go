@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository"
+)
+
+type seriesRepository struct {
+	mu     sync.RWMutex
+	nextID uint
+	series map[uint]models.RecurrenceSeries
+}
+
+func NewSeriesRepository() repository.SeriesRepository {
+	return &seriesRepository{series: make(map[uint]models.RecurrenceSeries)}
+}
+
+func (r *seriesRepository) Create(series *models.RecurrenceSeries) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.nextID++
+	series.ID = r.nextID
+	series.CreatedAt = now
+	series.UpdatedAt = now
+	r.series[series.ID] = *series
+	return nil
+}
+
+func (r *seriesRepository) FindByIDAndUserID(id, userID uint) (*models.RecurrenceSeries, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	series, ok := r.series[id]
+	if !ok || series.UserID != userID {
+		return nil, repository.ErrNotFound
+	}
+	return &series, nil
+}
+
+func (r *seriesRepository) Update(series *models.RecurrenceSeries) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	series.UpdatedAt = time.Now()
+	r.series[series.ID] = *series
+	return nil
+}
@@ -0,0 +1,51 @@
+package memory
+
+import (
+	"sync"
+
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository"
+)
+
+// reminderKey mirrors the unique index on reminders
+type reminderKey struct {
+	todoID  uint
+	dueDate int64
+	offset  int
+}
+
+type reminderRepository struct {
+	mu        sync.Mutex
+	nextID    uint
+	reminders map[reminderKey]models.Reminder
+}
+
+func NewReminderRepository() repository.ReminderRepository {
+	return &reminderRepository{reminders: make(map[reminderKey]models.Reminder)}
+}
+
+func (r *reminderRepository) Create(reminder *models.Reminder) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := reminderKey{reminder.TodoID, reminder.DueDate.UnixNano(), reminder.OffsetMinutes}
+	if _, ok := r.reminders[key]; ok {
+		return repository.ErrDuplicate
+	}
+	r.nextID++
+	reminder.ID = r.nextID
+	r.reminders[key] = *reminder
+	return nil
+}
+
+func (r *reminderRepository) Delete(id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, reminder := range r.reminders {
+		if reminder.ID == id {
+			delete(r.reminders, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package memory
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository"
+)
+
+type tagRepository struct {
+	mu     sync.RWMutex
+	nextID uint
+	tags   map[uint]models.Tag
+}
+
+func NewTagRepository() repository.TagRepository {
+	return &tagRepository{tags: make(map[uint]models.Tag)}
+}
+
+func (r *tagRepository) Create(tag *models.Tag) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.nameTaken(tag.UserID, tag.Name, 0) {
+		return repository.ErrDuplicate
+	}
+
+	now := time.Now()
+	r.nextID++
+	tag.ID = r.nextID
+	if tag.Color == "" {
+		tag.Color = models.DefaultTagColor
+	}
+	tag.CreatedAt = now
+	tag.UpdatedAt = now
+	r.tags[tag.ID] = *tag
+	return nil
+}
+
+func (r *tagRepository) FindAllByUserID(userID uint) ([]models.Tag, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tags := make([]models.Tag, 0)
+	for _, tag := range r.tags {
+		if tag.UserID == userID {
+			tags = append(tags, tag)
+		}
+	}
+	sortTags(tags)
+	return tags, nil
+}
+
+func (r *tagRepository) FindByIDAndUserID(id, userID uint) (*models.Tag, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tag, ok := r.tags[id]
+	if !ok || tag.UserID != userID {
+		return nil, repository.ErrNotFound
+	}
+	return &tag, nil
+}
+
+func (r *tagRepository) FindByIDsAndUserID(ids []uint, userID uint) ([]models.Tag, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tags := make([]models.Tag, 0, len(ids))
+	seen := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		tag, ok := r.tags[id]
+		if ok && tag.UserID == userID && !seen[id] {
+			seen[id] = true
+			tags = append(tags, tag)
+		}
+	}
+	sortTags(tags)
+	return tags, nil
+}
+
+func (r *tagRepository) Update(tag *models.Tag) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.nameTaken(tag.UserID, tag.Name, tag.ID) {
+		return repository.ErrDuplicate
+	}
+
+	tag.UpdatedAt = time.Now()
+	r.tags[tag.ID] = *tag
+	return nil
+}
+
+// Delete removes the tag; todos stop listing it because their assignments
+// are resolved against the live tag set
+func (r *tagRepository) Delete(id, userID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if tag, ok := r.tags[id]; ok && tag.UserID == userID {
+		delete(r.tags, id)
+	}
+	return nil
+}
+
+// nameTaken reports whether another tag of userID is called name.
+// It must be called with the lock held.
+func (r *tagRepository) nameTaken(userID uint, name string, exceptID uint) bool {
+	for _, tag := range r.tags {
+		if tag.UserID == userID && tag.Name == name && tag.ID != exceptID {
+			return true
+		}
+	}
+	return false
+}
+
+// sortTags orders tags by name, as the SQL repositories return them
+func sortTags(tags []models.Tag) {
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Name != tags[j].Name {
+			return tags[i].Name < tags[j].Name
+		}
+		return tags[i].ID < tags[j].ID
+	})
+}
@@ -0,0 +1,652 @@
+package memory
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository"
+	"github.com/user/go-todo-api/internal/search"
+	"gorm.io/gorm"
+)
+
+type todoRepository struct {
+	mu     sync.RWMutex
+	nextID uint
+	todos  map[uint]models.Todo
+	tagIDs map[uint][]uint // tag assignments by todo id
+
+	// tags resolves assignments to the current tag records, so renamed and
+	// deleted tags show up on todos as they would through the join table
+	tags repository.TagRepository
+	// items and series supply each todo's checklist and recurrence, as the
+	// GORM preloads do
+	items  repository.ChecklistRepository
+	series repository.SeriesRepository
+	// projects resolves each todo's project, so todos drop a deleted
+	// project as the GORM repository detaches them
+	projects repository.ProjectRepository
+}
+
+func NewTodoRepository(tags repository.TagRepository, items repository.ChecklistRepository, series repository.SeriesRepository, projects repository.ProjectRepository) repository.TodoRepository {
+	return &todoRepository{
+		todos:    make(map[uint]models.Todo),
+		tagIDs:   make(map[uint][]uint),
+		tags:     tags,
+		items:    items,
+		series:   series,
+		projects: projects,
+	}
+}
+
+func (r *todoRepository) Create(todo *models.Todo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.nextID++
+	todo.ID = r.nextID
+	if todo.Status == "" {
+		todo.Status = models.StatusPending
+	}
+	todo.CreatedAt = now
+	todo.UpdatedAt = now
+	todo.Version = 1
+	todo.StampCompletion(now)
+	r.todos[todo.ID] = cloneTodo(todo)
+	r.tagIDs[todo.ID] = tagIDs(todo.Tags)
+	return nil
+}
+
+func (r *todoRepository) FindAllByUserID(userID uint) ([]models.Todo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	todos := r.visible(func(t *models.Todo) bool { return t.UserID == userID })
+	sortTodos(todos, "created_at", "DESC")
+	return todos, r.loadAssociations(todos)
+}
+
+func (r *todoRepository) FindAllWithFilters(userID uint, params repository.QueryParams) (*repository.PaginatedResult, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// Assignments to deleted tags linger here, unlike in the join table, so
+	// only match against tags that still exist
+	live, err := r.tags.FindByIDsAndUserID(params.TagIDs, userID)
+	if err != nil {
+		return nil, err
+	}
+	liveTags := make(map[uint]bool, len(live))
+	for _, tag := range live {
+		liveTags[tag.ID] = true
+	}
+
+	q := search.Parse(params.Search)
+	scores := make(map[uint]float64)
+	todos := r.visible(func(t *models.Todo) bool {
+		if !r.canSee(t, userID) {
+			return false
+		}
+		if t.Archived && !params.IncludeArchived {
+			return false
+		}
+		if params.Status != "" && string(t.Status) != params.Status {
+			return false
+		}
+		if params.ProjectID != nil && r.projectOf(t) != *params.ProjectID {
+			return false
+		}
+		if params.AssigneeID != nil && (t.AssigneeID == nil || *t.AssigneeID != *params.AssigneeID) {
+			return false
+		}
+		if params.PriorityMin != nil && t.Priority < *params.PriorityMin {
+			return false
+		}
+		if params.PriorityMax != nil && t.Priority > *params.PriorityMax {
+			return false
+		}
+		if len(params.TagIDs) > 0 && !r.hasTags(t.ID, params.TagIDs, params.TagMode, liveTags) {
+			return false
+		}
+		if !q.Empty() {
+			score, ok := q.Match(t.Title, t.Description)
+			if !ok {
+				return false
+			}
+			scores[t.ID] = score
+		}
+		return true
+	})
+
+	if err := r.loadAssociations(todos); err != nil {
+		return nil, err
+	}
+
+	if !q.Empty() {
+		for i := range todos {
+			todos[i].Highlight = q.Highlight(todos[i].Title)
+			if todos[i].Highlight == "" {
+				todos[i].Highlight = q.Highlight(todos[i].Description)
+			}
+		}
+	}
+
+	if params.Cursor != nil {
+		return pageAfterCursor(todos, params)
+	}
+
+	sortBy, sortDir := repository.NormalizeSort(params.SortBy, params.SortDir, !q.Empty())
+	switch sortBy {
+	case repository.SortRelevance:
+		sort.Slice(todos, func(i, j int) bool {
+			a, b := scores[todos[i].ID], scores[todos[j].ID]
+			if a != b {
+				return a > b
+			}
+			return todos[i].ID > todos[j].ID
+		})
+	case repository.SortSmart:
+		sortSmart(todos, time.Now())
+	default:
+		sortTodos(todos, sortBy, sortDir)
+	}
+	pinnedFirst(todos)
+
+	page, pageSize := repository.NormalizePage(params.Page, params.PageSize)
+	total := len(todos)
+	start := min((page-1)*pageSize, total)
+	end := min(start+pageSize, total)
+	totalPages := (total + pageSize - 1) / pageSize
+
+	result := &repository.PaginatedResult{
+		Data:       todos[start:end],
+		Total:      int64(total),
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+	}
+	result.NextCursor, result.PrevCursor = repository.PageCursors(result.Data, sortBy, sortDir, page, totalPages)
+
+	return result, nil
+}
+
+// pageAfterCursor is the in-memory equivalent of the keyset query: it keeps
+// the rows strictly after (or before) the cursor's boundary row
+func pageAfterCursor(todos []models.Todo, params repository.QueryParams) (*repository.PaginatedResult, error) {
+	c := params.Cursor
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	_, limit := repository.NormalizePage(1, params.PageSize)
+
+	sortTodos(todos, c.SortBy, c.SortDir)
+	pinnedFirst(todos)
+	boundary := c.Boundary()
+	desc := c.SortDir == "DESC"
+
+	var rows []models.Todo
+	if c.Before {
+		// Closest to the boundary first, as the SQL query returns them
+		for i := len(todos) - 1; i >= 0 && len(rows) <= limit; i-- {
+			if lessListed(&todos[i], &boundary, c.SortBy, desc) {
+				rows = append(rows, todos[i])
+			}
+		}
+	} else {
+		for i := 0; i < len(todos) && len(rows) <= limit; i++ {
+			if lessListed(&boundary, &todos[i], c.SortBy, desc) {
+				rows = append(rows, todos[i])
+			}
+		}
+	}
+
+	return repository.PageFromCursor(rows, c, limit), nil
+}
+
+func (r *todoRepository) FindByID(id uint) (*models.Todo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	todo, ok := r.todos[id]
+	if !ok || todo.DeletedAt.Valid {
+		return nil, repository.ErrNotFound
+	}
+	found := []models.Todo{cloneTodo(&todo)}
+	if err := r.loadAssociations(found); err != nil {
+		return nil, err
+	}
+	return &found[0], nil
+}
+
+func (r *todoRepository) FindByIDAndUserID(id, userID uint) (*models.Todo, error) {
+	todo, err := r.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if todo.UserID != userID {
+		return nil, repository.ErrNotFound
+	}
+	return todo, nil
+}
+
+func (r *todoRepository) FindDueBetween(from, to time.Time) ([]models.Todo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	todos := r.visible(func(t *models.Todo) bool {
+		return t.DueDate != nil && !t.DueDate.Before(from) && !t.DueDate.After(to) &&
+			t.Status != models.StatusCompleted && len(t.Reminders) > 0
+	})
+	sort.Slice(todos, func(i, j int) bool {
+		if !todos[i].DueDate.Equal(*todos[j].DueDate) {
+			return todos[i].DueDate.Before(*todos[j].DueDate)
+		}
+		return todos[i].ID < todos[j].ID
+	})
+	return todos, r.loadAssociations(todos)
+}
+
+func (r *todoRepository) Update(todo *models.Todo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.todos[todo.ID]
+	if !ok || stored.DeletedAt.Valid || stored.Version != todo.Version {
+		return repository.ErrConflict
+	}
+	todo.Version++
+	todo.UpdatedAt = time.Now()
+	todo.StampCompletion(todo.UpdatedAt)
+	r.todos[todo.ID] = cloneTodo(todo)
+	r.tagIDs[todo.ID] = tagIDs(todo.Tags)
+	return nil
+}
+
+func (r *todoRepository) ArchiveCompletedBefore(before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var archived int64
+	for id, todo := range r.todos {
+		if todo.DeletedAt.Valid || todo.Archived || todo.Status != models.StatusCompleted || todo.CompletedAt == nil || !todo.CompletedAt.Before(before) {
+			continue
+		}
+		todo.Archived = true
+		todo.Version++
+		todo.UpdatedAt = time.Now()
+		r.todos[id] = todo
+		archived++
+	}
+	return archived, nil
+}
+
+func (r *todoRepository) Delete(id, userID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	todo, ok := r.todos[id]
+	if !ok || todo.UserID != userID || todo.DeletedAt.Valid {
+		return nil
+	}
+	todo.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	r.todos[id] = todo
+	return nil
+}
+
+func (r *todoRepository) FindDeletedByUserID(userID uint) ([]models.Todo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var todos []models.Todo
+	for _, todo := range r.todos {
+		if todo.DeletedAt.Valid && r.canSee(&todo, userID) {
+			todos = append(todos, cloneTodo(&todo))
+		}
+	}
+	sort.Slice(todos, func(i, j int) bool {
+		a, b := todos[i].DeletedAt.Time, todos[j].DeletedAt.Time
+		if !a.Equal(b) {
+			return a.After(b)
+		}
+		return todos[i].ID > todos[j].ID
+	})
+	return todos, r.loadAssociations(todos)
+}
+
+func (r *todoRepository) FindDeletedByID(id uint) (*models.Todo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	todo, ok := r.todos[id]
+	if !ok || !todo.DeletedAt.Valid {
+		return nil, repository.ErrNotFound
+	}
+	found := []models.Todo{cloneTodo(&todo)}
+	if err := r.loadAssociations(found); err != nil {
+		return nil, err
+	}
+	return &found[0], nil
+}
+
+func (r *todoRepository) FindDeletedByIDAndUserID(id, userID uint) (*models.Todo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	todo, ok := r.todos[id]
+	if !ok || !todo.DeletedAt.Valid || todo.UserID != userID {
+		return nil, repository.ErrNotFound
+	}
+	found := []models.Todo{cloneTodo(&todo)}
+	if err := r.loadAssociations(found); err != nil {
+		return nil, err
+	}
+	return &found[0], nil
+}
+
+func (r *todoRepository) Restore(id, userID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	todo, ok := r.todos[id]
+	if !ok || !todo.DeletedAt.Valid || todo.UserID != userID {
+		return repository.ErrNotFound
+	}
+	todo.DeletedAt = gorm.DeletedAt{}
+	todo.Version++
+	todo.UpdatedAt = time.Now()
+	r.todos[id] = todo
+	return nil
+}
+
+func (r *todoRepository) Purge(id, userID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	todo, ok := r.todos[id]
+	if !ok || todo.UserID != userID {
+		return nil
+	}
+	return r.purge(id)
+}
+
+func (r *todoRepository) PurgeDeletedBefore(before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var purged int64
+	for id, todo := range r.todos {
+		if !todo.DeletedAt.Valid || !todo.DeletedAt.Time.Before(before) {
+			continue
+		}
+		if err := r.purge(id); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+func (r *todoRepository) CountByProject(userID uint) (map[uint]models.StatusCounts, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := make(map[uint]models.StatusCounts)
+	for _, todo := range r.visible(func(t *models.Todo) bool { return r.canSee(t, userID) && !t.Archived }) {
+		projectID := r.projectOf(&todo)
+		c := counts[projectID]
+		c.Add(todo.Status, 1)
+		counts[projectID] = c
+	}
+	return counts, nil
+}
+
+// purge removes a todo with its tag assignments and checklist items. Sent
+// reminders are kept by the reminder repository and only ever looked up
+// for todos that still exist. It must be called with the lock held.
+func (r *todoRepository) purge(id uint) error {
+	items, err := r.items.FindByTodoID(id)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		if err := r.items.Delete(item.ID, id); err != nil {
+			return err
+		}
+	}
+	delete(r.todos, id)
+	delete(r.tagIDs, id)
+	return nil
+}
+
+// Transaction puts the todos back as they were when fn fails. Unlike a
+// database transaction it does not hide fn's changes from concurrent
+// callers while it runs.
+func (r *todoRepository) Transaction(fn func(repo repository.TodoRepository) error) error {
+	r.mu.Lock()
+	nextID, todos, assigned := r.nextID, maps.Clone(r.todos), maps.Clone(r.tagIDs)
+	r.mu.Unlock()
+
+	err := fn(r)
+	if err != nil {
+		r.mu.Lock()
+		r.nextID, r.todos, r.tagIDs = nextID, todos, assigned
+		r.mu.Unlock()
+	}
+	return err
+}
+
+// visible returns copies of the non-deleted todos matching keep.
+// It must be called with the lock held.
+func (r *todoRepository) visible(keep func(*models.Todo) bool) []models.Todo {
+	todos := make([]models.Todo, 0)
+	for _, todo := range r.todos {
+		if todo.DeletedAt.Valid || !keep(&todo) {
+			continue
+		}
+		todos = append(todos, cloneTodo(&todo))
+	}
+	return todos
+}
+
+// projectOf returns the ID of the todo's project, or 0 when it has none or
+// the project has been deleted. It must be called with the lock held.
+func (r *todoRepository) projectOf(todo *models.Todo) uint {
+	if todo.ProjectID == nil {
+		return 0
+	}
+	if _, err := r.projects.FindByID(*todo.ProjectID); err != nil {
+		return 0
+	}
+	return *todo.ProjectID
+}
+
+// canSee reports whether userID created the todo outside projects or owns
+// or is a member of its project. It must be called with the lock held.
+func (r *todoRepository) canSee(todo *models.Todo, userID uint) bool {
+	projectID := r.projectOf(todo)
+	if projectID == 0 {
+		return todo.UserID == userID
+	}
+	if _, err := r.projects.FindByIDAndUserID(projectID, userID); err == nil {
+		return true
+	}
+	_, err := r.projects.FindMember(projectID, userID)
+	return err == nil
+}
+
+// loadAssociations fills in the tags, checklist items and series of each
+// todo and drops deleted projects. It must be called with the lock held.
+func (r *todoRepository) loadAssociations(todos []models.Todo) error {
+	for i := range todos {
+		if r.projectOf(&todos[i]) == 0 {
+			todos[i].ProjectID = nil
+		}
+
+		tags, err := r.tags.FindByIDsAndUserID(r.tagIDs[todos[i].ID], todos[i].UserID)
+		if err != nil {
+			return err
+		}
+		todos[i].Tags = tags
+
+		items, err := r.items.FindByTodoID(todos[i].ID)
+		if err != nil {
+			return err
+		}
+		todos[i].Items = items
+
+		if todos[i].SeriesID != nil {
+			series, err := r.series.FindByIDAndUserID(*todos[i].SeriesID, todos[i].UserID)
+			if err != nil {
+				return err
+			}
+			todos[i].Series = series
+		}
+	}
+	return nil
+}
+
+// hasTags reports whether the todo carries any (or, for TagModeAll, every
+// one) of tagIDs among the live tags. It must be called with the lock held.
+func (r *todoRepository) hasTags(todoID uint, tagIDs []uint, mode string, live map[uint]bool) bool {
+	assigned := make(map[uint]bool)
+	for _, id := range r.tagIDs[todoID] {
+		assigned[id] = live[id]
+	}
+	for _, id := range tagIDs {
+		if assigned[id] && mode != repository.TagModeAll {
+			return true
+		}
+		if !assigned[id] && mode == repository.TagModeAll {
+			return false
+		}
+	}
+	return mode == repository.TagModeAll
+}
+
+// sortTodos mirrors the ORDER BY built by the GORM repository: the sort
+// column (NULL due dates last), then id in the same direction.
+func sortTodos(todos []models.Todo, sortBy, sortDir string) {
+	desc := sortDir == "DESC"
+	sort.Slice(todos, func(i, j int) bool {
+		return lessTodo(&todos[i], &todos[j], sortBy, desc)
+	})
+}
+
+// lessTodo reports whether a is listed before b
+func lessTodo(a, b *models.Todo, sortBy string, desc bool) bool {
+	if sortBy == "due_date" && (a.DueDate == nil) != (b.DueDate == nil) {
+		return b.DueDate == nil
+	}
+	if c := compareTodos(a, b, sortBy); c != 0 {
+		return (c < 0) != desc
+	}
+	if a.ID == b.ID {
+		return false
+	}
+	return (a.ID < b.ID) != desc
+}
+
+// pinnedFirst moves the pinned todos ahead of the others, keeping the order
+// within each group
+func pinnedFirst(todos []models.Todo) {
+	sort.SliceStable(todos, func(i, j int) bool {
+		return todos[i].Pinned && !todos[j].Pinned
+	})
+}
+
+// lessListed reports whether a is listed before b in a listing, where
+// pinned todos come first
+func lessListed(a, b *models.Todo, sortBy string, desc bool) bool {
+	if a.Pinned != b.Pinned {
+		return a.Pinned
+	}
+	return lessTodo(a, b, sortBy, desc)
+}
+
+// sortSmart mirrors the GORM repository's SortSmart ordering
+func sortSmart(todos []models.Todo, now time.Time) {
+	overdue := func(t *models.Todo) bool {
+		return t.DueDate != nil && t.DueDate.Before(now) && t.Status != models.StatusCompleted
+	}
+	sort.Slice(todos, func(i, j int) bool {
+		a, b := &todos[i], &todos[j]
+		if overdue(a) != overdue(b) {
+			return overdue(a)
+		}
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+		if (a.DueDate == nil) != (b.DueDate == nil) {
+			return b.DueDate == nil
+		}
+		if a.DueDate != nil && !a.DueDate.Equal(*b.DueDate) {
+			return a.DueDate.Before(*b.DueDate)
+		}
+		return a.ID > b.ID
+	})
+}
+
+func compareTodos(a, b *models.Todo, field string) int {
+	switch field {
+	case "priority":
+		return cmp.Compare(a.Priority, b.Priority)
+	case "updated_at":
+		return a.UpdatedAt.Compare(b.UpdatedAt)
+	case "title":
+		return strings.Compare(a.Title, b.Title)
+	case "status":
+		return strings.Compare(string(a.Status), string(b.Status))
+	case "due_date":
+		if a.DueDate == nil || b.DueDate == nil {
+			return 0
+		}
+		return a.DueDate.Compare(*b.DueDate)
+	default:
+		return a.CreatedAt.Compare(b.CreatedAt)
+	}
+}
+
+func cloneTodo(todo *models.Todo) models.Todo {
+	clone := *todo
+	clone.User = models.User{}
+	clone.Tags = nil
+	clone.Items = nil
+	clone.Series = nil
+	if todo.SeriesID != nil {
+		seriesID := *todo.SeriesID
+		clone.SeriesID = &seriesID
+	}
+	if todo.ProjectID != nil {
+		projectID := *todo.ProjectID
+		clone.ProjectID = &projectID
+	}
+	if todo.AssigneeID != nil {
+		assigneeID := *todo.AssigneeID
+		clone.AssigneeID = &assigneeID
+	}
+	clone.Highlight = ""
+	clone.Reminders = slices.Clone(todo.Reminders)
+	if todo.DueDate != nil {
+		due := *todo.DueDate
+		clone.DueDate = &due
+	}
+	if todo.CompletedAt != nil {
+		completed := *todo.CompletedAt
+		clone.CompletedAt = &completed
+	}
+	return clone
+}
+
+func tagIDs(tags []models.Tag) []uint {
+	ids := make([]uint, len(tags))
+	for i, tag := range tags {
+		ids[i] = tag.ID
+	}
+	return ids
+}
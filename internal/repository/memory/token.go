@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository"
+)
+
+type tokenRepository struct {
+	mu     sync.RWMutex
+	nextID uint
+	tokens map[string]models.RefreshToken
+}
+
+func NewTokenRepository() repository.TokenRepository {
+	return &tokenRepository{tokens: make(map[string]models.RefreshToken)}
+}
+
+func (r *tokenRepository) Create(userID uint, token string, expiresAt time.Time) (*models.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.tokens[token]; exists {
+		return nil, repository.ErrDuplicate
+	}
+
+	r.nextID++
+	refreshToken := models.RefreshToken{
+		ID:        r.nextID,
+		Token:     token,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	}
+	r.tokens[token] = refreshToken
+	return &refreshToken, nil
+}
+
+func (r *tokenRepository) FindByToken(token string) (*models.RefreshToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	refreshToken, ok := r.tokens[token]
+	if !ok || refreshToken.Revoked {
+		return nil, repository.ErrNotFound
+	}
+	return &refreshToken, nil
+}
+
+func (r *tokenRepository) Revoke(token string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if refreshToken, ok := r.tokens[token]; ok {
+		refreshToken.Revoked = true
+		r.tokens[token] = refreshToken
+	}
+	return nil
+}
+
+func (r *tokenRepository) RevokeAllForUser(userID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for token, refreshToken := range r.tokens {
+		if refreshToken.UserID == userID {
+			refreshToken.Revoked = true
+			r.tokens[token] = refreshToken
+		}
+	}
+	return nil
+}
+
+func (r *tokenRepository) CleanupExpired() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for token, refreshToken := range r.tokens {
+		if refreshToken.ExpiresAt.Before(now) {
+			delete(r.tokens, token)
+		}
+	}
+	return nil
+}
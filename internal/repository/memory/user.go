@@ -0,0 +1,95 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository"
+)
+
+type userRepository struct {
+	mu     sync.RWMutex
+	nextID uint
+	users  map[uint]models.User
+}
+
+func NewUserRepository() repository.UserRepository {
+	return &userRepository{users: make(map[uint]models.User)}
+}
+
+func (r *userRepository) Create(user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.findByEmail(user.Email) != nil {
+		return repository.ErrDuplicate
+	}
+
+	now := time.Now()
+	r.nextID++
+	user.ID = r.nextID
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	r.users[user.ID] = cloneUser(user)
+	return nil
+}
+
+func (r *userRepository) FindByEmail(email string) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user := r.findByEmail(email)
+	if user == nil {
+		return nil, repository.ErrNotFound
+	}
+	found := cloneUser(user)
+	return &found, nil
+}
+
+func (r *userRepository) FindByID(id uint) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	found := cloneUser(&user)
+	return &found, nil
+}
+
+func (r *userRepository) ExistsByEmail(email string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.findByEmail(email) != nil
+}
+
+func (r *userRepository) Update(user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[user.ID]; !ok {
+		return repository.ErrNotFound
+	}
+	user.UpdatedAt = time.Now()
+	r.users[user.ID] = cloneUser(user)
+	return nil
+}
+
+// findByEmail must be called with the lock held
+func (r *userRepository) findByEmail(email string) *models.User {
+	for _, user := range r.users {
+		if user.Email == email {
+			return &user
+		}
+	}
+	return nil
+}
+
+func cloneUser(user *models.User) models.User {
+	clone := *user
+	clone.Todos = nil
+	return clone
+}
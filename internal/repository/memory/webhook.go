@@ -0,0 +1,137 @@
+package memory
+
+import (
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository"
+)
+
+type webhookRepository struct {
+	mu             sync.RWMutex
+	nextID         uint
+	nextDeliveryID uint
+	hooks          map[uint]models.Webhook
+	deliveries     map[uint][]models.WebhookDelivery // by webhook, oldest first
+}
+
+func NewWebhookRepository() repository.WebhookRepository {
+	return &webhookRepository{
+		hooks:      make(map[uint]models.Webhook),
+		deliveries: make(map[uint][]models.WebhookDelivery),
+	}
+}
+
+func (r *webhookRepository) Create(hook *models.Webhook) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.nextID++
+	hook.ID = r.nextID
+	hook.CreatedAt = now
+	hook.UpdatedAt = now
+	r.hooks[hook.ID] = cloneWebhook(hook)
+	return nil
+}
+
+func (r *webhookRepository) FindAllByUserID(userID uint) ([]models.Webhook, error) {
+	return r.find(func(hook *models.Webhook) bool { return hook.UserID == userID }), nil
+}
+
+func (r *webhookRepository) FindByID(id uint) (*models.Webhook, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	hook, ok := r.hooks[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	found := cloneWebhook(&hook)
+	return &found, nil
+}
+
+func (r *webhookRepository) FindByIDAndUserID(id, userID uint) (*models.Webhook, error) {
+	hook, err := r.FindByID(id)
+	if err != nil || hook.UserID != userID {
+		return nil, repository.ErrNotFound
+	}
+	return hook, nil
+}
+
+func (r *webhookRepository) FindSubscribed(userID uint, event string) ([]models.Webhook, error) {
+	return r.find(func(hook *models.Webhook) bool {
+		return hook.UserID == userID && hook.Active && hook.Events.Has(event)
+	}), nil
+}
+
+func (r *webhookRepository) Update(hook *models.Webhook) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	hook.UpdatedAt = time.Now()
+	r.hooks[hook.ID] = cloneWebhook(hook)
+	return nil
+}
+
+func (r *webhookRepository) Delete(id, userID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if hook, ok := r.hooks[id]; ok && hook.UserID == userID {
+		delete(r.hooks, id)
+		delete(r.deliveries, id)
+	}
+	return nil
+}
+
+func (r *webhookRepository) CreateDelivery(delivery *models.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextDeliveryID++
+	delivery.ID = r.nextDeliveryID
+	if delivery.CreatedAt.IsZero() {
+		delivery.CreatedAt = time.Now()
+	}
+	r.deliveries[delivery.WebhookID] = append(r.deliveries[delivery.WebhookID], *delivery)
+	return nil
+}
+
+func (r *webhookRepository) FindDeliveries(webhookID uint, limit int) ([]models.WebhookDelivery, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	log := r.deliveries[webhookID]
+	deliveries := make([]models.WebhookDelivery, 0, min(limit, len(log)))
+	for i := len(log) - 1; i >= 0 && len(deliveries) < limit; i-- {
+		deliveries = append(deliveries, log[i])
+	}
+	return deliveries, nil
+}
+
+// find returns the webhooks matching keep, ordered by ID
+func (r *webhookRepository) find(keep func(*models.Webhook) bool) []models.Webhook {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	hooks := make([]models.Webhook, 0)
+	for _, hook := range r.hooks {
+		if keep(&hook) {
+			hooks = append(hooks, cloneWebhook(&hook))
+		}
+	}
+	sort.Slice(hooks, func(i, j int) bool { return hooks[i].ID < hooks[j].ID })
+	return hooks
+}
+
+// cloneWebhook copies hook so stored and returned webhooks never share
+// their event list
+func cloneWebhook(hook *models.Webhook) models.Webhook {
+	clone := *hook
+	clone.Events = slices.Clone(hook.Events)
+	return clone
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/user/go-todo-api/internal/models"
+	"gorm.io/gorm"
+)
+
+// projectRepository is the GORM implementation of ProjectRepository
+type projectRepository struct {
+	db *gorm.DB
+}
+
+func NewProjectRepository(db *gorm.DB) ProjectRepository {
+	return &projectRepository{db: db}
+}
+
+// Create appends the project after the user's last project
+func (r *projectRepository) Create(project *models.Project) error {
+	if project.Color == "" {
+		project.Color = models.DefaultProjectColor
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var last struct{ Position *int }
+		err := tx.Model(&models.Project{}).Select("MAX(position) AS position").
+			Where("user_id = ?", project.UserID).Scan(&last).Error
+		if err != nil {
+			return err
+		}
+		project.Position = 0
+		if last.Position != nil {
+			project.Position = *last.Position + 1
+		}
+		return tx.Create(project).Error
+	})
+}
+
+func (r *projectRepository) FindAllByUserID(userID uint) ([]models.Project, error) {
+	projects := make([]models.Project, 0)
+	err := r.db.Where("user_id = ?", userID).Order("position, id").Find(&projects).Error
+	return projects, err
+}
+
+func (r *projectRepository) FindSharedWithUser(userID uint) ([]models.Project, error) {
+	projects := make([]models.Project, 0)
+	err := r.db.Where("id IN (SELECT project_id FROM project_members WHERE user_id = ?)", userID).
+		Order("name, id").Find(&projects).Error
+	return projects, err
+}
+
+func (r *projectRepository) FindByID(id uint) (*models.Project, error) {
+	var project models.Project
+	err := r.db.First(&project, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+func (r *projectRepository) FindByIDAndUserID(id, userID uint) (*models.Project, error) {
+	var project models.Project
+	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&project).Error
+	if err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+func (r *projectRepository) Update(project *models.Project) error {
+	return r.db.Save(project).Error
+}
+
+// Reorder numbers the user's projects in the order of ids
+func (r *projectRepository) Reorder(userID uint, ids []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for position, id := range ids {
+			err := tx.Model(&models.Project{}).
+				Where("id = ? AND user_id = ?", id, userID).
+				Update("position", position).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Delete removes the project with its members and detaches its todos, those
+// in the trash included, incrementing their Version. Todos and members are
+// let go of before the project row, which they reference; members are
+// deleted explicitly since SQLite only cascades when foreign keys are
+// switched on.
+func (r *projectRepository) Delete(id, userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var project models.Project
+		err := tx.Where("id = ? AND user_id = ?", id, userID).First(&project).Error
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		err = tx.Unscoped().Model(&models.Todo{}).Where("project_id = ?", id).
+			Updates(map[string]interface{}{"project_id": nil, "version": gorm.Expr("version + 1")}).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("project_id = ?", id).Delete(&models.ProjectMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&project).Error
+	})
+}
+
+func (r *projectRepository) AddMember(member *models.ProjectMember) error {
+	return r.db.Create(member).Error
+}
+
+func (r *projectRepository) FindMembers(projectID uint) ([]models.ProjectMember, error) {
+	members := make([]models.ProjectMember, 0)
+	err := r.db.Where("project_id = ?", projectID).Order("id").Find(&members).Error
+	return members, err
+}
+
+func (r *projectRepository) FindMember(projectID, userID uint) (*models.ProjectMember, error) {
+	var member models.ProjectMember
+	err := r.db.Where("project_id = ? AND user_id = ?", projectID, userID).First(&member).Error
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+func (r *projectRepository) UpdateMember(member *models.ProjectMember) error {
+	return r.db.Save(member).Error
+}
+
+func (r *projectRepository) RemoveMember(projectID, userID uint) error {
+	return r.db.Where("project_id = ? AND user_id = ?", projectID, userID).Delete(&models.ProjectMember{}).Error
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"github.com/user/go-todo-api/internal/models"
+	"gorm.io/gorm"
+)
+
+// seriesRepository is the GORM implementation of SeriesRepository
+type seriesRepository struct {
+	db *gorm.DB
+}
+
+func NewSeriesRepository(db *gorm.DB) SeriesRepository {
+	return &seriesRepository{db: db}
+}
+
+func (r *seriesRepository) Create(series *models.RecurrenceSeries) error {
+	return r.db.Create(series).Error
+}
+
+func (r *seriesRepository) FindByIDAndUserID(id, userID uint) (*models.RecurrenceSeries, error) {
+	var series models.RecurrenceSeries
+	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&series).Error
+	if err != nil {
+		return nil, err
+	}
+	return &series, nil
+}
+
+func (r *seriesRepository) Update(series *models.RecurrenceSeries) error {
+	return r.db.Save(series).Error
+}
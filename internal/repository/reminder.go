@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"github.com/user/go-todo-api/internal/models"
+	"gorm.io/gorm"
+)
+
+// reminderRepository is the GORM implementation of ReminderRepository
+type reminderRepository struct {
+	db *gorm.DB
+}
+
+func NewReminderRepository(db *gorm.DB) ReminderRepository {
+	return &reminderRepository{db: db}
+}
+
+func (r *reminderRepository) Create(reminder *models.Reminder) error {
+	return r.db.Create(reminder).Error
+}
+
+func (r *reminderRepository) Delete(id uint) error {
+	return r.db.Delete(&models.Reminder{}, id).Error
+}
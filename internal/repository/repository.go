@@ -0,0 +1,237 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/user/go-todo-api/internal/models"
+	"gorm.io/gorm"
+)
+
+// ErrNotFound is returned by every implementation when a record does not exist.
+// It aliases gorm.ErrRecordNotFound so callers can check either.
+var ErrNotFound = gorm.ErrRecordNotFound
+
+// ErrDuplicate is returned when a unique constraint would be violated.
+// database.Open enables GORM error translation so the SQL backends report it too.
+var ErrDuplicate = gorm.ErrDuplicatedKey
+
+// ErrConflict is returned when a record changed after it was read, so
+// writing it would overwrite someone else's change
+var ErrConflict = errors.New("record was modified concurrently")
+
+// TodoRepository persists todos. Todos are returned with their Tags,
+// checklist Items and recurrence Series loaded. Create and Update store todo.Tags as the complete
+// set of assignments; Items are managed through ChecklistRepository.
+type TodoRepository interface {
+	Create(todo *models.Todo) error
+	FindAllByUserID(userID uint) ([]models.Todo, error)
+	// FindAllWithFilters lists the todos userID can see: their own and
+	// those in the projects they own or are a member of
+	FindAllWithFilters(userID uint, params QueryParams) (*PaginatedResult, error)
+	FindByID(id uint) (*models.Todo, error)
+	FindByIDAndUserID(id, userID uint) (*models.Todo, error)
+	// FindDueBetween returns every user's open todos with reminders that
+	// are due within [from, to], soonest first
+	FindDueBetween(from, to time.Time) ([]models.Todo, error)
+	// Update saves the todo if its Version is still the stored one, and
+	// increments it; otherwise, or when the todo has been deleted, it
+	// returns ErrConflict
+	Update(todo *models.Todo) error
+	// ArchiveCompletedBefore archives the todos completed before before,
+	// however recently they were edited since, incrementing their Version
+	ArchiveCompletedBefore(before time.Time) (int64, error)
+	// Delete moves the todo to the trash; the Find methods other than
+	// FindDeleted* no longer return it
+	Delete(id, userID uint) error
+	// FindDeletedByUserID returns the todos in the trash that userID can
+	// see, as listed by FindAllWithFilters, most recently deleted first
+	FindDeletedByUserID(userID uint) ([]models.Todo, error)
+	FindDeletedByID(id uint) (*models.Todo, error)
+	FindDeletedByIDAndUserID(id, userID uint) (*models.Todo, error)
+	// Restore takes a todo out of the trash and increments its Version;
+	// ErrNotFound when the user created no such todo in the trash
+	Restore(id, userID uint) error
+	// Purge deletes the todo for good, whether or not it is in the trash,
+	// together with its tag assignments, checklist items and sent reminders
+	Purge(id, userID uint) error
+	// PurgeDeletedBefore purges every todo moved to the trash before before
+	PurgeDeletedBefore(before time.Time) (int64, error)
+	// CountByProject counts the todos userID can see, as listed by
+	// FindAllWithFilters, by project and status, leaving out archived
+	// todos and those in the trash. Todos without a project are counted
+	// under 0.
+	CountByProject(userID uint) (map[uint]models.StatusCounts, error)
+	// Transaction runs fn with a repository whose changes are committed
+	// together when fn returns nil and discarded otherwise. fn should not
+	// use other repositories, which may be waiting for the same connection.
+	Transaction(fn func(repo TodoRepository) error) error
+}
+
+// TagRepository persists per-user tags. Names are unique per user; Create
+// and Update report a clash as ErrDuplicate.
+type TagRepository interface {
+	Create(tag *models.Tag) error
+	FindAllByUserID(userID uint) ([]models.Tag, error)
+	FindByIDAndUserID(id, userID uint) (*models.Tag, error)
+	// FindByIDsAndUserID returns the tags among ids owned by userID, skipping the rest
+	FindByIDsAndUserID(ids []uint, userID uint) ([]models.Tag, error)
+	Update(tag *models.Tag) error
+	Delete(id, userID uint) error
+}
+
+// ProjectRepository persists projects and the members they are shared
+// with. Names are unique per owner; Create and Update report a clash as
+// ErrDuplicate. The *ByUserID methods only cover the projects a user owns.
+type ProjectRepository interface {
+	// Create appends the project after the user's last one
+	Create(project *models.Project) error
+	// FindAllByUserID returns the user's projects, archived ones included,
+	// in position order
+	FindAllByUserID(userID uint) ([]models.Project, error)
+	// FindSharedWithUser returns the projects of other users that userID
+	// is a member of, by name
+	FindSharedWithUser(userID uint) ([]models.Project, error)
+	FindByID(id uint) (*models.Project, error)
+	FindByIDAndUserID(id, userID uint) (*models.Project, error)
+	Update(project *models.Project) error
+	// Reorder sets the position of each of the user's projects in ids to its index
+	Reorder(userID uint, ids []uint) error
+	// Delete removes the project and its members; its todos are kept,
+	// without a project
+	Delete(id, userID uint) error
+	// AddMember shares a project, or returns ErrDuplicate when the user
+	// already is a member
+	AddMember(member *models.ProjectMember) error
+	// FindMembers returns the members of a project in the order they joined
+	FindMembers(projectID uint) ([]models.ProjectMember, error)
+	// FindMember returns ErrNotFound when the project is not shared with userID
+	FindMember(projectID, userID uint) (*models.ProjectMember, error)
+	UpdateMember(member *models.ProjectMember) error
+	RemoveMember(projectID, userID uint) error
+}
+
+// ChecklistRepository persists the checklist items of todos. Callers check
+// that the todo belongs to the user before touching its items.
+type ChecklistRepository interface {
+	Create(item *models.ChecklistItem) error
+	FindByTodoID(todoID uint) ([]models.ChecklistItem, error)
+	FindByIDAndTodoID(id, todoID uint) (*models.ChecklistItem, error)
+	Update(item *models.ChecklistItem) error
+	// Reorder sets the position of each item in ids to its index
+	Reorder(todoID uint, ids []uint) error
+	Delete(id, todoID uint) error
+}
+
+// SeriesRepository persists the recurrence series behind recurring todos
+type SeriesRepository interface {
+	Create(series *models.RecurrenceSeries) error
+	FindByIDAndUserID(id, userID uint) (*models.RecurrenceSeries, error)
+	Update(series *models.RecurrenceSeries) error
+}
+
+// ReminderRepository records the due-date reminders that have been sent.
+// Create reports a reminder already recorded for the same todo, due date
+// and offset as ErrDuplicate.
+type ReminderRepository interface {
+	Create(reminder *models.Reminder) error
+	// Delete forgets a reminder, e.g. one that could not be sent after all,
+	// so it is sent again
+	Delete(id uint) error
+}
+
+// JobRepository persists the background job queue
+type JobRepository interface {
+	Create(job *models.Job) error
+	FindByID(id uint) (*models.Job, error)
+	// Claim marks the pending job that has been due longest by now as
+	// running, counting the attempt, and returns it; ErrNotFound when none
+	// is due. Concurrent callers never claim the same job.
+	Claim(now time.Time) (*models.Job, error)
+	Update(job *models.Job) error
+	// RequeueStale returns jobs left running since before lockedBefore,
+	// such as those of a crashed worker, to pending
+	RequeueStale(lockedBefore time.Time) (int64, error)
+	// DeleteFinishedBefore deletes the done jobs last updated before
+	// before; dead jobs are kept for inspection
+	DeleteFinishedBefore(before time.Time) (int64, error)
+}
+
+// WebhookRepository persists webhook subscriptions and their delivery log
+type WebhookRepository interface {
+	Create(hook *models.Webhook) error
+	FindAllByUserID(userID uint) ([]models.Webhook, error)
+	FindByID(id uint) (*models.Webhook, error)
+	FindByIDAndUserID(id, userID uint) (*models.Webhook, error)
+	// FindSubscribed returns the user's active webhooks subscribed to event
+	FindSubscribed(userID uint, event string) ([]models.Webhook, error)
+	Update(hook *models.Webhook) error
+	// Delete removes the webhook and its delivery log
+	Delete(id, userID uint) error
+	CreateDelivery(delivery *models.WebhookDelivery) error
+	// FindDeliveries returns the latest limit deliveries of a webhook, newest first
+	FindDeliveries(webhookID uint, limit int) ([]models.WebhookDelivery, error)
+}
+
+// IdempotencyRepository stores the requests sent with an Idempotency-Key
+// header. A key is scoped to a user, method and path.
+type IdempotencyRepository interface {
+	// Create records a new request, or returns ErrDuplicate when the key
+	// is already recorded for the same user, method and path
+	Create(key *models.IdempotencyKey) error
+	Find(userID uint, method, path, key string) (*models.IdempotencyKey, error)
+	Update(key *models.IdempotencyKey) error
+	Delete(id uint) error
+	// DeleteExpired removes the keys that expired before now
+	DeleteExpired(now time.Time) (int64, error)
+}
+
+// UserRepository persists user accounts
+type UserRepository interface {
+	Create(user *models.User) error
+	FindByEmail(email string) (*models.User, error)
+	FindByID(id uint) (*models.User, error)
+	ExistsByEmail(email string) bool
+	Update(user *models.User) error
+}
+
+// TokenRepository persists refresh tokens
+type TokenRepository interface {
+	Create(userID uint, token string, expiresAt time.Time) (*models.RefreshToken, error)
+	FindByToken(token string) (*models.RefreshToken, error)
+	Revoke(token string) error
+	RevokeAllForUser(userID uint) error
+	CleanupExpired() error
+}
+
+// Repositories bundles the data access layer handed to the HTTP handlers
+type Repositories struct {
+	Todos       TodoRepository
+	Tags        TagRepository
+	Projects    ProjectRepository
+	Items       ChecklistRepository
+	Series      SeriesRepository
+	Reminders   ReminderRepository
+	Jobs        JobRepository
+	Webhooks    WebhookRepository
+	Idempotency IdempotencyRepository
+	Users       UserRepository
+	Tokens      TokenRepository
+}
+
+// NewRepositories returns the GORM-backed implementations for db
+func NewRepositories(db *gorm.DB) Repositories {
+	return Repositories{
+		Todos:       NewTodoRepository(db),
+		Tags:        NewTagRepository(db),
+		Projects:    NewProjectRepository(db),
+		Items:       NewChecklistRepository(db),
+		Series:      NewSeriesRepository(db),
+		Reminders:   NewReminderRepository(db),
+		Jobs:        NewJobRepository(db),
+		Webhooks:    NewWebhookRepository(db),
+		Idempotency: NewIdempotencyRepository(db),
+		Users:       NewUserRepository(db),
+		Tokens:      NewTokenRepository(db),
+	}
+}
@@ -0,0 +1,46 @@
+package repository_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/user/go-todo-api/internal/config"
+	"github.com/user/go-todo-api/internal/database"
+	"github.com/user/go-todo-api/internal/migrate"
+	"github.com/user/go-todo-api/internal/repository"
+	"github.com/user/go-todo-api/internal/repository/repotest"
+	"gorm.io/gorm"
+)
+
+// TestConformance runs the shared suite against the GORM repositories on the
+// backend selected by DB_DRIVER (in-memory SQLite by default). SQLite checks
+// foreign keys here, as Postgres always does, so a write order that only
+// works without them fails on either backend.
+func TestConformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repository.Repositories {
+		cfg := config.Load()
+		if cfg.DBDriver == database.DriverSQLite {
+			cfg.DBPath = ":memory:"
+		}
+
+		db, err := database.Open(cfg, &gorm.Config{})
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			if sqlDB, err := db.DB(); err == nil {
+				sqlDB.Close()
+			}
+		})
+
+		m, err := migrate.New(db)
+		require.NoError(t, err)
+		_, err = m.To(0)
+		require.NoError(t, err)
+		_, err = m.Up()
+		require.NoError(t, err)
+		if cfg.DBDriver == database.DriverSQLite {
+			require.NoError(t, db.Exec("PRAGMA foreign_keys = ON").Error)
+		}
+
+		return repository.NewRepositories(db)
+	})
+}
@@ -0,0 +1,1370 @@
+// Package repotest is a conformance suite for the repository interfaces.
+// Every backend (GORM over SQLite/Postgres, in-memory) runs the same tests so
+// they stay interchangeable.
+package repotest
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository"
+)
+
+// Factory returns a fresh, empty set of repositories for one test
+type Factory func(t *testing.T) repository.Repositories
+
+// Run executes the whole suite against the backend built by newRepos
+func Run(t *testing.T, newRepos Factory) {
+	t.Run("Users", func(t *testing.T) { testUsers(t, newRepos(t)) })
+	t.Run("Tokens", func(t *testing.T) { testTokens(t, newRepos(t)) })
+	t.Run("Todos", func(t *testing.T) { testTodos(t, newRepos(t)) })
+	t.Run("TodoFilters", func(t *testing.T) { testTodoFilters(t, newRepos(t)) })
+	t.Run("TodoSearch", func(t *testing.T) { testTodoSearch(t, newRepos(t)) })
+	t.Run("TodoCursors", func(t *testing.T) { testTodoCursors(t, newRepos(t)) })
+	t.Run("TodoPriority", func(t *testing.T) { testTodoPriority(t, newRepos(t)) })
+	t.Run("TodoTransaction", func(t *testing.T) { testTodoTransaction(t, newRepos(t)) })
+	t.Run("TodoVersion", func(t *testing.T) { testTodoVersion(t, newRepos(t)) })
+	t.Run("TodoTrash", func(t *testing.T) { testTodoTrash(t, newRepos(t)) })
+	t.Run("TodoArchive", func(t *testing.T) { testTodoArchive(t, newRepos(t)) })
+	t.Run("Checklist", func(t *testing.T) { testChecklist(t, newRepos(t)) })
+	t.Run("Series", func(t *testing.T) { testSeries(t, newRepos(t)) })
+	t.Run("Reminders", func(t *testing.T) { testReminders(t, newRepos(t)) })
+	t.Run("Jobs", func(t *testing.T) { testJobs(t, newRepos(t)) })
+	t.Run("Webhooks", func(t *testing.T) { testWebhooks(t, newRepos(t)) })
+	t.Run("Idempotency", func(t *testing.T) { testIdempotency(t, newRepos(t)) })
+	t.Run("Tags", func(t *testing.T) { testTags(t, newRepos(t)) })
+	t.Run("TodoTags", func(t *testing.T) { testTodoTags(t, newRepos(t)) })
+	t.Run("Projects", func(t *testing.T) { testProjects(t, newRepos(t)) })
+	t.Run("TodoProjects", func(t *testing.T) { testTodoProjects(t, newRepos(t)) })
+	t.Run("ProjectMembers", func(t *testing.T) { testProjectMembers(t, newRepos(t)) })
+	t.Run("TodoAssignees", func(t *testing.T) { testTodoAssignees(t, newRepos(t)) })
+}
+
+// createUser inserts a user with a unique email
+func createUser(t *testing.T, repos repository.Repositories, name string) *models.User {
+	t.Helper()
+	user := &models.User{Email: name + "@example.com", Password: "hash", Name: name}
+	require.NoError(t, repos.Users.Create(user))
+	return user
+}
+
+func createTodo(t *testing.T, repos repository.Repositories, todo models.Todo) *models.Todo {
+	t.Helper()
+	if todo.Status == "" {
+		todo.Status = models.StatusPending
+	}
+	require.NoError(t, repos.Todos.Create(&todo))
+	return &todo
+}
+
+func createTag(t *testing.T, repos repository.Repositories, userID uint, name string) *models.Tag {
+	t.Helper()
+	tag := &models.Tag{UserID: userID, Name: name}
+	require.NoError(t, repos.Tags.Create(tag))
+	return tag
+}
+
+func testUsers(t *testing.T, repos repository.Repositories) {
+	user := createUser(t, repos, "alice")
+	assert.NotZero(t, user.ID)
+	assert.False(t, user.CreatedAt.IsZero())
+
+	err := repos.Users.Create(&models.User{Email: "alice@example.com", Password: "x", Name: "Other"})
+	assert.ErrorIs(t, err, repository.ErrDuplicate)
+
+	found, err := repos.Users.FindByEmail("alice@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, found.ID)
+	assert.Equal(t, "alice", found.Name)
+
+	found, err = repos.Users.FindByID(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, user.Email, found.Email)
+
+	_, err = repos.Users.FindByEmail("missing@example.com")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	_, err = repos.Users.FindByID(user.ID + 1000)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	assert.True(t, repos.Users.ExistsByEmail("alice@example.com"))
+	assert.False(t, repos.Users.ExistsByEmail("bob@example.com"))
+
+	found.Timezone = "Europe/Berlin"
+	found.QuietHoursStart, found.QuietHoursEnd = "22:00", "07:00"
+	require.NoError(t, repos.Users.Update(found))
+	found, err = repos.Users.FindByID(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Europe/Berlin", found.Timezone)
+	assert.Equal(t, "22:00", found.QuietHoursStart)
+	assert.Equal(t, "07:00", found.QuietHoursEnd)
+}
+
+func testTokens(t *testing.T, repos repository.Repositories) {
+	alice := createUser(t, repos, "alice")
+	bob := createUser(t, repos, "bob")
+	future := time.Now().Add(time.Hour)
+
+	token, err := repos.Tokens.Create(alice.ID, "token-a1", future)
+	require.NoError(t, err)
+	assert.NotZero(t, token.ID)
+
+	_, err = repos.Tokens.Create(alice.ID, "token-a1", future)
+	assert.ErrorIs(t, err, repository.ErrDuplicate)
+
+	found, err := repos.Tokens.FindByToken("token-a1")
+	require.NoError(t, err)
+	assert.Equal(t, alice.ID, found.UserID)
+
+	require.NoError(t, repos.Tokens.Revoke("token-a1"))
+	_, err = repos.Tokens.FindByToken("token-a1")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	_, err = repos.Tokens.Create(alice.ID, "token-a2", future)
+	require.NoError(t, err)
+	_, err = repos.Tokens.Create(bob.ID, "token-b1", future)
+	require.NoError(t, err)
+	require.NoError(t, repos.Tokens.RevokeAllForUser(alice.ID))
+	_, err = repos.Tokens.FindByToken("token-a2")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	_, err = repos.Tokens.FindByToken("token-b1")
+	assert.NoError(t, err)
+
+	_, err = repos.Tokens.Create(bob.ID, "token-expired", time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	require.NoError(t, repos.Tokens.CleanupExpired())
+	_, err = repos.Tokens.FindByToken("token-expired")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	_, err = repos.Tokens.FindByToken("token-b1")
+	assert.NoError(t, err)
+}
+
+func testTodos(t *testing.T, repos repository.Repositories) {
+	alice := createUser(t, repos, "alice")
+	bob := createUser(t, repos, "bob")
+
+	due := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+	todo := createTodo(t, repos, models.Todo{Title: "Write tests", Description: "conformance", DueDate: &due, UserID: alice.ID})
+	assert.NotZero(t, todo.ID)
+	assert.False(t, todo.CreatedAt.IsZero())
+
+	found, err := repos.Todos.FindByID(todo.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Write tests", found.Title)
+	assert.Equal(t, models.StatusPending, found.Status)
+	require.NotNil(t, found.DueDate)
+	assert.True(t, due.Equal(*found.DueDate))
+
+	_, err = repos.Todos.FindByIDAndUserID(todo.ID, bob.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	// Mutating a returned value must not leak into the store
+	found.Title = "changed locally"
+	again, err := repos.Todos.FindByIDAndUserID(todo.ID, alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Write tests", again.Title)
+
+	again.Status = models.StatusCompleted
+	again.Description = ""
+	require.NoError(t, repos.Todos.Update(again))
+	updated, err := repos.Todos.FindByID(todo.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusCompleted, updated.Status)
+	assert.Empty(t, updated.Description)
+
+	list, err := repos.Todos.FindAllByUserID(alice.ID)
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+
+	// Deleting someone else's todo is a no-op
+	require.NoError(t, repos.Todos.Delete(todo.ID, bob.ID))
+	_, err = repos.Todos.FindByID(todo.ID)
+	assert.NoError(t, err)
+
+	require.NoError(t, repos.Todos.Delete(todo.ID, alice.ID))
+	_, err = repos.Todos.FindByID(todo.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	list, err = repos.Todos.FindAllByUserID(alice.ID)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+}
+
+func testTodoTransaction(t *testing.T, repos repository.Repositories) {
+	alice := createUser(t, repos, "alice")
+	kept := createTodo(t, repos, models.Todo{Title: "Kept", UserID: alice.ID})
+
+	// A failing transaction leaves no trace
+	errAbort := errors.New("abort")
+	err := repos.Todos.Transaction(func(tx repository.TodoRepository) error {
+		require.NoError(t, tx.Create(&models.Todo{Title: "Discarded", UserID: alice.ID}))
+		found, err := tx.FindByIDAndUserID(kept.ID, alice.ID)
+		require.NoError(t, err)
+		found.Status = models.StatusCompleted
+		require.NoError(t, tx.Update(found))
+		return errAbort
+	})
+	assert.ErrorIs(t, err, errAbort)
+
+	list, err := repos.Todos.FindAllByUserID(alice.ID)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, "Kept", list[0].Title)
+	assert.Equal(t, models.StatusPending, list[0].Status)
+
+	// A successful one commits every change
+	var created models.Todo
+	err = repos.Todos.Transaction(func(tx repository.TodoRepository) error {
+		created = models.Todo{Title: "Committed", UserID: alice.ID}
+		if err := tx.Create(&created); err != nil {
+			return err
+		}
+		return tx.Delete(kept.ID, alice.ID)
+	})
+	require.NoError(t, err)
+
+	list, err = repos.Todos.FindAllByUserID(alice.ID)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, created.ID, list[0].ID)
+}
+
+func testTodoVersion(t *testing.T, repos repository.Repositories) {
+	alice := createUser(t, repos, "alice")
+	todo := createTodo(t, repos, models.Todo{Title: "Shared", UserID: alice.ID})
+	assert.EqualValues(t, 1, todo.Version)
+
+	phone, err := repos.Todos.FindByID(todo.ID)
+	require.NoError(t, err)
+	laptop, err := repos.Todos.FindByID(todo.ID)
+	require.NoError(t, err)
+
+	phone.Title = "Edited on the phone"
+	require.NoError(t, repos.Todos.Update(phone))
+	assert.EqualValues(t, 2, phone.Version)
+
+	// The laptop read version 1, so its write would lose the phone's edit
+	laptop.Title = "Edited on the laptop"
+	assert.ErrorIs(t, repos.Todos.Update(laptop), repository.ErrConflict)
+	assert.EqualValues(t, 1, laptop.Version)
+
+	found, err := repos.Todos.FindByID(todo.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Edited on the phone", found.Title)
+	assert.EqualValues(t, 2, found.Version)
+
+	require.NoError(t, repos.Todos.Delete(todo.ID, alice.ID))
+	assert.ErrorIs(t, repos.Todos.Update(found), repository.ErrConflict)
+}
+
+func testTodoTrash(t *testing.T, repos repository.Repositories) {
+	alice := createUser(t, repos, "alice")
+	bob := createUser(t, repos, "bob")
+	work := createTag(t, repos, alice.ID, "work")
+	first := createTodo(t, repos, models.Todo{Title: "First", UserID: alice.ID, Tags: []models.Tag{*work}})
+	second := createTodo(t, repos, models.Todo{Title: "Second", UserID: alice.ID})
+	kept := createTodo(t, repos, models.Todo{Title: "Kept", UserID: alice.ID})
+	require.NoError(t, repos.Items.Create(&models.ChecklistItem{TodoID: first.ID, Title: "Step"}))
+
+	trash, err := repos.Todos.FindDeletedByUserID(alice.ID)
+	require.NoError(t, err)
+	assert.Empty(t, trash)
+
+	require.NoError(t, repos.Todos.Delete(first.ID, alice.ID))
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, repos.Todos.Delete(second.ID, alice.ID))
+
+	trash, err = repos.Todos.FindDeletedByUserID(alice.ID)
+	require.NoError(t, err)
+	require.Len(t, trash, 2)
+	assert.Equal(t, second.ID, trash[0].ID, "most recently deleted first")
+	assert.True(t, trash[1].DeletedAt.Valid)
+	require.Len(t, trash[1].Tags, 1)
+	assert.Len(t, trash[1].Items, 1)
+	trash, err = repos.Todos.FindDeletedByUserID(bob.ID)
+	require.NoError(t, err)
+	assert.Empty(t, trash)
+
+	_, err = repos.Todos.FindDeletedByIDAndUserID(kept.ID, alice.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	_, err = repos.Todos.FindDeletedByIDAndUserID(first.ID, bob.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	// Restoring brings the todo back as it was, under a new version
+	assert.ErrorIs(t, repos.Todos.Restore(first.ID, bob.ID), repository.ErrNotFound)
+	assert.ErrorIs(t, repos.Todos.Restore(kept.ID, alice.ID), repository.ErrNotFound)
+	require.NoError(t, repos.Todos.Restore(first.ID, alice.ID))
+	restored, err := repos.Todos.FindByIDAndUserID(first.ID, alice.ID)
+	require.NoError(t, err)
+	assert.False(t, restored.DeletedAt.Valid)
+	assert.EqualValues(t, 2, restored.Version)
+	assert.Len(t, restored.Tags, 1)
+	assert.Len(t, restored.Items, 1)
+	assert.ErrorIs(t, repos.Todos.Restore(first.ID, alice.ID), repository.ErrNotFound)
+
+	// Purging works in and out of the trash, and only for the owner
+	require.NoError(t, repos.Todos.Purge(first.ID, bob.ID))
+	_, err = repos.Todos.FindByID(first.ID)
+	require.NoError(t, err)
+	require.NoError(t, repos.Todos.Purge(first.ID, alice.ID))
+	require.NoError(t, repos.Todos.Purge(second.ID, alice.ID))
+	_, err = repos.Todos.FindByID(first.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	items, err := repos.Items.FindByTodoID(first.ID)
+	require.NoError(t, err)
+	assert.Empty(t, items)
+	trash, err = repos.Todos.FindDeletedByUserID(alice.ID)
+	require.NoError(t, err)
+	assert.Empty(t, trash)
+	assert.ErrorIs(t, repos.Todos.Restore(second.ID, alice.ID), repository.ErrNotFound)
+
+	// Only todos deleted before the cutoff are purged
+	old := createTodo(t, repos, models.Todo{Title: "Old", UserID: bob.ID})
+	require.NoError(t, repos.Todos.Delete(old.ID, bob.ID))
+	cutoff := models.Now().Add(time.Second)
+	n, err := repos.Todos.PurgeDeletedBefore(cutoff.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	n, err = repos.Todos.PurgeDeletedBefore(cutoff)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, n)
+	trash, err = repos.Todos.FindDeletedByUserID(bob.ID)
+	require.NoError(t, err)
+	assert.Empty(t, trash)
+	_, err = repos.Todos.FindByID(kept.ID)
+	assert.NoError(t, err)
+
+	// Todos deleted from a project are in the trash of everyone who can
+	// see the project
+	carol := createUser(t, repos, "carol")
+	project := createProject(t, repos, alice.ID, "Work")
+	require.NoError(t, repos.Projects.AddMember(&models.ProjectMember{ProjectID: project.ID, UserID: bob.ID, Role: models.RoleViewer}))
+	shared := createTodo(t, repos, models.Todo{Title: "Shared", UserID: bob.ID, ProjectID: &project.ID})
+	require.NoError(t, repos.Todos.Delete(shared.ID, bob.ID))
+	for _, user := range []*models.User{alice, bob} {
+		trash, err = repos.Todos.FindDeletedByUserID(user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []uint{shared.ID}, todoIDs(trash), user.Name)
+	}
+	trash, err = repos.Todos.FindDeletedByUserID(carol.ID)
+	require.NoError(t, err)
+	assert.Empty(t, trash)
+
+	inTrash, err := repos.Todos.FindDeletedByID(shared.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Shared", inTrash.Title)
+	assert.Equal(t, bob.ID, inTrash.UserID)
+	_, err = repos.Todos.FindDeletedByID(kept.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func testTodoArchive(t *testing.T, repos repository.Repositories) {
+	alice := createUser(t, repos, "alice")
+	done := createTodo(t, repos, models.Todo{Title: "Done milk run", Status: models.StatusCompleted, UserID: alice.ID})
+	open := createTodo(t, repos, models.Todo{Title: "Open milk run", UserID: alice.ID})
+	pinned := createTodo(t, repos, models.Todo{Title: "Pinned milk run", Priority: models.PriorityLow, UserID: alice.ID, Pinned: true})
+	archived := createTodo(t, repos, models.Todo{Title: "Archived milk run", Status: models.StatusCompleted, UserID: alice.ID})
+	archived.Archived = true
+	require.NoError(t, repos.Todos.Update(archived))
+
+	ids := func(params repository.QueryParams) []uint {
+		t.Helper()
+		params.PageSize = 10
+		result, err := repos.Todos.FindAllWithFilters(alice.ID, params)
+		require.NoError(t, err)
+		var ids []uint
+		for _, todo := range result.Data {
+			ids = append(ids, todo.ID)
+		}
+		return ids
+	}
+
+	// Archived todos are left out unless asked for; pinned ones lead every ordering
+	assert.Equal(t, []uint{pinned.ID, open.ID, done.ID}, ids(repository.QueryParams{}))
+	assert.Equal(t, []uint{pinned.ID, archived.ID, open.ID, done.ID}, ids(repository.QueryParams{IncludeArchived: true}))
+	assert.Equal(t, []uint{pinned.ID, done.ID, open.ID}, ids(repository.QueryParams{SortBy: "title", SortDir: "ASC"}))
+	assert.Equal(t, []uint{pinned.ID, open.ID, done.ID}, ids(repository.QueryParams{SortBy: repository.SortSmart}))
+	assert.Equal(t, []uint{pinned.ID, open.ID, done.ID}, ids(repository.QueryParams{Search: "milk"}))
+	assert.Equal(t, []uint{done.ID}, ids(repository.QueryParams{Status: string(models.StatusCompleted)}))
+
+	// Only todos completed before the cutoff are archived, however recently
+	// they were edited
+	n, err := repos.Todos.ArchiveCompletedBefore(models.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	completed := models.Now().Add(-2 * time.Hour)
+	done.CompletedAt = &completed
+	require.NoError(t, repos.Todos.Update(done))
+	done.Description = "Oat, not dairy"
+	require.NoError(t, repos.Todos.Update(done))
+	n, err = repos.Todos.ArchiveCompletedBefore(models.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, n)
+	assert.Equal(t, []uint{pinned.ID, open.ID}, ids(repository.QueryParams{}))
+
+	found, err := repos.Todos.FindByID(done.ID)
+	require.NoError(t, err)
+	assert.True(t, found.Archived)
+	assert.EqualValues(t, 4, found.Version)
+	require.NotNil(t, found.CompletedAt)
+	assert.WithinDuration(t, completed, *found.CompletedAt, time.Second)
+	found, err = repos.Todos.FindByID(archived.ID)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, found.Version)
+
+	// Completing a todo records when, and reopening it clears that
+	open.Status = models.StatusCompleted
+	require.NoError(t, repos.Todos.Update(open))
+	found, err = repos.Todos.FindByID(open.ID)
+	require.NoError(t, err)
+	require.NotNil(t, found.CompletedAt)
+	assert.WithinDuration(t, time.Now(), *found.CompletedAt, time.Minute)
+	found.Status = models.StatusPending
+	require.NoError(t, repos.Todos.Update(found))
+	found, err = repos.Todos.FindByID(open.ID)
+	require.NoError(t, err)
+	assert.Nil(t, found.CompletedAt)
+}
+
+func testTodoFilters(t *testing.T, repos repository.Repositories) {
+	alice := createUser(t, repos, "alice")
+	bob := createUser(t, repos, "bob")
+
+	for i := 1; i <= 12; i++ {
+		status := models.StatusPending
+		if i%3 == 0 {
+			status = models.StatusCompleted
+		}
+		createTodo(t, repos, models.Todo{Title: fmt.Sprintf("Task %02d", i), Status: status, UserID: alice.ID})
+	}
+	createTodo(t, repos, models.Todo{Title: "Bob's task", UserID: bob.ID})
+
+	result, err := repos.Todos.FindAllWithFilters(alice.ID, repository.QueryParams{Page: 2, PageSize: 5, SortBy: "title", SortDir: "ASC"})
+	require.NoError(t, err)
+	assert.EqualValues(t, 12, result.Total)
+	assert.Equal(t, 3, result.TotalPages)
+	require.Len(t, result.Data, 5)
+	assert.Equal(t, "Task 06", result.Data[0].Title)
+	assert.Equal(t, "Task 10", result.Data[4].Title)
+
+	result, err = repos.Todos.FindAllWithFilters(alice.ID, repository.QueryParams{Page: 3, PageSize: 5, SortBy: "title", SortDir: "DESC"})
+	require.NoError(t, err)
+	require.Len(t, result.Data, 2)
+	assert.Equal(t, "Task 01", result.Data[1].Title)
+
+	result, err = repos.Todos.FindAllWithFilters(alice.ID, repository.QueryParams{Status: string(models.StatusCompleted)})
+	require.NoError(t, err)
+	assert.EqualValues(t, 4, result.Total)
+	for _, todo := range result.Data {
+		assert.Equal(t, models.StatusCompleted, todo.Status)
+	}
+
+	// Invalid sort input falls back to the defaults instead of failing
+	result, err = repos.Todos.FindAllWithFilters(alice.ID, repository.QueryParams{SortBy: "password; DROP TABLE todos", SortDir: "sideways", PageSize: 500})
+	require.NoError(t, err)
+	assert.Equal(t, 10, result.PageSize)
+	assert.Len(t, result.Data, 10)
+}
+
+func testTodoSearch(t *testing.T, repos repository.Repositories) {
+	alice := createUser(t, repos, "alice")
+	bob := createUser(t, repos, "bob")
+
+	inTitle := createTodo(t, repos, models.Todo{Title: "Buy milk", Description: "and bread", UserID: alice.ID})
+	inDescription := createTodo(t, repos, models.Todo{Title: "Groceries", Description: "Milk, eggs and oat milk", UserID: alice.ID})
+	reversed := createTodo(t, repos, models.Todo{Title: "Milk to buy", UserID: alice.ID})
+	createTodo(t, repos, models.Todo{Title: "Quarterly report", Description: "numbers for Q3", UserID: alice.ID})
+	createTodo(t, repos, models.Todo{Title: "Buy milk", UserID: bob.ID})
+	deleted := createTodo(t, repos, models.Todo{Title: "Old milk", UserID: alice.ID})
+	require.NoError(t, repos.Todos.Delete(deleted.ID, alice.ID))
+
+	ids := func(result *repository.PaginatedResult) []uint {
+		var ids []uint
+		for _, todo := range result.Data {
+			ids = append(ids, todo.ID)
+		}
+		return ids
+	}
+
+	// Prefix match, case-insensitive, other users and deleted todos excluded,
+	// title matches ranked above description-only matches
+	result, err := repos.Todos.FindAllWithFilters(alice.ID, repository.QueryParams{Search: "MIL"})
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, result.Total)
+	require.Len(t, result.Data, 3)
+	assert.Equal(t, inDescription.ID, result.Data[2].ID)
+	for _, todo := range result.Data {
+		assert.Contains(t, strings.ToLower(todo.Highlight), "<mark>milk</mark>")
+	}
+
+	// All words must match, in any order
+	result, err = repos.Todos.FindAllWithFilters(alice.ID, repository.QueryParams{Search: "milk buy"})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uint{inTitle.ID, reversed.ID}, ids(result))
+
+	// Quoted text must match as a phrase
+	result, err = repos.Todos.FindAllWithFilters(alice.ID, repository.QueryParams{Search: `"buy milk"`})
+	require.NoError(t, err)
+	assert.Equal(t, []uint{inTitle.ID}, ids(result))
+
+	result, err = repos.Todos.FindAllWithFilters(alice.ID, repository.QueryParams{Search: `"oat milk"`})
+	require.NoError(t, err)
+	assert.Equal(t, []uint{inDescription.ID}, ids(result))
+
+	// Search combines with other filters and explicit sorting
+	result, err = repos.Todos.FindAllWithFilters(alice.ID, repository.QueryParams{Search: "milk", SortBy: "title", SortDir: "ASC"})
+	require.NoError(t, err)
+	assert.Equal(t, []uint{inTitle.ID, inDescription.ID, reversed.ID}, ids(result))
+
+	result, err = repos.Todos.FindAllWithFilters(alice.ID, repository.QueryParams{Search: "milk", Status: string(models.StatusCompleted)})
+	require.NoError(t, err)
+	assert.Empty(t, result.Data)
+
+	// Updates are reflected in the index
+	inTitle.Title = "Buy cheese"
+	require.NoError(t, repos.Todos.Update(inTitle))
+	result, err = repos.Todos.FindAllWithFilters(alice.ID, repository.QueryParams{Search: "cheese"})
+	require.NoError(t, err)
+	assert.Equal(t, []uint{inTitle.ID}, ids(result))
+
+	// Operators and punctuation in user input are treated as plain text
+	result, err = repos.Todos.FindAllWithFilters(alice.ID, repository.QueryParams{Search: `q3 OR* "`})
+	require.NoError(t, err)
+	assert.Empty(t, result.Data)
+	result, err = repos.Todos.FindAllWithFilters(alice.ID, repository.QueryParams{Search: `q3 numbers!`})
+	require.NoError(t, err)
+	assert.Len(t, result.Data, 1)
+}
+
+func testTodoCursors(t *testing.T, repos repository.Repositories) {
+	alice := createUser(t, repos, "alice")
+
+	base := time.Now().Truncate(time.Second)
+	for i := 0; i < 23; i++ {
+		todo := models.Todo{Title: fmt.Sprintf("Task %d", i%7), UserID: alice.ID}
+		if i%3 != 0 {
+			due := base.Add(time.Duration(i%5) * time.Hour)
+			todo.DueDate = &due
+		}
+		if i%4 == 0 {
+			todo.Status = models.StatusCompleted
+		}
+		todo.Priority = models.Priority(i % 5)
+		todo.Pinned = i%6 == 1
+		createTodo(t, repos, todo)
+	}
+
+	ids := func(todos []models.Todo) []uint {
+		var ids []uint
+		for _, todo := range todos {
+			ids = append(ids, todo.ID)
+		}
+		return ids
+	}
+
+	for _, sortBy := range []string{"created_at", "updated_at", "title", "status", "priority", "due_date"} {
+		for _, sortDir := range []string{"ASC", "DESC"} {
+			t.Run(sortBy+" "+sortDir, func(t *testing.T) {
+				all, err := repos.Todos.FindAllWithFilters(alice.ID, repository.QueryParams{PageSize: 100, SortBy: sortBy, SortDir: sortDir})
+				require.NoError(t, err)
+				require.Len(t, all.Data, 23)
+				assert.Nil(t, all.NextCursor)
+				for i, todo := range all.Data {
+					assert.Equal(t, i < 4, todo.Pinned, "pinned todos come first")
+				}
+
+				// Walk forward: first page in page mode, then follow cursors
+				first, err := repos.Todos.FindAllWithFilters(alice.ID, repository.QueryParams{PageSize: 5, SortBy: sortBy, SortDir: sortDir})
+				require.NoError(t, err)
+				assert.Nil(t, first.PrevCursor)
+				walked := ids(first.Data)
+				cursor := first.NextCursor
+				var last *repository.PaginatedResult
+				for pages := 0; cursor != nil; pages++ {
+					require.Less(t, pages, 10, "cursor walk does not terminate")
+					last, err = repos.Todos.FindAllWithFilters(alice.ID, repository.QueryParams{PageSize: 5, Cursor: cursor})
+					require.NoError(t, err)
+					assert.NotNil(t, last.PrevCursor)
+					walked = append(walked, ids(last.Data)...)
+					cursor = last.NextCursor
+				}
+				assert.Equal(t, ids(all.Data), walked)
+
+				// Walk back from the last page
+				require.NotNil(t, last)
+				back := ids(last.Data)
+				cursor = last.PrevCursor
+				for pages := 0; cursor != nil; pages++ {
+					require.Less(t, pages, 10, "cursor walk does not terminate")
+					page, err := repos.Todos.FindAllWithFilters(alice.ID, repository.QueryParams{PageSize: 5, Cursor: cursor})
+					require.NoError(t, err)
+					assert.NotNil(t, page.NextCursor)
+					back = append(ids(page.Data), back...)
+					cursor = page.PrevCursor
+				}
+				assert.Equal(t, ids(all.Data), back)
+			})
+		}
+	}
+
+	// Rows inserted mid-walk neither shift nor duplicate later pages
+	first, err := repos.Todos.FindAllWithFilters(alice.ID, repository.QueryParams{PageSize: 10, SortBy: "created_at", SortDir: "DESC"})
+	require.NoError(t, err)
+	createTodo(t, repos, models.Todo{Title: "Inserted while paging", UserID: alice.ID})
+	second, err := repos.Todos.FindAllWithFilters(alice.ID, repository.QueryParams{PageSize: 10, Cursor: first.NextCursor})
+	require.NoError(t, err)
+	require.NotEmpty(t, second.Data)
+	assert.NotContains(t, ids(second.Data), first.Data[len(first.Data)-1].ID)
+	assert.Less(t, second.Data[0].ID, first.Data[len(first.Data)-1].ID)
+
+	// Filters still apply in cursor mode
+	completed, err := repos.Todos.FindAllWithFilters(alice.ID, repository.QueryParams{PageSize: 2, Status: string(models.StatusCompleted), SortBy: "title", SortDir: "ASC"})
+	require.NoError(t, err)
+	next, err := repos.Todos.FindAllWithFilters(alice.ID, repository.QueryParams{PageSize: 2, Status: string(models.StatusCompleted), Cursor: completed.NextCursor})
+	require.NoError(t, err)
+	for _, todo := range next.Data {
+		assert.Equal(t, models.StatusCompleted, todo.Status)
+	}
+
+	// Relevance ordering has no keyset, and malformed cursors are rejected
+	searched, err := repos.Todos.FindAllWithFilters(alice.ID, repository.QueryParams{PageSize: 2, Search: "task"})
+	require.NoError(t, err)
+	assert.Nil(t, searched.NextCursor)
+	_, err = repos.Todos.FindAllWithFilters(alice.ID, repository.QueryParams{Cursor: &repository.Cursor{SortBy: "password", SortDir: "ASC", ID: 1}})
+	assert.ErrorIs(t, err, repository.ErrInvalidCursor)
+	bad := "yesterday"
+	_, err = repos.Todos.FindAllWithFilters(alice.ID, repository.QueryParams{Cursor: &repository.Cursor{SortBy: "created_at", SortDir: "ASC", Value: &bad, ID: 1}})
+	assert.ErrorIs(t, err, repository.ErrInvalidCursor)
+}
+
+func testTodoPriority(t *testing.T, repos repository.Repositories) {
+	alice := createUser(t, repos, "alice")
+	now := time.Now().Truncate(time.Second)
+	yesterday, tomorrow, nextWeek := now.Add(-24*time.Hour), now.Add(24*time.Hour), now.Add(7*24*time.Hour)
+
+	overdueLow := createTodo(t, repos, models.Todo{Title: "Overdue low", Priority: models.PriorityLow, DueDate: &yesterday, UserID: alice.ID})
+	doneOverdue := createTodo(t, repos, models.Todo{Title: "Done overdue", Priority: models.PriorityUrgent, DueDate: &yesterday, Status: models.StatusCompleted, UserID: alice.ID})
+	urgentLater := createTodo(t, repos, models.Todo{Title: "Urgent later", Priority: models.PriorityUrgent, DueDate: &nextWeek, UserID: alice.ID})
+	urgentSoon := createTodo(t, repos, models.Todo{Title: "Urgent soon", Priority: models.PriorityUrgent, DueDate: &tomorrow, UserID: alice.ID})
+	urgentUndated := createTodo(t, repos, models.Todo{Title: "Urgent undated", Priority: models.PriorityUrgent, UserID: alice.ID})
+	medium := createTodo(t, repos, models.Todo{Title: "Medium", Priority: models.PriorityMedium, DueDate: &tomorrow, UserID: alice.ID})
+	none := createTodo(t, repos, models.Todo{Title: "None", UserID: alice.ID})
+
+	found, err := repos.Todos.FindByID(medium.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.PriorityMedium, found.Priority)
+
+	result, err := repos.Todos.FindAllWithFilters(alice.ID, repository.QueryParams{PageSize: 100, SortBy: repository.SortSmart, SortDir: "ASC"})
+	require.NoError(t, err)
+	assert.Equal(t, []uint{
+		overdueLow.ID, doneOverdue.ID, urgentSoon.ID, urgentLater.ID, urgentUndated.ID, medium.ID, none.ID,
+	}, todoIDs(result.Data))
+	assert.Nil(t, result.NextCursor)
+
+	low, high := models.PriorityLow, models.PriorityHigh
+	result, err = repos.Todos.FindAllWithFilters(alice.ID, repository.QueryParams{PriorityMin: &low, PriorityMax: &high, SortBy: "title", SortDir: "ASC"})
+	require.NoError(t, err)
+	assert.Equal(t, []uint{medium.ID, overdueLow.ID}, todoIDs(result.Data))
+
+	result, err = repos.Todos.FindAllWithFilters(alice.ID, repository.QueryParams{PriorityMin: &high})
+	require.NoError(t, err)
+	assert.EqualValues(t, 4, result.Total)
+}
+
+func testChecklist(t *testing.T, repos repository.Repositories) {
+	alice := createUser(t, repos, "alice")
+	todo := createTodo(t, repos, models.Todo{Title: "Move house", AutoComplete: true, UserID: alice.ID})
+	other := createTodo(t, repos, models.Todo{Title: "Other", UserID: alice.ID})
+
+	var items []*models.ChecklistItem
+	for _, title := range []string{"Pack", "Load van", "Unpack"} {
+		item := &models.ChecklistItem{TodoID: todo.ID, Title: title}
+		require.NoError(t, repos.Items.Create(item))
+		items = append(items, item)
+	}
+	assert.Equal(t, []int{0, 1, 2}, []int{items[0].Position, items[1].Position, items[2].Position})
+	otherItem := &models.ChecklistItem{TodoID: other.ID, Title: "Elsewhere"}
+	require.NoError(t, repos.Items.Create(otherItem))
+	assert.Equal(t, 0, otherItem.Position)
+
+	_, err := repos.Items.FindByIDAndTodoID(items[0].ID, other.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	found, err := repos.Items.FindByIDAndTodoID(items[1].ID, todo.ID)
+	require.NoError(t, err)
+	found.Done = true
+	require.NoError(t, repos.Items.Update(found))
+
+	require.NoError(t, repos.Items.Reorder(todo.ID, []uint{items[2].ID, items[0].ID, items[1].ID}))
+	listed, err := repos.Items.FindByTodoID(todo.ID)
+	require.NoError(t, err)
+	require.Len(t, listed, 3)
+	assert.Equal(t, []string{"Unpack", "Pack", "Load van"}, []string{listed[0].Title, listed[1].Title, listed[2].Title})
+	assert.True(t, listed[2].Done)
+
+	// Todos carry their checklist in order, and saving a todo leaves it alone
+	loaded, err := repos.Todos.FindByIDAndUserID(todo.ID, alice.ID)
+	require.NoError(t, err)
+	assert.True(t, loaded.AutoComplete)
+	require.Len(t, loaded.Items, 3)
+	assert.Equal(t, "Unpack", loaded.Items[0].Title)
+	assert.Equal(t, models.Progress{Done: 1, Total: 3}, loaded.Progress())
+	loaded.Title = "Move flat"
+	require.NoError(t, repos.Todos.Update(loaded))
+
+	require.NoError(t, repos.Items.Delete(items[0].ID, other.ID))
+	require.NoError(t, repos.Items.Delete(items[0].ID, todo.ID))
+	result, err := repos.Todos.FindAllWithFilters(alice.ID, repository.QueryParams{SortBy: "title", SortDir: "ASC"})
+	require.NoError(t, err)
+	require.Len(t, result.Data, 2)
+	assert.Equal(t, "Move flat", result.Data[0].Title)
+	assert.Equal(t, models.Progress{Done: 1, Total: 2}, result.Data[0].Progress())
+	assert.Len(t, result.Data[1].Items, 1)
+}
+
+func testSeries(t *testing.T, repos repository.Repositories) {
+	alice := createUser(t, repos, "alice")
+	bob := createUser(t, repos, "bob")
+	start := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	series := &models.RecurrenceSeries{UserID: alice.ID, RRule: "FREQ=WEEKLY", DTStart: start, Title: "Weekly report", Priority: models.PriorityHigh}
+	require.NoError(t, repos.Series.Create(series))
+	assert.NotZero(t, series.ID)
+
+	todo := createTodo(t, repos, models.Todo{Title: "Weekly report", DueDate: &start, SeriesID: &series.ID, UserID: alice.ID})
+	series.LatestTodoID = todo.ID
+	require.NoError(t, repos.Series.Update(series))
+
+	_, err := repos.Series.FindByIDAndUserID(series.ID, bob.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	found, err := repos.Series.FindByIDAndUserID(series.ID, alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, todo.ID, found.LatestTodoID)
+	assert.True(t, start.Equal(found.DTStart))
+	assert.Equal(t, models.PriorityHigh, found.Priority)
+
+	loaded, err := repos.Todos.FindByIDAndUserID(todo.ID, alice.ID)
+	require.NoError(t, err)
+	require.NotNil(t, loaded.SeriesID)
+	assert.Equal(t, series.ID, *loaded.SeriesID)
+	require.NotNil(t, loaded.Series)
+	assert.Equal(t, "FREQ=WEEKLY", loaded.Series.RRule)
+
+	// Detaching a todo from its series
+	loaded.SeriesID, loaded.Series = nil, nil
+	require.NoError(t, repos.Todos.Update(loaded))
+	loaded, err = repos.Todos.FindByID(todo.ID)
+	require.NoError(t, err)
+	assert.Nil(t, loaded.SeriesID)
+	assert.Nil(t, loaded.Series)
+}
+
+func testReminders(t *testing.T, repos repository.Repositories) {
+	alice := createUser(t, repos, "alice")
+	bob := createUser(t, repos, "bob")
+	now := models.Now().Truncate(time.Second)
+	at := func(d time.Duration) *time.Time {
+		due := now.Add(d)
+		return &due
+	}
+
+	soon := createTodo(t, repos, models.Todo{Title: "Soon", DueDate: at(time.Hour), Reminders: models.ReminderOffsets{1440, 15}, UserID: alice.ID})
+	sooner := createTodo(t, repos, models.Todo{Title: "Sooner", DueDate: at(time.Minute), Reminders: models.ReminderOffsets{5}, UserID: bob.ID})
+	createTodo(t, repos, models.Todo{Title: "No reminders", DueDate: at(time.Hour), UserID: alice.ID})
+	createTodo(t, repos, models.Todo{Title: "Done", DueDate: at(time.Hour), Status: models.StatusCompleted, Reminders: models.ReminderOffsets{15}, UserID: alice.ID})
+	createTodo(t, repos, models.Todo{Title: "Later", DueDate: at(48 * time.Hour), Reminders: models.ReminderOffsets{15}, UserID: alice.ID})
+	createTodo(t, repos, models.Todo{Title: "Past", DueDate: at(-time.Hour), Reminders: models.ReminderOffsets{15}, UserID: alice.ID})
+
+	due, err := repos.Todos.FindDueBetween(now, now.Add(24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []uint{sooner.ID, soon.ID}, todoIDs(due))
+	assert.Equal(t, models.ReminderOffsets{1440, 15}, due[1].Reminders)
+
+	reminder := &models.Reminder{TodoID: soon.ID, DueDate: *soon.DueDate, OffsetMinutes: 15, SentAt: now}
+	require.NoError(t, repos.Reminders.Create(reminder))
+	assert.NotZero(t, reminder.ID)
+	err = repos.Reminders.Create(&models.Reminder{TodoID: soon.ID, DueDate: *soon.DueDate, OffsetMinutes: 15, SentAt: now})
+	assert.ErrorIs(t, err, repository.ErrDuplicate)
+
+	// Another offset or a moved due date is a new reminder
+	require.NoError(t, repos.Reminders.Create(&models.Reminder{TodoID: soon.ID, DueDate: *soon.DueDate, OffsetMinutes: 1440, SentAt: now}))
+	require.NoError(t, repos.Reminders.Create(&models.Reminder{TodoID: soon.ID, DueDate: soon.DueDate.Add(time.Hour), OffsetMinutes: 15, SentAt: now}))
+
+	// A deleted reminder can be recorded again
+	require.NoError(t, repos.Reminders.Delete(reminder.ID))
+	require.NoError(t, repos.Reminders.Create(&models.Reminder{TodoID: soon.ID, DueDate: *soon.DueDate, OffsetMinutes: 15, SentAt: now}))
+}
+
+func testJobs(t *testing.T, repos repository.Repositories) {
+	now := models.Now().Truncate(time.Second)
+	enqueue := func(taskType string, runAt time.Time) *models.Job {
+		job := &models.Job{Type: taskType, Payload: "{}", Status: models.JobPending, MaxAttempts: 3, RunAt: runAt}
+		require.NoError(t, repos.Jobs.Create(job))
+		return job
+	}
+	later := enqueue("LATER", now.Add(time.Hour))
+	second := enqueue("SECOND", now.Add(-time.Minute))
+	first := enqueue("FIRST", now.Add(-time.Hour))
+
+	claimed, err := repos.Jobs.Claim(now)
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, claimed.ID)
+	assert.Equal(t, models.JobRunning, claimed.Status)
+	assert.Equal(t, 1, claimed.Attempts)
+	require.NotNil(t, claimed.LockedAt)
+
+	claimed2, err := repos.Jobs.Claim(now)
+	require.NoError(t, err)
+	assert.Equal(t, second.ID, claimed2.ID)
+	_, err = repos.Jobs.Claim(now)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	// A failed attempt goes back to pending with a later run_at
+	claimed.Status = models.JobPending
+	claimed.RunAt = now.Add(30 * time.Minute)
+	claimed.LockedAt = nil
+	claimed.LastError = "boom"
+	require.NoError(t, repos.Jobs.Update(claimed))
+	found, err := repos.Jobs.FindByID(first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "boom", found.LastError)
+	assert.Equal(t, 1, found.Attempts)
+
+	claimed, err = repos.Jobs.Claim(now.Add(2 * time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, claimed.ID)
+	assert.Equal(t, 2, claimed.Attempts)
+	claimed, err = repos.Jobs.Claim(now.Add(2 * time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, later.ID, claimed.ID)
+
+	// Only jobs locked before the cut-off are requeued
+	n, err := repos.Jobs.RequeueStale(now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	found, err = repos.Jobs.FindByID(second.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.JobPending, found.Status)
+	assert.Nil(t, found.LockedAt)
+
+	// Only done jobs are deleted, once they are older than the cut-off
+	first.Status = models.JobDone
+	require.NoError(t, repos.Jobs.Update(first))
+	later.Status = models.JobDead
+	require.NoError(t, repos.Jobs.Update(later))
+	n, err = repos.Jobs.DeleteFinishedBefore(now.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	n, err = repos.Jobs.DeleteFinishedBefore(time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	_, err = repos.Jobs.FindByID(first.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	_, err = repos.Jobs.FindByID(later.ID)
+	assert.NoError(t, err)
+	_, err = repos.Jobs.FindByID(second.ID)
+	assert.NoError(t, err)
+}
+
+func testIdempotency(t *testing.T, repos repository.Repositories) {
+	alice := createUser(t, repos, "alice")
+	bob := createUser(t, repos, "bob")
+	now := models.Now()
+	newKey := func(userID uint, path, key string, expiresAt time.Time) *models.IdempotencyKey {
+		return &models.IdempotencyKey{
+			UserID: userID, Method: "POST", Path: path, Key: key,
+			RequestHash: "hash", ExpiresAt: expiresAt,
+		}
+	}
+
+	first := newKey(alice.ID, "/api/todos", "k1", now.Add(time.Hour))
+	require.NoError(t, repos.Idempotency.Create(first))
+	assert.NotZero(t, first.ID)
+
+	// The same key is a duplicate only for the same user, method and path
+	assert.ErrorIs(t, repos.Idempotency.Create(newKey(alice.ID, "/api/todos", "k1", now.Add(time.Hour))), repository.ErrDuplicate)
+	require.NoError(t, repos.Idempotency.Create(newKey(bob.ID, "/api/todos", "k1", now.Add(time.Hour))))
+	require.NoError(t, repos.Idempotency.Create(newKey(alice.ID, "/api/tags", "k1", now.Add(time.Hour))))
+	require.NoError(t, repos.Idempotency.Create(newKey(alice.ID, "/api/todos", "old", now.Add(-time.Minute))))
+
+	found, err := repos.Idempotency.Find(alice.ID, "POST", "/api/todos", "k1")
+	require.NoError(t, err)
+	assert.False(t, found.Completed())
+
+	found.StatusCode = 201
+	found.Header = `{"Content-Type":["application/json"]}`
+	found.Body = `{"success":true}`
+	require.NoError(t, repos.Idempotency.Update(found))
+	found, err = repos.Idempotency.Find(alice.ID, "POST", "/api/todos", "k1")
+	require.NoError(t, err)
+	assert.True(t, found.Completed())
+	assert.Equal(t, `{"success":true}`, found.Body)
+
+	n, err := repos.Idempotency.DeleteExpired(now)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, n)
+	_, err = repos.Idempotency.Find(alice.ID, "POST", "/api/todos", "old")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	require.NoError(t, repos.Idempotency.Delete(found.ID))
+	_, err = repos.Idempotency.Find(alice.ID, "POST", "/api/todos", "k1")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	_, err = repos.Idempotency.Find(bob.ID, "POST", "/api/todos", "k1")
+	assert.NoError(t, err)
+}
+
+func testWebhooks(t *testing.T, repos repository.Repositories) {
+	alice := createUser(t, repos, "alice")
+	bob := createUser(t, repos, "bob")
+	createHook := func(userID uint, url string, active bool, events ...string) *models.Webhook {
+		hook := &models.Webhook{UserID: userID, URL: url, Secret: "whsec_test", Events: events, Active: active}
+		require.NoError(t, repos.Webhooks.Create(hook))
+		return hook
+	}
+	all := createHook(alice.ID, "https://example.com/all", true, models.TodoEvents...)
+	done := createHook(alice.ID, "https://example.com/done", true, models.EventTodoCompleted)
+	paused := createHook(alice.ID, "https://example.com/paused", false, models.EventTodoCompleted)
+	createHook(bob.ID, "https://example.com/bob", true, models.EventTodoCompleted)
+	assert.NotZero(t, all.ID)
+
+	found, err := repos.Webhooks.FindByIDAndUserID(done.ID, alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.WebhookEvents{models.EventTodoCompleted}, found.Events)
+	assert.Equal(t, "whsec_test", found.Secret)
+	_, err = repos.Webhooks.FindByIDAndUserID(done.ID, bob.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	hooks, err := repos.Webhooks.FindAllByUserID(alice.ID)
+	require.NoError(t, err)
+	assert.Len(t, hooks, 3)
+
+	hookIDs := func(hooks []models.Webhook) []uint {
+		ids := make([]uint, len(hooks))
+		for i, hook := range hooks {
+			ids[i] = hook.ID
+		}
+		return ids
+	}
+	subscribed, err := repos.Webhooks.FindSubscribed(alice.ID, models.EventTodoCompleted)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{all.ID, done.ID}, hookIDs(subscribed))
+	subscribed, err = repos.Webhooks.FindSubscribed(alice.ID, models.EventTodoCreated)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{all.ID}, hookIDs(subscribed))
+
+	paused.Active = true
+	require.NoError(t, repos.Webhooks.Update(paused))
+	subscribed, err = repos.Webhooks.FindSubscribed(alice.ID, models.EventTodoCompleted)
+	require.NoError(t, err)
+	assert.Len(t, subscribed, 3)
+
+	// The delivery log lists the newest attempts first
+	for i, code := range []int{500, 200} {
+		delivery := &models.WebhookDelivery{
+			WebhookID: done.ID, EventID: fmt.Sprintf("evt_%d", i), Event: models.EventTodoCompleted,
+			Payload: "{}", StatusCode: code, CreatedAt: models.Now(),
+		}
+		require.NoError(t, repos.Webhooks.CreateDelivery(delivery))
+		assert.NotZero(t, delivery.ID)
+	}
+	deliveries, err := repos.Webhooks.FindDeliveries(done.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 2)
+	assert.Equal(t, 200, deliveries[0].StatusCode)
+	assert.True(t, deliveries[0].Succeeded())
+	assert.False(t, deliveries[1].Succeeded())
+	deliveries, err = repos.Webhooks.FindDeliveries(done.ID, 1)
+	require.NoError(t, err)
+	assert.Len(t, deliveries, 1)
+
+	require.NoError(t, repos.Webhooks.Delete(done.ID, bob.ID))
+	_, err = repos.Webhooks.FindByID(done.ID)
+	require.NoError(t, err, "only the owner can delete a webhook")
+	require.NoError(t, repos.Webhooks.Delete(done.ID, alice.ID))
+	_, err = repos.Webhooks.FindByID(done.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	deliveries, err = repos.Webhooks.FindDeliveries(done.ID, 10)
+	require.NoError(t, err)
+	assert.Empty(t, deliveries)
+}
+
+func testTags(t *testing.T, repos repository.Repositories) {
+	alice := createUser(t, repos, "alice")
+	bob := createUser(t, repos, "bob")
+
+	work := createTag(t, repos, alice.ID, "work")
+	assert.NotZero(t, work.ID)
+	assert.Equal(t, models.DefaultTagColor, work.Color)
+	home := &models.Tag{UserID: alice.ID, Name: "home", Color: "#22c55e"}
+	require.NoError(t, repos.Tags.Create(home))
+
+	// Names are unique per user, not globally
+	err := repos.Tags.Create(&models.Tag{UserID: alice.ID, Name: "work"})
+	assert.ErrorIs(t, err, repository.ErrDuplicate)
+	bobsWork := createTag(t, repos, bob.ID, "work")
+
+	tags, err := repos.Tags.FindAllByUserID(alice.ID)
+	require.NoError(t, err)
+	require.Len(t, tags, 2)
+	assert.Equal(t, "home", tags[0].Name)
+	assert.Equal(t, "#22c55e", tags[0].Color)
+	assert.Equal(t, "work", tags[1].Name)
+
+	_, err = repos.Tags.FindByIDAndUserID(work.ID, bob.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	owned, err := repos.Tags.FindByIDsAndUserID([]uint{work.ID, bobsWork.ID, home.ID, work.ID}, alice.ID)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uint{work.ID, home.ID}, tagIDs(owned))
+
+	found, err := repos.Tags.FindByIDAndUserID(home.ID, alice.ID)
+	require.NoError(t, err)
+	found.Name = "work"
+	assert.ErrorIs(t, repos.Tags.Update(found), repository.ErrDuplicate)
+	found.Name = "household"
+	found.Color = "#000000"
+	require.NoError(t, repos.Tags.Update(found))
+	found, err = repos.Tags.FindByIDAndUserID(home.ID, alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "household", found.Name)
+	assert.Equal(t, "#000000", found.Color)
+
+	// Deleting someone else's tag is a no-op
+	require.NoError(t, repos.Tags.Delete(work.ID, bob.ID))
+	_, err = repos.Tags.FindByIDAndUserID(work.ID, alice.ID)
+	assert.NoError(t, err)
+
+	require.NoError(t, repos.Tags.Delete(work.ID, alice.ID))
+	_, err = repos.Tags.FindByIDAndUserID(work.ID, alice.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func createProject(t *testing.T, repos repository.Repositories, userID uint, name string) *models.Project {
+	t.Helper()
+	project := &models.Project{UserID: userID, Name: name}
+	require.NoError(t, repos.Projects.Create(project))
+	return project
+}
+
+func testProjects(t *testing.T, repos repository.Repositories) {
+	alice := createUser(t, repos, "alice")
+	bob := createUser(t, repos, "bob")
+
+	work := createProject(t, repos, alice.ID, "Work")
+	assert.NotZero(t, work.ID)
+	assert.Equal(t, models.DefaultProjectColor, work.Color)
+	assert.Equal(t, 0, work.Position)
+	home := &models.Project{UserID: alice.ID, Name: "Home", Color: "#22c55e", Description: "Chores"}
+	require.NoError(t, repos.Projects.Create(home))
+	assert.Equal(t, 1, home.Position)
+
+	// Names are unique per user, not globally, and positions are per user
+	err := repos.Projects.Create(&models.Project{UserID: alice.ID, Name: "Work"})
+	assert.ErrorIs(t, err, repository.ErrDuplicate)
+	bobsWork := createProject(t, repos, bob.ID, "Work")
+	assert.Equal(t, 0, bobsWork.Position)
+
+	projects, err := repos.Projects.FindAllByUserID(alice.ID)
+	require.NoError(t, err)
+	require.Len(t, projects, 2)
+	assert.Equal(t, "Work", projects[0].Name)
+	assert.Equal(t, "Home", projects[1].Name)
+	assert.Equal(t, "Chores", projects[1].Description)
+
+	_, err = repos.Projects.FindByIDAndUserID(work.ID, bob.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	found, err := repos.Projects.FindByIDAndUserID(home.ID, alice.ID)
+	require.NoError(t, err)
+	found.Name = "Work"
+	assert.ErrorIs(t, repos.Projects.Update(found), repository.ErrDuplicate)
+	found.Name = "Household"
+	found.Archived = true
+	require.NoError(t, repos.Projects.Update(found))
+	found, err = repos.Projects.FindByIDAndUserID(home.ID, alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Household", found.Name)
+	assert.True(t, found.Archived)
+
+	// Reordering skips projects of other users
+	require.NoError(t, repos.Projects.Reorder(alice.ID, []uint{home.ID, work.ID, bobsWork.ID}))
+	projects, err = repos.Projects.FindAllByUserID(alice.ID)
+	require.NoError(t, err)
+	require.Len(t, projects, 2)
+	assert.Equal(t, home.ID, projects[0].ID)
+	assert.Equal(t, work.ID, projects[1].ID)
+	bobs, err := repos.Projects.FindAllByUserID(bob.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, bobs[0].Position)
+
+	// Deleting someone else's project is a no-op
+	require.NoError(t, repos.Projects.Delete(work.ID, bob.ID))
+	_, err = repos.Projects.FindByIDAndUserID(work.ID, alice.ID)
+	assert.NoError(t, err)
+
+	require.NoError(t, repos.Projects.Delete(work.ID, alice.ID))
+	_, err = repos.Projects.FindByIDAndUserID(work.ID, alice.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func testTodoProjects(t *testing.T, repos repository.Repositories) {
+	alice := createUser(t, repos, "alice")
+	bob := createUser(t, repos, "bob")
+	work := createProject(t, repos, alice.ID, "Work")
+	home := createProject(t, repos, alice.ID, "Home")
+
+	report := createTodo(t, repos, models.Todo{Title: "Write report", UserID: alice.ID, ProjectID: &work.ID})
+	release := createTodo(t, repos, models.Todo{Title: "Ship release", UserID: alice.ID, ProjectID: &work.ID, Status: models.StatusInProgress})
+	createTodo(t, repos, models.Todo{Title: "Old release", UserID: alice.ID, ProjectID: &work.ID, Status: models.StatusCompleted, Archived: true})
+	trashed := createTodo(t, repos, models.Todo{Title: "Cancelled", UserID: alice.ID, ProjectID: &work.ID})
+	require.NoError(t, repos.Todos.Delete(trashed.ID, alice.ID))
+	plants := createTodo(t, repos, models.Todo{Title: "Water plants", UserID: alice.ID, ProjectID: &home.ID, Status: models.StatusCompleted})
+	loose := createTodo(t, repos, models.Todo{Title: "Read a book", UserID: alice.ID})
+	createTodo(t, repos, models.Todo{Title: "Bob's todo", UserID: bob.ID})
+
+	found, err := repos.Todos.FindByIDAndUserID(report.ID, alice.ID)
+	require.NoError(t, err)
+	require.NotNil(t, found.ProjectID)
+	assert.Equal(t, work.ID, *found.ProjectID)
+
+	list := func(projectID uint) []uint {
+		t.Helper()
+		result, err := repos.Todos.FindAllWithFilters(alice.ID, repository.QueryParams{ProjectID: &projectID, SortBy: "title", SortDir: "ASC"})
+		require.NoError(t, err)
+		return todoIDs(result.Data)
+	}
+	assert.Equal(t, []uint{release.ID, report.ID}, list(work.ID))
+	assert.Equal(t, []uint{plants.ID}, list(home.ID))
+	assert.Equal(t, []uint{loose.ID}, list(0))
+
+	// Archived todos and those in the trash are not counted
+	counts, err := repos.Todos.CountByProject(alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusCounts{Pending: 1, InProgress: 1, Total: 2}, counts[work.ID])
+	assert.Equal(t, models.StatusCounts{Completed: 1, Total: 1}, counts[home.ID])
+	assert.Equal(t, models.StatusCounts{Pending: 1, Total: 1}, counts[0])
+
+	// Moving a todo between projects
+	found.ProjectID = &home.ID
+	require.NoError(t, repos.Todos.Update(found))
+	assert.Equal(t, []uint{release.ID}, list(work.ID))
+	found.ProjectID = nil
+	require.NoError(t, repos.Todos.Update(found))
+	assert.Equal(t, []uint{loose.ID, report.ID}, list(0))
+
+	// Deleting a project keeps its todos, the trashed one included, without
+	// a project; they reference it, so they must be detached first
+	require.NoError(t, repos.Projects.Delete(work.ID, alice.ID))
+	assert.Equal(t, []uint{loose.ID, release.ID, report.ID}, list(0))
+	inTrash, err := repos.Todos.FindDeletedByIDAndUserID(trashed.ID, alice.ID)
+	require.NoError(t, err)
+	assert.Nil(t, inTrash.ProjectID)
+	counts, err = repos.Todos.CountByProject(alice.ID)
+	require.NoError(t, err)
+	assert.NotContains(t, counts, work.ID)
+	assert.Equal(t, 3, counts[0].Total)
+}
+
+func testProjectMembers(t *testing.T, repos repository.Repositories) {
+	alice := createUser(t, repos, "alice")
+	bob := createUser(t, repos, "bob")
+	carol := createUser(t, repos, "carol")
+	work := createProject(t, repos, alice.ID, "Work")
+	createProject(t, repos, alice.ID, "Private")
+
+	report := createTodo(t, repos, models.Todo{Title: "Write report", UserID: alice.ID, ProjectID: &work.ID})
+	createTodo(t, repos, models.Todo{Title: "Read a book", UserID: alice.ID})
+	own := createTodo(t, repos, models.Todo{Title: "Bob's todo", UserID: bob.ID})
+
+	member := &models.ProjectMember{ProjectID: work.ID, UserID: bob.ID, Role: models.RoleEditor}
+	require.NoError(t, repos.Projects.AddMember(member))
+	assert.NotZero(t, member.ID)
+	err := repos.Projects.AddMember(&models.ProjectMember{ProjectID: work.ID, UserID: bob.ID, Role: models.RoleViewer})
+	assert.ErrorIs(t, err, repository.ErrDuplicate)
+	require.NoError(t, repos.Projects.AddMember(&models.ProjectMember{ProjectID: work.ID, UserID: carol.ID, Role: models.RoleViewer}))
+
+	members, err := repos.Projects.FindMembers(work.ID)
+	require.NoError(t, err)
+	require.Len(t, members, 2)
+	assert.Equal(t, bob.ID, members[0].UserID)
+
+	found, err := repos.Projects.FindMember(work.ID, bob.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleEditor, found.Role)
+	found.Role = models.RoleOwner
+	require.NoError(t, repos.Projects.UpdateMember(found))
+	found, err = repos.Projects.FindMember(work.ID, bob.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleOwner, found.Role)
+	_, err = repos.Projects.FindMember(work.ID, alice.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	shared, err := repos.Projects.FindSharedWithUser(bob.ID)
+	require.NoError(t, err)
+	require.Len(t, shared, 1)
+	assert.Equal(t, work.ID, shared[0].ID)
+
+	// Members see the project's todos, and the owner sees those members add
+	added := createTodo(t, repos, models.Todo{Title: "Review report", UserID: bob.ID, ProjectID: &work.ID})
+	list := func(userID uint) []uint {
+		t.Helper()
+		result, err := repos.Todos.FindAllWithFilters(userID, repository.QueryParams{SortBy: "title", SortDir: "ASC"})
+		require.NoError(t, err)
+		return todoIDs(result.Data)
+	}
+	assert.Equal(t, []uint{own.ID, added.ID, report.ID}, list(bob.ID))
+	assert.Equal(t, []uint{added.ID, report.ID}, list(carol.ID))
+	assert.Len(t, list(alice.ID), 3)
+
+	counts, err := repos.Todos.CountByProject(carol.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusCounts{Pending: 2, Total: 2}, counts[work.ID])
+	assert.NotContains(t, counts, uint(0))
+
+	// Removed members lose access, to the todos they added too
+	note := createTodo(t, repos, models.Todo{Title: "Take notes", UserID: carol.ID, ProjectID: &work.ID})
+	assert.Contains(t, list(carol.ID), note.ID)
+	require.NoError(t, repos.Projects.RemoveMember(work.ID, carol.ID))
+	assert.Empty(t, list(carol.ID))
+	counts, err = repos.Todos.CountByProject(carol.ID)
+	require.NoError(t, err)
+	assert.Empty(t, counts)
+	shared, err = repos.Projects.FindSharedWithUser(carol.ID)
+	require.NoError(t, err)
+	assert.Empty(t, shared)
+
+	// Deleting the project removes its members
+	require.NoError(t, repos.Projects.Delete(work.ID, alice.ID))
+	members, err = repos.Projects.FindMembers(work.ID)
+	require.NoError(t, err)
+	assert.Empty(t, members)
+	assert.Equal(t, []uint{own.ID, added.ID}, list(bob.ID))
+	assert.Equal(t, []uint{note.ID}, list(carol.ID))
+}
+
+func testTodoAssignees(t *testing.T, repos repository.Repositories) {
+	alice := createUser(t, repos, "alice")
+	bob := createUser(t, repos, "bob")
+	work := createProject(t, repos, alice.ID, "Work")
+	require.NoError(t, repos.Projects.AddMember(&models.ProjectMember{ProjectID: work.ID, UserID: bob.ID, Role: models.RoleEditor}))
+
+	report := createTodo(t, repos, models.Todo{Title: "Write report", UserID: alice.ID, ProjectID: &work.ID, AssigneeID: &bob.ID})
+	review := createTodo(t, repos, models.Todo{Title: "Review report", UserID: alice.ID, ProjectID: &work.ID})
+	mine := createTodo(t, repos, models.Todo{Title: "Read a book", UserID: bob.ID, AssigneeID: &bob.ID})
+
+	found, err := repos.Todos.FindByID(report.ID)
+	require.NoError(t, err)
+	require.NotNil(t, found.AssigneeID)
+	assert.Equal(t, bob.ID, *found.AssigneeID)
+
+	assigned := func(userID uint) []uint {
+		t.Helper()
+		result, err := repos.Todos.FindAllWithFilters(userID, repository.QueryParams{AssigneeID: &userID, SortBy: "title", SortDir: "ASC"})
+		require.NoError(t, err)
+		return todoIDs(result.Data)
+	}
+	assert.Equal(t, []uint{mine.ID, report.ID}, assigned(bob.ID))
+	assert.Empty(t, assigned(alice.ID))
+
+	// Reassigning and unassigning
+	found.AssigneeID = &alice.ID
+	require.NoError(t, repos.Todos.Update(found))
+	assert.Equal(t, []uint{report.ID}, assigned(alice.ID))
+	found.AssigneeID = nil
+	require.NoError(t, repos.Todos.Update(found))
+	assert.Empty(t, assigned(alice.ID))
+	assert.Equal(t, []uint{mine.ID}, assigned(bob.ID))
+
+	found, err = repos.Todos.FindByID(review.ID)
+	require.NoError(t, err)
+	assert.Nil(t, found.AssigneeID)
+}
+
+func testTodoTags(t *testing.T, repos repository.Repositories) {
+	alice := createUser(t, repos, "alice")
+	work := createTag(t, repos, alice.ID, "work")
+	urgent := createTag(t, repos, alice.ID, "urgent")
+	home := createTag(t, repos, alice.ID, "home")
+
+	both := createTodo(t, repos, models.Todo{Title: "Ship release", UserID: alice.ID, Tags: []models.Tag{*work, *urgent}})
+	onlyWork := createTodo(t, repos, models.Todo{Title: "Write report", UserID: alice.ID, Tags: []models.Tag{*work}})
+	untagged := createTodo(t, repos, models.Todo{Title: "Read a book", UserID: alice.ID})
+
+	found, err := repos.Todos.FindByIDAndUserID(both.ID, alice.ID)
+	require.NoError(t, err)
+	require.Len(t, found.Tags, 2)
+	assert.Equal(t, "urgent", found.Tags[0].Name)
+	assert.Equal(t, "work", found.Tags[1].Name)
+
+	filter := func(mode string, tags ...uint) []uint {
+		t.Helper()
+		result, err := repos.Todos.FindAllWithFilters(alice.ID, repository.QueryParams{TagIDs: tags, TagMode: mode, SortBy: "title", SortDir: "ASC"})
+		require.NoError(t, err)
+		assert.EqualValues(t, len(result.Data), result.Total)
+		return todoIDs(result.Data)
+	}
+	assert.Equal(t, []uint{both.ID, onlyWork.ID}, filter(repository.TagModeAny, work.ID, urgent.ID))
+	assert.Equal(t, []uint{both.ID, onlyWork.ID}, filter("", work.ID))
+	assert.Equal(t, []uint{both.ID}, filter(repository.TagModeAll, work.ID, urgent.ID))
+	assert.Equal(t, []uint{both.ID}, filter(repository.TagModeAll, urgent.ID, work.ID, urgent.ID))
+	assert.Empty(t, filter(repository.TagModeAll, work.ID, home.ID))
+
+	// Listings carry tags too
+	result, err := repos.Todos.FindAllWithFilters(alice.ID, repository.QueryParams{TagIDs: []uint{urgent.ID}})
+	require.NoError(t, err)
+	require.Len(t, result.Data, 1)
+	assert.Len(t, result.Data[0].Tags, 2)
+
+	// Update replaces the whole set, and an empty set clears it
+	found.Tags = []models.Tag{*home}
+	require.NoError(t, repos.Todos.Update(found))
+	found, err = repos.Todos.FindByID(both.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{home.ID}, tagIDs(found.Tags))
+
+	untaggedTodo, err := repos.Todos.FindByID(untagged.ID)
+	require.NoError(t, err)
+	assert.Empty(t, untaggedTodo.Tags)
+	untaggedTodo.Title = "Read two books"
+	require.NoError(t, repos.Todos.Update(untaggedTodo))
+
+	found.Tags = nil
+	require.NoError(t, repos.Todos.Update(found))
+	found, err = repos.Todos.FindByID(both.ID)
+	require.NoError(t, err)
+	assert.Empty(t, found.Tags)
+
+	// Renames show through and deleted tags drop off their todos
+	work.Name = "office"
+	require.NoError(t, repos.Tags.Update(work))
+	found, err = repos.Todos.FindByID(onlyWork.ID)
+	require.NoError(t, err)
+	require.Len(t, found.Tags, 1)
+	assert.Equal(t, "office", found.Tags[0].Name)
+
+	require.NoError(t, repos.Tags.Delete(work.ID, alice.ID))
+	found, err = repos.Todos.FindByID(onlyWork.ID)
+	require.NoError(t, err)
+	assert.Empty(t, found.Tags)
+	assert.Empty(t, filter(repository.TagModeAny, work.ID))
+}
+
+func todoIDs(todos []models.Todo) []uint {
+	ids := make([]uint, len(todos))
+	for i, todo := range todos {
+		ids[i] = todo.ID
+	}
+	return ids
+}
+
+func tagIDs(tags []models.Tag) []uint {
+	ids := make([]uint, len(tags))
+	for i, tag := range tags {
+		ids[i] = tag.ID
+	}
+	return ids
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"github.com/user/go-todo-api/internal/models"
+	"gorm.io/gorm"
+)
+
+// tagRepository is the GORM implementation of TagRepository
+type tagRepository struct {
+	db *gorm.DB
+}
+
+func NewTagRepository(db *gorm.DB) TagRepository {
+	return &tagRepository{db: db}
+}
+
+func (r *tagRepository) Create(tag *models.Tag) error {
+	if tag.Color == "" {
+		tag.Color = models.DefaultTagColor
+	}
+	return r.db.Create(tag).Error
+}
+
+func (r *tagRepository) FindAllByUserID(userID uint) ([]models.Tag, error) {
+	var tags []models.Tag
+	err := r.db.Where("user_id = ?", userID).Order("name").Find(&tags).Error
+	return tags, err
+}
+
+func (r *tagRepository) FindByIDAndUserID(id, userID uint) (*models.Tag, error) {
+	var tag models.Tag
+	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&tag).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+func (r *tagRepository) FindByIDsAndUserID(ids []uint, userID uint) ([]models.Tag, error) {
+	tags := make([]models.Tag, 0, len(ids))
+	if len(ids) == 0 {
+		return tags, nil
+	}
+	err := r.db.Where("id IN ? AND user_id = ?", ids, userID).Order("name").Find(&tags).Error
+	return tags, err
+}
+
+func (r *tagRepository) Update(tag *models.Tag) error {
+	return r.db.Save(tag).Error
+}
+
+// Delete removes the tag and detaches it from every todo
+func (r *tagRepository) Delete(id, userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Tag{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Exec("DELETE FROM todo_tags WHERE tag_id = ?", id).Error
+	})
+}
@@ -1,106 +1,227 @@
 package repository
 
 import (
-	"github.com/user/go-todo-api/internal/database"
+	"time"
+
 	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/search"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-type TodoRepository struct{}
+// todoRepository is the GORM implementation of TodoRepository
+type todoRepository struct {
+	db *gorm.DB
+}
 
-func NewTodoRepository() *TodoRepository {
-	return &TodoRepository{}
+func NewTodoRepository(db *gorm.DB) TodoRepository {
+	return &todoRepository{db: db}
 }
 
-// QueryParams holds pagination, filtering, and sorting options
+// QueryParams holds pagination, filtering, and sorting options.
+// When Cursor is set the listing is keyset-paginated: Page is ignored and
+// the cursor's ordering replaces SortBy/SortDir. Whatever the ordering,
+// pinned todos come first.
 type QueryParams struct {
 	Page     int
 	PageSize int
+	Cursor   *Cursor
 	Status   string
-	Search   string
-	SortBy   string
-	SortDir  string
+	Search   string // see package search for the query syntax
+	TagIDs   []uint
+	TagMode  string // TagModeAny (default) or TagModeAll
+	// PriorityMin and PriorityMax bound the priority range, inclusive
+	PriorityMin *models.Priority
+	PriorityMax *models.Priority
+	SortBy      string
+	SortDir     string
+	// IncludeArchived lists archived todos too; they are left out by default
+	IncludeArchived bool
+	// ProjectID keeps the todos of one project; pointing at 0 it keeps
+	// those without a project
+	ProjectID *uint
+	// AssigneeID keeps the todos assigned to one user
+	AssigneeID *uint
 }
 
-// PaginatedResult holds the paginated response
+// Tag filter modes: match todos carrying any of the requested tags, or all of them
+const (
+	TagModeAny = "any"
+	TagModeAll = "all"
+)
+
+// PaginatedResult holds the paginated response. Total, Page and TotalPages
+// are only filled in page mode; cursors are set whenever a neighbouring
+// page exists and the ordering supports keyset pagination.
 type PaginatedResult struct {
 	Data       []models.Todo `json:"data"`
 	Total      int64         `json:"total"`
 	Page       int           `json:"page"`
 	PageSize   int           `json:"page_size"`
 	TotalPages int           `json:"total_pages"`
+	NextCursor *Cursor       `json:"-"`
+	PrevCursor *Cursor       `json:"-"`
+}
+
+// allowedSortFields whitelists sort columns to prevent SQL injection
+var allowedSortFields = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+	"title":      true,
+	"status":     true,
+	"priority":   true,
+	"due_date":   true,
+}
+
+// SortRelevance orders search results best match first. It is the default
+// when a search is given and is ignored otherwise.
+const SortRelevance = "relevance"
+
+// SortSmart ranks what needs attention first: overdue open todos, then by
+// priority (highest first), then by due date (soonest first, none last),
+// then newest. It is computed, so it ignores the sort direction.
+const SortSmart = "smart"
+
+// NormalizeSort validates the requested sort column and direction, falling
+// back to relevance for searches and created_at DESC otherwise
+func NormalizeSort(sortBy, sortDir string, searching bool) (string, string) {
+	if sortBy == SortSmart {
+		return SortSmart, "DESC"
+	}
+	if searching && (sortBy == "" || sortBy == SortRelevance) {
+		return SortRelevance, "DESC"
+	}
+	if !allowedSortFields[sortBy] {
+		sortBy = "created_at"
+	}
+	if sortDir != "ASC" && sortDir != "DESC" {
+		sortDir = "DESC"
+	}
+	return sortBy, sortDir
+}
+
+// NormalizePage clamps pagination parameters to page >= 1 and 1 <= pageSize <= 100
+func NormalizePage(page, pageSize int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 10
+	}
+	return page, pageSize
 }
 
-func (r *TodoRepository) Create(todo *models.Todo) error {
-	return database.DB.Create(todo).Error
+// Create inserts the todo and its tag assignments. The tags themselves
+// must already exist; they are referenced, never written.
+func (r *todoRepository) Create(todo *models.Todo) error {
+	todo.Version = 1
+	todo.StampCompletion(r.db.NowFunc())
+	return r.db.Omit("Tags.*", "Items", "Series").Create(todo).Error
 }
 
-func (r *TodoRepository) FindAllByUserID(userID uint) ([]models.Todo, error) {
+// withAssociations preloads each todo's tags in name order, its checklist
+// items in position order and its recurrence series
+func withAssociations(query *gorm.DB) *gorm.DB {
+	return query.Preload("Tags", func(db *gorm.DB) *gorm.DB {
+		return db.Order("tags.name")
+	}).Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("checklist_items.position, checklist_items.id")
+	}).Preload("Series")
+}
+
+func (r *todoRepository) FindAllByUserID(userID uint) ([]models.Todo, error) {
 	var todos []models.Todo
-	err := database.DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&todos).Error
+	err := withAssociations(r.db).Where("user_id = ?", userID).Order("created_at DESC").Find(&todos).Error
 	return todos, err
 }
 
 // FindAllWithFilters returns paginated, filtered, and sorted todos
-func (r *TodoRepository) FindAllWithFilters(userID uint, params QueryParams) (*PaginatedResult, error) {
-	var todos []models.Todo
-	var total int64
-
+func (r *todoRepository) FindAllWithFilters(userID uint, params QueryParams) (*PaginatedResult, error) {
 	// Base query
-	query := database.DB.Model(&models.Todo{}).Where("user_id = ?", userID)
+	query := visibleTo(r.db.Model(&models.Todo{}), userID)
+
+	if !params.IncludeArchived {
+		query = query.Where("todos.archived = ?", false)
+	}
 
 	// Apply status filter
 	if params.Status != "" {
-		query = query.Where("status = ?", params.Status)
+		query = query.Where("todos.status = ?", params.Status)
 	}
 
-	// Apply search filter (search in title and description)
-	if params.Search != "" {
-		searchPattern := "%" + params.Search + "%"
-		query = query.Where("title ILIKE ? OR description ILIKE ?", searchPattern, searchPattern)
+	// Apply project filter
+	if params.ProjectID != nil {
+		if *params.ProjectID == 0 {
+			query = query.Where("todos.project_id IS NULL")
+		} else {
+			query = query.Where("todos.project_id = ?", *params.ProjectID)
+		}
 	}
 
-	// Count total before pagination
-	if err := query.Count(&total).Error; err != nil {
-		return nil, err
+	// Apply assignee filter
+	if params.AssigneeID != nil {
+		query = query.Where("todos.assignee_id = ?", *params.AssigneeID)
 	}
 
-	// Apply sorting
-	sortBy := params.SortBy
-	if sortBy == "" {
-		sortBy = "created_at"
+	// Apply tag filter
+	if len(params.TagIDs) > 0 {
+		query = tagFilter(query, params.TagIDs, params.TagMode)
 	}
-	sortDir := params.SortDir
-	if sortDir == "" {
-		sortDir = "DESC"
+
+	// Apply priority range
+	if params.PriorityMin != nil {
+		query = query.Where("todos.priority >= ?", *params.PriorityMin)
 	}
-	// Validate sort fields to prevent SQL injection
-	allowedSortFields := map[string]bool{
-		"created_at": true,
-		"updated_at": true,
-		"title":      true,
-		"status":     true,
-		"due_date":   true,
+	if params.PriorityMax != nil {
+		query = query.Where("todos.priority <= ?", *params.PriorityMax)
 	}
-	if !allowedSortFields[sortBy] {
-		sortBy = "created_at"
+
+	// Apply full-text search over title and description
+	q := search.Parse(params.Search)
+	if !q.Empty() {
+		query = r.searchFilter(query, q)
 	}
-	if sortDir != "ASC" && sortDir != "DESC" {
-		sortDir = "DESC"
+
+	if params.Cursor != nil {
+		if !q.Empty() {
+			query = r.searchSelect(query, q)
+		}
+		return r.findPageAfterCursor(query, params)
 	}
-	query = query.Order(sortBy + " " + sortDir)
 
-	// Apply pagination
-	page := params.Page
-	if page < 1 {
-		page = 1
+	// Count total before pagination
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
 	}
-	pageSize := params.PageSize
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 10
+
+	if !q.Empty() {
+		query = r.searchSelect(query, q)
 	}
+
+	// Apply sorting, pinned todos first
+	sortBy, sortDir := NormalizeSort(params.SortBy, params.SortDir, !q.Empty())
+	switch sortBy {
+	case SortRelevance:
+		query = query.Order("todos.pinned DESC").Order("search_rank DESC").Order("todos.id DESC")
+	case SortSmart:
+		query = query.Order(smartOrder(time.Now()))
+	default:
+		query = query.Order("todos.pinned DESC")
+		// Keep NULL due dates last on every dialect and break ties by id so
+		// pages are stable
+		if sortBy == "due_date" {
+			query = query.Order("todos.due_date IS NULL")
+		}
+		query = query.Order("todos." + sortBy + " " + sortDir).Order("todos.id " + sortDir)
+	}
+
+	// Apply pagination
+	page, pageSize := NormalizePage(params.Page, params.PageSize)
 	offset := (page - 1) * pageSize
 
-	if err := query.Offset(offset).Limit(pageSize).Find(&todos).Error; err != nil {
+	var todos []models.Todo
+	if err := withAssociations(query).Offset(offset).Limit(pageSize).Find(&todos).Error; err != nil {
 		return nil, err
 	}
 
@@ -110,37 +231,281 @@ func (r *TodoRepository) FindAllWithFilters(userID uint, params QueryParams) (*P
 		totalPages++
 	}
 
-	return &PaginatedResult{
+	result := &PaginatedResult{
 		Data:       todos,
 		Total:      total,
 		Page:       page,
 		PageSize:   pageSize,
 		TotalPages: totalPages,
-	}, nil
+	}
+	result.NextCursor, result.PrevCursor = PageCursors(todos, sortBy, sortDir, page, totalPages)
+
+	return result, nil
+}
+
+// visibleTo keeps the todos userID created outside projects and those in
+// the projects they own or are a member of
+func visibleTo(query *gorm.DB, userID uint) *gorm.DB {
+	return query.Where(
+		"((todos.project_id IS NULL AND todos.user_id = ?) OR todos.project_id IN (SELECT id FROM projects WHERE user_id = ?) "+
+			"OR todos.project_id IN (SELECT project_id FROM project_members WHERE user_id = ?))",
+		userID, userID, userID,
+	)
+}
+
+// smartOrder builds the SortSmart ordering, after pinned todos, as a single
+// clause, since an ORDER BY expression cannot be combined with further
+// Order calls
+func smartOrder(now time.Time) clause.OrderBy {
+	return clause.OrderBy{Expression: clause.Expr{
+		SQL: "todos.pinned DESC, CASE WHEN todos.due_date < ? AND todos.status <> ? THEN 0 ELSE 1 END, " +
+			"todos.priority DESC, todos.due_date IS NULL, todos.due_date ASC, todos.id DESC",
+		Vars: []interface{}{now, models.StatusCompleted},
+	}}
+}
+
+// tagFilter keeps todos carrying any (or, for TagModeAll, every one) of tagIDs
+func tagFilter(query *gorm.DB, tagIDs []uint, mode string) *gorm.DB {
+	if mode == TagModeAll {
+		return query.Where(
+			"todos.id IN (SELECT todo_id FROM todo_tags WHERE tag_id IN ? GROUP BY todo_id HAVING COUNT(DISTINCT tag_id) = ?)",
+			tagIDs, len(uniqueIDs(tagIDs)),
+		)
+	}
+	return query.Where("todos.id IN (SELECT todo_id FROM todo_tags WHERE tag_id IN ?)", tagIDs)
+}
+
+// uniqueIDs returns ids without duplicates, keeping the first occurrence
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	unique := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// PageCursors offers keyset cursors from page mode so clients can switch
+// over; relevance and smart ordering have no stable key and get none
+func PageCursors(todos []models.Todo, sortBy, sortDir string, page, totalPages int) (next, prev *Cursor) {
+	if !allowedSortFields[sortBy] || len(todos) == 0 {
+		return nil, nil
+	}
+	if page < totalPages {
+		next = NewCursor(&todos[len(todos)-1], sortBy, sortDir, false)
+	}
+	if page > 1 {
+		prev = NewCursor(&todos[0], sortBy, sortDir, true)
+	}
+	return next, prev
+}
+
+// findPageAfterCursor runs a keyset query: no OFFSET and no COUNT, so the
+// cost stays flat however deep the client pages
+func (r *todoRepository) findPageAfterCursor(query *gorm.DB, params QueryParams) (*PaginatedResult, error) {
+	if err := params.Cursor.Validate(); err != nil {
+		return nil, err
+	}
+	_, limit := NormalizePage(1, params.PageSize)
+
+	var todos []models.Todo
+	err := withAssociations(applyKeyset(query, params.Cursor)).Limit(limit + 1).Find(&todos).Error
+	if err != nil {
+		return nil, err
+	}
+	return PageFromCursor(todos, params.Cursor, limit), nil
 }
 
-func (r *TodoRepository) FindByID(id uint) (*models.Todo, error) {
+func (r *todoRepository) FindByID(id uint) (*models.Todo, error) {
 	var todo models.Todo
-	err := database.DB.First(&todo, id).Error
+	err := withAssociations(r.db).First(&todo, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &todo, nil
 }
 
-func (r *TodoRepository) FindByIDAndUserID(id, userID uint) (*models.Todo, error) {
+func (r *todoRepository) FindByIDAndUserID(id, userID uint) (*models.Todo, error) {
 	var todo models.Todo
-	err := database.DB.Where("id = ? AND user_id = ?", id, userID).First(&todo).Error
+	err := withAssociations(r.db).Where("id = ? AND user_id = ?", id, userID).First(&todo).Error
 	if err != nil {
 		return nil, err
 	}
 	return &todo, nil
 }
 
-func (r *TodoRepository) Update(todo *models.Todo) error {
-	return database.DB.Save(todo).Error
+func (r *todoRepository) FindDueBetween(from, to time.Time) ([]models.Todo, error) {
+	var todos []models.Todo
+	err := withAssociations(r.db).
+		Where("due_date BETWEEN ? AND ? AND status <> ? AND reminder_offsets <> ''", from, to, models.StatusCompleted).
+		Order("due_date").Order("id").
+		Find(&todos).Error
+	return todos, err
+}
+
+// Update saves the todo's columns and replaces its tag assignments with
+// todo.Tags. The version check is part of the UPDATE, so of two writers
+// that read the same version only the first succeeds.
+func (r *todoRepository) Update(todo *models.Todo) error {
+	read := todo.Version
+	todo.StampCompletion(r.db.NowFunc())
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		todo.Version = read + 1
+		result := tx.Model(todo).Where("version = ?", read).
+			Select("*").Omit("ID", "CreatedAt", "Tags", "Items", "Series", "User").
+			Updates(todo)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrConflict
+		}
+		tags := tx.Model(todo).Omit("Tags.*").Association("Tags")
+		if len(todo.Tags) == 0 {
+			return tags.Clear()
+		}
+		return tags.Replace(todo.Tags)
+	})
+	if err != nil {
+		todo.Version = read
+	}
+	return err
+}
+
+func (r *todoRepository) ArchiveCompletedBefore(before time.Time) (int64, error) {
+	result := r.db.Model(&models.Todo{}).
+		Where("status = ? AND archived = ? AND completed_at < ?", models.StatusCompleted, false, before).
+		Updates(map[string]interface{}{"archived": true, "version": gorm.Expr("version + 1")})
+	return result.RowsAffected, result.Error
+}
+
+// Delete soft-deletes the todo
+func (r *todoRepository) Delete(id, userID uint) error {
+	return r.db.Model(&models.Todo{}).Where("id = ? AND user_id = ?", id, userID).
+		UpdateColumn("deleted_at", r.db.NowFunc()).Error
+}
+
+func (r *todoRepository) FindDeletedByUserID(userID uint) ([]models.Todo, error) {
+	var todos []models.Todo
+	err := visibleTo(withAssociations(r.db.Unscoped()), userID).
+		Where("todos.deleted_at IS NOT NULL").
+		Order("todos.deleted_at DESC").Order("todos.id DESC").
+		Find(&todos).Error
+	return todos, err
+}
+
+func (r *todoRepository) FindDeletedByID(id uint) (*models.Todo, error) {
+	var todo models.Todo
+	err := withAssociations(r.db.Unscoped()).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		First(&todo).Error
+	if err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
+
+func (r *todoRepository) FindDeletedByIDAndUserID(id, userID uint) (*models.Todo, error) {
+	var todo models.Todo
+	err := withAssociations(r.db.Unscoped()).
+		Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", id, userID).
+		First(&todo).Error
+	if err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
+
+func (r *todoRepository) Restore(id, userID uint) error {
+	result := r.db.Unscoped().Model(&models.Todo{}).
+		Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", id, userID).
+		Updates(map[string]interface{}{"deleted_at": nil, "version": gorm.Expr("version + 1")})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Purge removes the rows that reference the todo itself, since SQLite only
+// enforces the ON DELETE CASCADE clauses when foreign keys are switched on
+func (r *todoRepository) Purge(id, userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Where("id = ? AND user_id = ?", id, userID).Delete(&models.Todo{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return purgeReferences(tx, []uint{id})
+	})
+}
+
+func (r *todoRepository) PurgeDeletedBefore(before time.Time) (int64, error) {
+	var purged int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var ids []uint
+		err := tx.Unscoped().Model(&models.Todo{}).
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+			Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+		result := tx.Unscoped().Where("id IN ?", ids).Delete(&models.Todo{})
+		if result.Error != nil {
+			return result.Error
+		}
+		purged = result.RowsAffected
+		return purgeReferences(tx, ids)
+	})
+	return purged, err
+}
+
+func (r *todoRepository) CountByProject(userID uint) (map[uint]models.StatusCounts, error) {
+	var rows []struct {
+		ProjectID *uint
+		Status    models.TodoStatus
+		Count     int
+	}
+	err := visibleTo(r.db.Model(&models.Todo{}), userID).
+		Select("todos.project_id, todos.status, COUNT(*) AS count").
+		Where("todos.archived = ?", false).
+		Group("todos.project_id, todos.status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uint]models.StatusCounts)
+	for _, row := range rows {
+		var projectID uint
+		if row.ProjectID != nil {
+			projectID = *row.ProjectID
+		}
+		c := counts[projectID]
+		c.Add(row.Status, row.Count)
+		counts[projectID] = c
+	}
+	return counts, nil
+}
+
+// purgeReferences deletes the tag assignments, checklist items and sent
+// reminders of purged todos
+func purgeReferences(tx *gorm.DB, todoIDs []uint) error {
+	if err := tx.Exec("DELETE FROM todo_tags WHERE todo_id IN ?", todoIDs).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("todo_id IN ?", todoIDs).Delete(&models.ChecklistItem{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("todo_id IN ?", todoIDs).Delete(&models.Reminder{}).Error
 }
 
-func (r *TodoRepository) Delete(id, userID uint) error {
-	return database.DB.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Todo{}).Error
+func (r *todoRepository) Transaction(fn func(repo TodoRepository) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&todoRepository{db: tx})
+	})
 }
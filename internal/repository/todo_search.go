@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"github.com/user/go-todo-api/internal/search"
+	"gorm.io/gorm"
+)
+
+// searchFilter restricts query to todos matching q using the dialect's
+// full-text index (see migration 0002_todo_search)
+func (r *todoRepository) searchFilter(query *gorm.DB, q search.Query) *gorm.DB {
+	if r.db.Dialector.Name() == "postgres" {
+		return query.Where("todos.search_vector @@ to_tsquery('simple', ?)", q.TSQuery())
+	}
+	return query.
+		Joins("JOIN todos_fts ON todos_fts.rowid = todos.id").
+		Where("todos_fts MATCH ?", q.FTS5())
+}
+
+// searchSelect adds the highlight snippet and a search_rank column (higher is
+// better) to a query already narrowed by searchFilter
+func (r *todoRepository) searchSelect(query *gorm.DB, q search.Query) *gorm.DB {
+	if r.db.Dialector.Name() == "postgres" {
+		tsQuery := q.TSQuery()
+		return query.Select(`todos.*,
+			ts_headline('simple', coalesce(todos.title, '') || ' — ' || coalesce(todos.description, ''),
+				to_tsquery('simple', ?), 'StartSel=<mark>, StopSel=</mark>, MaxWords=16, MinWords=4') AS highlight,
+			ts_rank(todos.search_vector, to_tsquery('simple', ?)) AS search_rank`, tsQuery, tsQuery)
+	}
+	// bm25 weights: title matches count ten times as much as description
+	// matches. It returns lower scores for better matches, hence the negation.
+	return query.Select(`todos.*,
+		snippet(todos_fts, -1, '<mark>', '</mark>', '…', 16) AS highlight,
+		-bm25(todos_fts, 10.0, 1.0) AS search_rank`)
+}
@@ -5,14 +5,17 @@ import (
 	"encoding/base64"
 	"time"
 
-	"github.com/user/go-todo-api/internal/database"
 	"github.com/user/go-todo-api/internal/models"
+	"gorm.io/gorm"
 )
 
-type TokenRepository struct{}
+// tokenRepository is the GORM implementation of TokenRepository
+type tokenRepository struct {
+	db *gorm.DB
+}
 
-func NewTokenRepository() *TokenRepository {
-	return &TokenRepository{}
+func NewTokenRepository(db *gorm.DB) TokenRepository {
+	return &tokenRepository{db: db}
 }
 
 // GenerateRefreshToken creates a cryptographically secure random token
@@ -25,21 +28,21 @@ func GenerateRefreshToken() (string, error) {
 }
 
 // Create stores a new refresh token
-func (r *TokenRepository) Create(userID uint, token string, expiresAt time.Time) (*models.RefreshToken, error) {
+func (r *tokenRepository) Create(userID uint, token string, expiresAt time.Time) (*models.RefreshToken, error) {
 	refreshToken := &models.RefreshToken{
 		Token:     token,
 		UserID:    userID,
 		ExpiresAt: expiresAt,
 		Revoked:   false,
 	}
-	err := database.DB.Create(refreshToken).Error
+	err := r.db.Create(refreshToken).Error
 	return refreshToken, err
 }
 
 // FindByToken retrieves a refresh token
-func (r *TokenRepository) FindByToken(token string) (*models.RefreshToken, error) {
+func (r *tokenRepository) FindByToken(token string) (*models.RefreshToken, error) {
 	var refreshToken models.RefreshToken
-	err := database.DB.Where("token = ? AND revoked = ?", token, false).First(&refreshToken).Error
+	err := r.db.Where("token = ? AND revoked = ?", token, false).First(&refreshToken).Error
 	if err != nil {
 		return nil, err
 	}
@@ -47,16 +50,16 @@ func (r *TokenRepository) FindByToken(token string) (*models.RefreshToken, error
 }
 
 // Revoke marks a refresh token as revoked
-func (r *TokenRepository) Revoke(token string) error {
-	return database.DB.Model(&models.RefreshToken{}).Where("token = ?", token).Update("revoked", true).Error
+func (r *tokenRepository) Revoke(token string) error {
+	return r.db.Model(&models.RefreshToken{}).Where("token = ?", token).Update("revoked", true).Error
 }
 
 // RevokeAllForUser revokes all refresh tokens for a user
-func (r *TokenRepository) RevokeAllForUser(userID uint) error {
-	return database.DB.Model(&models.RefreshToken{}).Where("user_id = ?", userID).Update("revoked", true).Error
+func (r *tokenRepository) RevokeAllForUser(userID uint) error {
+	return r.db.Model(&models.RefreshToken{}).Where("user_id = ?", userID).Update("revoked", true).Error
 }
 
 // CleanupExpired removes expired tokens
-func (r *TokenRepository) CleanupExpired() error {
-	return database.DB.Where("expires_at < ?", time.Now()).Delete(&models.RefreshToken{}).Error
+func (r *tokenRepository) CleanupExpired() error {
+	return r.db.Where("expires_at < ?", time.Now()).Delete(&models.RefreshToken{}).Error
 }
@@ -1,40 +1,47 @@
 package repository
 
 import (
-	"github.com/user/go-todo-api/internal/database"
 	"github.com/user/go-todo-api/internal/models"
+	"gorm.io/gorm"
 )
 
-type UserRepository struct{}
+// userRepository is the GORM implementation of UserRepository
+type userRepository struct {
+	db *gorm.DB
+}
 
-func NewUserRepository() *UserRepository {
-	return &UserRepository{}
+func NewUserRepository(db *gorm.DB) UserRepository {
+	return &userRepository{db: db}
 }
 
-func (r *UserRepository) Create(user *models.User) error {
-	return database.DB.Create(user).Error
+func (r *userRepository) Create(user *models.User) error {
+	return r.db.Create(user).Error
 }
 
-func (r *UserRepository) FindByEmail(email string) (*models.User, error) {
+func (r *userRepository) FindByEmail(email string) (*models.User, error) {
 	var user models.User
-	err := database.DB.Where("email = ?", email).First(&user).Error
+	err := r.db.Where("email = ?", email).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *UserRepository) FindByID(id uint) (*models.User, error) {
+func (r *userRepository) FindByID(id uint) (*models.User, error) {
 	var user models.User
-	err := database.DB.First(&user, id).Error
+	err := r.db.First(&user, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *UserRepository) ExistsByEmail(email string) bool {
+func (r *userRepository) ExistsByEmail(email string) bool {
 	var count int64
-	database.DB.Model(&models.User{}).Where("email = ?", email).Count(&count)
+	r.db.Model(&models.User{}).Where("email = ?", email).Count(&count)
 	return count > 0
 }
+
+func (r *userRepository) Update(user *models.User) error {
+	return r.db.Omit("Todos").Save(user).Error
+}
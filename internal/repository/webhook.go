@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"github.com/user/go-todo-api/internal/models"
+	"gorm.io/gorm"
+)
+
+// webhookRepository is the GORM implementation of WebhookRepository
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+func (r *webhookRepository) Create(hook *models.Webhook) error {
+	return r.db.Create(hook).Error
+}
+
+func (r *webhookRepository) FindAllByUserID(userID uint) ([]models.Webhook, error) {
+	var hooks []models.Webhook
+	err := r.db.Where("user_id = ?", userID).Order("id").Find(&hooks).Error
+	return hooks, err
+}
+
+func (r *webhookRepository) FindByID(id uint) (*models.Webhook, error) {
+	var hook models.Webhook
+	err := r.db.First(&hook, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &hook, nil
+}
+
+func (r *webhookRepository) FindByIDAndUserID(id, userID uint) (*models.Webhook, error) {
+	var hook models.Webhook
+	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&hook).Error
+	if err != nil {
+		return nil, err
+	}
+	return &hook, nil
+}
+
+// FindSubscribed filters on events in Go: users have few webhooks, and it
+// avoids matching inside the comma-separated column
+func (r *webhookRepository) FindSubscribed(userID uint, event string) ([]models.Webhook, error) {
+	var hooks []models.Webhook
+	err := r.db.Where("user_id = ? AND active = ?", userID, true).Order("id").Find(&hooks).Error
+	if err != nil {
+		return nil, err
+	}
+	subscribed := make([]models.Webhook, 0, len(hooks))
+	for _, hook := range hooks {
+		if hook.Events.Has(event) {
+			subscribed = append(subscribed, hook)
+		}
+	}
+	return subscribed, nil
+}
+
+func (r *webhookRepository) Update(hook *models.Webhook) error {
+	return r.db.Save(hook).Error
+}
+
+func (r *webhookRepository) Delete(id, userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Webhook{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Where("webhook_id = ?", id).Delete(&models.WebhookDelivery{}).Error
+	})
+}
+
+func (r *webhookRepository) CreateDelivery(delivery *models.WebhookDelivery) error {
+	return r.db.Create(delivery).Error
+}
+
+func (r *webhookRepository) FindDeliveries(webhookID uint, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.db.Where("webhook_id = ?", webhookID).Order("id DESC").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
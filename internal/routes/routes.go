@@ -1,20 +1,26 @@
 package routes
 
 import (
-	"time"
-
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 	_ "github.com/user/go-todo-api/docs"
+	"github.com/user/go-todo-api/internal/config"
+	"github.com/user/go-todo-api/internal/events"
 	"github.com/user/go-todo-api/internal/handlers"
 	"github.com/user/go-todo-api/internal/middleware"
+	"github.com/user/go-todo-api/internal/repository"
 	ginprometheus "github.com/zsais/go-gin-prometheus"
 )
 
-func SetupRouter() *gin.Engine {
-	r := gin.Default()
+// SetupRouter wires the handlers for repos. limiter rate-limits every
+// request and broker feeds the event streams; the caller owns both and
+// stops them on shutdown.
+func SetupRouter(repos repository.Repositories, limiter *middleware.RateLimiter, broker *events.Broker) *gin.Engine {
+	// gin.Default's logger would write stream credentials to the log
+	r := gin.New()
+	r.Use(middleware.RequestLogger(gin.DefaultWriter), gin.Recovery())
 
 	// Prometheus metrics
 	p := ginprometheus.NewPrometheus("gin")
@@ -23,21 +29,27 @@ func SetupRouter() *gin.Engine {
 	// CORS configuration
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:4200"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "Last-Event-ID", "If-Match", "If-None-Match", "Idempotency-Key"},
+		ExposeHeaders:    []string{"Content-Length", "ETag", "Idempotent-Replayed", "Retry-After"},
 		AllowCredentials: true,
 	}))
 
 	// Custom logger middleware
 	r.Use(middleware.LoggerMiddleware())
 
-	// Rate limiting: 100 requests per minute per IP
-	r.Use(middleware.RateLimitMiddleware(100, time.Minute))
+	// Rate limiting per IP
+	r.Use(limiter.Middleware())
 
 	// Initialize handlers
-	authHandler := handlers.NewAuthHandler()
-	todoHandler := handlers.NewTodoHandler()
+	authHandler := handlers.NewAuthHandler(repos.Users, repos.Tokens)
+	todoHandler := handlers.NewTodoHandler(repos.Todos, repos.Tags, repos.Projects, repos.Series)
+	tagHandler := handlers.NewTagHandler(repos.Tags)
+	projectHandler := handlers.NewProjectHandler(repos.Projects, repos.Todos, repos.Users)
+	checklistHandler := handlers.NewChecklistHandler(repos.Todos, repos.Projects, repos.Items, repos.Series)
+	webhookHandler := handlers.NewWebhookHandler(repos.Webhooks)
+	streamTickets := middleware.NewStreamTickets(middleware.StreamTicketTTL)
+	streamHandler := handlers.NewStreamHandler(broker, streamTickets)
 
 	// API routes
 	api := r.Group("/api")
@@ -58,22 +70,91 @@ func SetupRouter() *gin.Engine {
 			auth.POST("/refresh", authHandler.RefreshToken)
 		}
 
+		// Live todo events, which also take a ticket as ?ticket=
+		stream := api.Group("/stream", middleware.StreamAuthMiddleware(streamTickets))
+		{
+			stream.GET("", streamHandler.SSE)
+			stream.GET("/ws", streamHandler.WebSocket)
+		}
+
 		// Protected routes
 		protected := api.Group("/")
-		protected.Use(middleware.AuthMiddleware())
+		protected.Use(
+			middleware.AuthMiddleware(),
+			middleware.IdempotencyMiddleware(repos.Idempotency, config.AppConfig.IdempotencyTTL),
+		)
 		{
 			// User routes
 			protected.GET("profile", authHandler.GetProfile)
+			protected.PUT("profile", authHandler.UpdateProfile)
 			protected.POST("auth/logout", authHandler.Logout)
+			protected.POST("stream/ticket", streamHandler.Ticket)
 
 			// Todo routes
 			todos := protected.Group("todos")
 			{
 				todos.GET("", todoHandler.GetAll)
+				todos.GET("/trash", todoHandler.Trash)
 				todos.GET("/:id", todoHandler.GetByID)
 				todos.POST("", todoHandler.Create)
+				todos.POST("/bulk", todoHandler.Bulk)
 				todos.PUT("/:id", todoHandler.Update)
+				todos.PATCH("/:id", todoHandler.Patch)
 				todos.DELETE("/:id", todoHandler.Delete)
+				todos.POST("/:id/restore", todoHandler.Restore)
+				todos.POST("/:id/archive", todoHandler.Archive)
+				todos.POST("/:id/unarchive", todoHandler.Unarchive)
+				todos.POST("/:id/pin", todoHandler.Pin)
+				todos.POST("/:id/unpin", todoHandler.Unpin)
+				todos.POST("/:id/assign", todoHandler.Assign)
+				todos.POST("/:id/unassign", todoHandler.Unassign)
+				todos.GET("/:id/occurrences", todoHandler.Occurrences)
+
+				// Checklist items
+				todos.GET("/:id/items", checklistHandler.GetAll)
+				todos.POST("/:id/items", checklistHandler.Create)
+				todos.PUT("/:id/items/order", checklistHandler.Reorder)
+				todos.POST("/:id/items/:item_id/toggle", checklistHandler.Toggle)
+				todos.DELETE("/:id/items/:item_id", checklistHandler.Delete)
+			}
+
+			// Tag routes
+			tags := protected.Group("tags")
+			{
+				tags.GET("", tagHandler.GetAll)
+				tags.GET("/:id", tagHandler.GetByID)
+				tags.POST("", tagHandler.Create)
+				tags.PUT("/:id", tagHandler.Update)
+				tags.DELETE("/:id", tagHandler.Delete)
+			}
+
+			// Project routes
+			projects := protected.Group("projects")
+			{
+				projects.GET("", projectHandler.GetAll)
+				projects.PUT("/order", projectHandler.Reorder)
+				projects.GET("/:id", projectHandler.GetByID)
+				projects.POST("", projectHandler.Create)
+				projects.PUT("/:id", projectHandler.Update)
+				projects.DELETE("/:id", projectHandler.Delete)
+
+				// Members
+				projects.GET("/:id/members", projectHandler.GetMembers)
+				projects.POST("/:id/members", projectHandler.AddMember)
+				projects.PUT("/:id/members/:user_id", projectHandler.UpdateMember)
+				projects.DELETE("/:id/members/:user_id", projectHandler.RemoveMember)
+			}
+
+			// Webhook routes
+			webhooks := protected.Group("webhooks")
+			{
+				webhooks.GET("", webhookHandler.GetAll)
+				webhooks.GET("/:id", webhookHandler.GetByID)
+				webhooks.POST("", webhookHandler.Create)
+				webhooks.PUT("/:id", webhookHandler.Update)
+				webhooks.DELETE("/:id", webhookHandler.Delete)
+				webhooks.GET("/:id/deliveries", webhookHandler.Deliveries)
+				webhooks.POST("/:id/test", webhookHandler.Test)
 			}
 		}
 	}
@@ -0,0 +1,184 @@
+// Package search parses the ?search= query language shared by every todo
+// backend and renders it for SQLite FTS5 and PostgreSQL full-text search.
+//
+// The language is intentionally small:
+//
+//	milk            words match by prefix ("mil" finds "milk")
+//	buy milk        all words must match, in any order or column
+//	"buy milk"      quoted text must appear as an exact phrase
+//
+// Input is reduced to letters and digits before it reaches SQL, so user
+// text can never inject FTS operators.
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Term is a single word (matched by prefix) or a quoted phrase
+type Term struct {
+	Words  []string
+	Phrase bool
+}
+
+// Query is a parsed search expression; all terms must match
+type Query struct {
+	Terms []Term
+}
+
+// Parse turns raw user input into a Query. Unbalanced quotes are closed
+// at the end of the input.
+func Parse(input string) Query {
+	var q Query
+
+	for i, part := range strings.Split(input, `"`) {
+		inPhrase := i%2 == 1
+		words := tokenize(part)
+		if len(words) == 0 {
+			continue
+		}
+		if inPhrase {
+			q.Terms = append(q.Terms, Term{Words: words, Phrase: true})
+			continue
+		}
+		for _, word := range words {
+			q.Terms = append(q.Terms, Term{Words: []string{word}})
+		}
+	}
+
+	return q
+}
+
+// Empty reports whether the query has nothing to search for
+func (q Query) Empty() bool {
+	return len(q.Terms) == 0
+}
+
+// FTS5 renders the query for SQLite's MATCH operator
+func (q Query) FTS5() string {
+	parts := make([]string, 0, len(q.Terms))
+	for _, term := range q.Terms {
+		quoted := `"` + strings.Join(term.Words, " ") + `"`
+		if !term.Phrase {
+			quoted += "*"
+		}
+		parts = append(parts, quoted)
+	}
+	return strings.Join(parts, " AND ")
+}
+
+// TSQuery renders the query for PostgreSQL's to_tsquery
+func (q Query) TSQuery() string {
+	parts := make([]string, 0, len(q.Terms))
+	for _, term := range q.Terms {
+		if term.Phrase {
+			parts = append(parts, "("+strings.Join(term.Words, " <-> ")+")")
+		} else {
+			parts = append(parts, term.Words[0]+":*")
+		}
+	}
+	return strings.Join(parts, " & ")
+}
+
+// Match evaluates the query against a title and description without a
+// database, returning a relevance score (higher is better). Title hits
+// weigh ten times as much as description hits, mirroring the SQL backends.
+func (q Query) Match(title, description string) (float64, bool) {
+	titleWords := tokenize(title)
+	descriptionWords := tokenize(description)
+
+	score := 0.0
+	for _, term := range q.Terms {
+		titleHits := countHits(titleWords, term)
+		descriptionHits := countHits(descriptionWords, term)
+		if titleHits+descriptionHits == 0 {
+			return 0, false
+		}
+		score += 10*float64(titleHits) + float64(descriptionHits)
+	}
+	return score, true
+}
+
+// Highlight wraps every word of text matched by the query in <mark> tags.
+// It returns an empty string when nothing in text matches.
+func (q Query) Highlight(text string) string {
+	var b strings.Builder
+	matched := false
+
+	words := tokenize(text)
+	marks := make([]bool, len(words))
+	for _, term := range q.Terms {
+		for start := range words {
+			if termMatchesAt(words, start, term) {
+				for i := range term.Words {
+					marks[start+i] = true
+				}
+				matched = true
+			}
+		}
+	}
+	if !matched {
+		return ""
+	}
+
+	// Walk the original text so punctuation and spacing are preserved
+	index := 0
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		if !isWordRune(runes[i]) {
+			b.WriteRune(runes[i])
+			i++
+			continue
+		}
+		j := i
+		for j < len(runes) && isWordRune(runes[j]) {
+			j++
+		}
+		word := string(runes[i:j])
+		if index < len(marks) && marks[index] {
+			b.WriteString("<mark>" + word + "</mark>")
+		} else {
+			b.WriteString(word)
+		}
+		index++
+		i = j
+	}
+	return b.String()
+}
+
+func countHits(words []string, term Term) int {
+	hits := 0
+	for start := range words {
+		if termMatchesAt(words, start, term) {
+			hits++
+		}
+	}
+	return hits
+}
+
+func termMatchesAt(words []string, start int, term Term) bool {
+	if !term.Phrase {
+		return strings.HasPrefix(words[start], term.Words[0])
+	}
+	if start+len(term.Words) > len(words) {
+		return false
+	}
+	for i, word := range term.Words {
+		if words[start+i] != word {
+			return false
+		}
+	}
+	return true
+}
+
+// tokenize lower-cases text and splits it into runs of letters and digits
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !isWordRune(r)
+	})
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
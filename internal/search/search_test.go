@@ -0,0 +1,55 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	q := Parse(`Buy "oat  milk" eggs!`)
+	assert.Equal(t, []Term{
+		{Words: []string{"buy"}},
+		{Words: []string{"oat", "milk"}, Phrase: true},
+		{Words: []string{"eggs"}},
+	}, q.Terms)
+
+	assert.True(t, Parse(`  "" ** --  `).Empty())
+
+	// An unbalanced quote runs to the end of the input
+	q = Parse(`report "q3 numbers`)
+	assert.Len(t, q.Terms, 2)
+	assert.True(t, q.Terms[1].Phrase)
+}
+
+func TestRender(t *testing.T) {
+	q := Parse(`buy "oat milk"`)
+	assert.Equal(t, `"buy"* AND "oat milk"`, q.FTS5())
+	assert.Equal(t, `buy:* & (oat <-> milk)`, q.TSQuery())
+
+	// FTS syntax in the input is neutralised
+	q = Parse(`title:x OR NEAR(a b) ^c`)
+	assert.Equal(t, `"title"* AND "x"* AND "or"* AND "near"* AND "a"* AND "b"* AND "c"*`, q.FTS5())
+}
+
+func TestMatch(t *testing.T) {
+	q := Parse("mil")
+	titleScore, ok := q.Match("Buy milk", "")
+	assert.True(t, ok)
+	descriptionScore, ok := q.Match("Groceries", "milk and bread")
+	assert.True(t, ok)
+	assert.Greater(t, titleScore, descriptionScore)
+
+	_, ok = Parse(`"milk buy"`).Match("Buy milk", "")
+	assert.False(t, ok)
+	_, ok = Parse(`"buy milk"`).Match("Please BUY milk today", "")
+	assert.True(t, ok)
+	_, ok = Parse("milk eggs").Match("Buy milk", "")
+	assert.False(t, ok)
+}
+
+func TestHighlight(t *testing.T) {
+	q := Parse(`mil "the store"`)
+	assert.Equal(t, "Buy <mark>Milk</mark>, at <mark>the</mark> <mark>store</mark>.", q.Highlight("Buy Milk, at the store."))
+	assert.Empty(t, q.Highlight("nothing here"))
+}
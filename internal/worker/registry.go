@@ -0,0 +1,68 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrUnknownTaskType is returned by Enqueue for task types without a handler
+var ErrUnknownTaskType = errors.New("unknown task type")
+
+// Handler processes the tasks of one type. Handle receives the task's JSON
+// payload and should return promptly once ctx is done.
+type Handler struct {
+	Handle  func(ctx context.Context, payload json.RawMessage) error
+	Timeout time.Duration // per attempt; zero uses Options.TaskTimeout
+}
+
+// TypedHandler builds a Handler whose payload is decoded into P. A payload
+// that does not decode fails the task permanently, since retrying it
+// cannot help.
+func TypedHandler[P any](timeout time.Duration, fn func(ctx context.Context, payload P) error) Handler {
+	return Handler{
+		Timeout: timeout,
+		Handle: func(ctx context.Context, raw json.RawMessage) error {
+			var payload P
+			if err := json.Unmarshal(raw, &payload); err != nil {
+				return Permanent(fmt.Errorf("decode %T payload: %w", payload, err))
+			}
+			return fn(ctx, payload)
+		},
+	}
+}
+
+// Register installs the handler for taskType, replacing any earlier one.
+// Register handlers before enqueuing tasks of their type.
+func (w *Worker) Register(taskType string, handler Handler) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.handlers[taskType] = handler
+}
+
+func (w *Worker) handler(taskType string) (Handler, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	handler, ok := w.handlers[taskType]
+	return handler, ok
+}
+
+// permanentError marks a failure that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the task is marked dead at once instead of retried
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+func isPermanent(err error) bool {
+	var permanent permanentError
+	return errors.As(err, &permanent)
+}
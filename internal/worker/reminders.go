@@ -0,0 +1,103 @@
+package worker
+
+import (
+	"errors"
+	"time"
+
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository"
+)
+
+// reminderLookback is how long after its time a reminder is still sent. It
+// covers a check interval and the longest quiet hours, so reminders that
+// fall between two checks or are held back overnight go out even when the
+// todo has come due in the meantime.
+const reminderLookback = 24*time.Hour + reminderInterval
+
+// sendDueReminders enqueues a TaskDueReminder for each open todo
+// with a reminder that has come due by now, and returns how many it
+// enqueued. Sent reminders are recorded first, so a restart or an
+// overlapping check never sends one twice; they are deleted again when
+// the reminder cannot be enqueued. Reminders are held back during
+// the owner's quiet hours; when several of a todo's offsets have passed by
+// the time it may be reminded, one reminder goes out for the latest.
+// Reminders older than reminderLookback are dropped.
+func (w *Worker) sendDueReminders(now time.Time) (int, error) {
+	since := now.Add(-reminderLookback)
+	todos, err := w.repos.Todos.FindDueBetween(since, now.Add(models.MaxReminderOffset))
+	if err != nil {
+		return 0, err
+	}
+
+	users := make(map[uint]*models.User)
+	sent := 0
+	for i := range todos {
+		todo := &todos[i]
+		user, ok := users[todo.UserID]
+		if !ok {
+			if user, err = w.repos.Users.FindByID(todo.UserID); err != nil {
+				return sent, err
+			}
+			users[todo.UserID] = user
+		}
+		if user.InQuietHours(now) {
+			continue
+		}
+
+		claimed := -1
+		var recorded []uint
+		for _, minutes := range todo.Reminders {
+			at := todo.DueDate.Add(-time.Duration(minutes) * time.Minute)
+			if at.After(now) || at.Before(since) {
+				continue
+			}
+			reminder := &models.Reminder{
+				TodoID:        todo.ID,
+				DueDate:       *todo.DueDate,
+				OffsetMinutes: minutes,
+				SentAt:        now,
+			}
+			err := w.repos.Reminders.Create(reminder)
+			if errors.Is(err, repository.ErrDuplicate) {
+				continue
+			}
+			if err != nil {
+				return sent, errors.Join(err, w.forgetReminders(recorded))
+			}
+			recorded = append(recorded, reminder.ID)
+			if claimed < 0 || minutes < claimed {
+				claimed = minutes
+			}
+		}
+		if claimed < 0 {
+			continue
+		}
+
+		err := w.Enqueue(Task{
+			Type: TaskDueReminder,
+			Payload: DueReminderPayload{
+				TodoID:        todo.ID,
+				Email:         user.Email,
+				Name:          user.Name,
+				Title:         todo.Title,
+				DueDate:       todo.DueDate.In(user.Location()),
+				OffsetMinutes: claimed,
+			},
+		})
+		if err != nil {
+			return sent, errors.Join(err, w.forgetReminders(recorded))
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// forgetReminders deletes the reminders recorded for a todo whose reminder
+// could not be enqueued, so the next check tries again
+func (w *Worker) forgetReminders(ids []uint) error {
+	var errs []error
+	for _, id := range ids {
+		errs = append(errs, w.repos.Reminders.Delete(id))
+	}
+	return errors.Join(errs...)
+}
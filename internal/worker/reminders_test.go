@@ -0,0 +1,206 @@
+package worker
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository"
+	"github.com/user/go-todo-api/internal/repository/memory"
+)
+
+func TestSendDueReminders(t *testing.T) {
+	repos := memory.NewRepositories()
+	w := New(repos, Options{})
+
+	alice := &models.User{Email: "alice@example.com", Name: "Alice", Timezone: "Europe/Berlin"}
+	require.NoError(t, repos.Users.Create(alice))
+
+	// 12:00 UTC is 13:00 in Berlin
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	due := now.Add(30 * time.Minute)
+	todo := &models.Todo{Title: "Call the bank", DueDate: &due, Reminders: models.ReminderOffsets{1440, 60, 15}, UserID: alice.ID}
+	require.NoError(t, repos.Todos.Create(todo))
+
+	drain := func() []Task {
+		var tasks []Task
+		for {
+			job, err := repos.Jobs.Claim(time.Now().Add(time.Hour))
+			if err != nil {
+				return tasks
+			}
+			var payload DueReminderPayload
+			require.NoError(t, json.Unmarshal([]byte(job.Payload), &payload))
+			tasks = append(tasks, Task{Type: job.Type, Payload: payload})
+		}
+	}
+
+	// The day-ahead and hour-ahead reminders have both passed: one goes out
+	n, err := w.sendDueReminders(now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	tasks := drain()
+	require.Len(t, tasks, 1)
+	assert.Equal(t, TaskDueReminder, tasks[0].Type)
+	reminder := tasks[0].Payload.(DueReminderPayload)
+	assert.Equal(t, 60, reminder.OffsetMinutes)
+	assert.Equal(t, "2026-03-02T13:30:00+01:00", reminder.DueDate.Format(time.RFC3339))
+
+	// Checking again, as after a restart, sends nothing new
+	n, err = w.sendDueReminders(now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	n, err = w.sendDueReminders(now.Add(15 * time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, 15, drain()[0].Payload.(DueReminderPayload).OffsetMinutes)
+
+	// Quiet hours hold reminders back until they end
+	alice.QuietHoursStart, alice.QuietHoursEnd = "13:00", "14:00"
+	require.NoError(t, repos.Users.Update(alice))
+	due = now.Add(2 * time.Hour)
+	todo.DueDate = &due
+	require.NoError(t, repos.Todos.Update(todo))
+
+	n, err = w.sendDueReminders(now.Add(45 * time.Minute))
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	n, err = w.sendDueReminders(now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, 60, drain()[0].Payload.(DueReminderPayload).OffsetMinutes)
+
+	// Completed todos are not reminded
+	todo.Status = models.StatusCompleted
+	require.NoError(t, repos.Todos.Update(todo))
+	n, err = w.sendDueReminders(now.Add(time.Hour + 50*time.Minute))
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func TestSendDueRemindersAroundTheDueDate(t *testing.T) {
+	repos := memory.NewRepositories()
+	w := New(repos, Options{})
+
+	// Quiet hours from 13:00 to 14:00 in Berlin are 12:00 to 13:00 UTC
+	alice := &models.User{Email: "alice@example.com", Name: "Alice", Timezone: "Europe/Berlin", QuietHoursStart: "13:00", QuietHoursEnd: "14:00"}
+	require.NoError(t, repos.Users.Create(alice))
+
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	create := func(title string, due time.Time, offsets ...int) *models.Todo {
+		todo := &models.Todo{Title: title, DueDate: &due, Reminders: offsets, UserID: alice.ID}
+		require.NoError(t, repos.Todos.Create(todo))
+		return todo
+	}
+	sent := func() []DueReminderPayload {
+		var payloads []DueReminderPayload
+		for {
+			job, err := repos.Jobs.Claim(time.Now().Add(time.Hour))
+			if err != nil {
+				return payloads
+			}
+			var payload DueReminderPayload
+			require.NoError(t, json.Unmarshal([]byte(job.Payload), &payload))
+			payloads = append(payloads, payload)
+		}
+	}
+	check := func(at time.Time) []DueReminderPayload {
+		_, err := w.sendDueReminders(at)
+		require.NoError(t, err)
+		return sent()
+	}
+
+	// A reminder at the due date goes out at the first check after it
+	onTime := create("Take out the bins", now.Add(time.Minute), 0)
+	assert.Empty(t, check(now))
+	reminders := check(now.Add(reminderInterval))
+	require.Len(t, reminders, 1)
+	assert.Equal(t, onTime.ID, reminders[0].TodoID)
+	assert.Equal(t, 0, reminders[0].OffsetMinutes)
+
+	// So does one that falls between two checks, after the todo came due
+	now = now.Add(time.Hour)
+	between := create("Water the plants", now.Add(3*time.Minute), 2)
+	assert.Empty(t, check(now))
+	reminders = check(now.Add(reminderInterval))
+	require.Len(t, reminders, 1)
+	assert.Equal(t, between.ID, reminders[0].TodoID)
+	assert.Equal(t, 2, reminders[0].OffsetMinutes)
+
+	// Quiet hours spanning the due date hold the reminder until they end
+	now = time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	quiet := create("Call the bank", now.Add(30*time.Minute), 15)
+	assert.Empty(t, check(now.Add(20*time.Minute)))
+	assert.Empty(t, check(now.Add(40*time.Minute)))
+	reminders = check(now.Add(time.Hour))
+	require.Len(t, reminders, 1)
+	assert.Equal(t, quiet.ID, reminders[0].TodoID)
+	assert.Equal(t, 15, reminders[0].OffsetMinutes)
+	assert.Empty(t, check(now.Add(time.Hour+reminderInterval)))
+
+	// Reminders long past are not sent
+	create("File the taxes", now.Add(-2*24*time.Hour), 60, 0)
+	assert.Empty(t, check(now.Add(time.Hour)))
+}
+
+func TestReminderEmailAfterQuietHours(t *testing.T) {
+	repos := memory.NewRepositories()
+	outbox := &recordingMailer{}
+	w := New(repos, Options{Mailer: outbox})
+
+	// Quiet hours ended five minutes ago, twenty after the todo came due
+	current := time.Now().UTC()
+	alice := &models.User{
+		Email: "alice@example.com", Name: "Alice", Timezone: "UTC",
+		QuietHoursStart: current.Add(-time.Hour).Format("15:04"), QuietHoursEnd: current.Add(-5 * time.Minute).Format("15:04"),
+	}
+	require.NoError(t, repos.Users.Create(alice))
+	due := current.Add(-20 * time.Minute)
+	require.NoError(t, repos.Todos.Create(&models.Todo{Title: "Call the bank", DueDate: &due, Reminders: models.ReminderOffsets{15}, UserID: alice.ID}))
+
+	n, err := w.sendDueReminders(current.Add(-30 * time.Minute))
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	n, err = w.sendDueReminders(current)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	assert.True(t, w.runNext())
+
+	// The email tells how late it is, not the offset it was set for
+	require.Len(t, outbox.sent, 1)
+	assert.Equal(t, "Reminder: Call the bank was due 20 minutes ago", outbox.sent[0].Subject)
+}
+
+// failingJobs refuses every new job, as when the database is down
+type failingJobs struct {
+	repository.JobRepository
+}
+
+func (failingJobs) Create(*models.Job) error {
+	return errors.New("database is down")
+}
+
+func TestRemindersRetriedWhenEnqueueFails(t *testing.T) {
+	repos := memory.NewRepositories()
+	alice := &models.User{Email: "alice@example.com", Name: "Alice"}
+	require.NoError(t, repos.Users.Create(alice))
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	due := now.Add(10 * time.Minute)
+	require.NoError(t, repos.Todos.Create(&models.Todo{Title: "Call the bank", DueDate: &due, Reminders: models.ReminderOffsets{60, 15}, UserID: alice.ID}))
+
+	broken := repos
+	broken.Jobs = failingJobs{repos.Jobs}
+	n, err := New(broken, Options{}).sendDueReminders(now)
+	assert.Error(t, err)
+	assert.Zero(t, n)
+
+	// Nothing was recorded as sent, so the next check sends the reminder
+	n, err = New(repos, Options{}).sendDueReminders(now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
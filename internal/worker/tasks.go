@@ -0,0 +1,165 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/mail"
+	"time"
+
+	"github.com/user/go-todo-api/internal/mailer"
+	"github.com/user/go-todo-api/internal/repository"
+)
+
+// Built-in task types
+const (
+	TaskWelcomeEmail  = "SEND_WELCOME_EMAIL"
+	TaskDueReminder   = "SEND_DUE_REMINDER"
+	TaskTodoCompleted = "TODO_COMPLETED_NOTIFICATION"
+	TaskTodoAssigned  = "TODO_ASSIGNED_NOTIFICATION"
+)
+
+// WelcomeEmailPayload is the payload of TaskWelcomeEmail
+type WelcomeEmailPayload struct {
+	Email string `json:"email"`
+	Name  string `json:"name"`
+}
+
+// DueReminderPayload is the payload of TaskDueReminder
+type DueReminderPayload struct {
+	TodoID        uint      `json:"todo_id"`
+	Email         string    `json:"email"`
+	Name          string    `json:"name"`
+	Title         string    `json:"title"`
+	DueDate       time.Time `json:"due_date"` // in the user's time zone
+	OffsetMinutes int       `json:"offset_minutes"`
+}
+
+// TodoCompletedPayload is the payload of TaskTodoCompleted
+type TodoCompletedPayload struct {
+	ID     uint   `json:"id"`
+	UserID uint   `json:"user_id"`
+	Title  string `json:"title"`
+}
+
+// TodoAssignedPayload is the payload of TaskTodoAssigned. UserID is the
+// assignee and AssignerID the user who assigned the todo.
+type TodoAssignedPayload struct {
+	ID         uint   `json:"id"`
+	UserID     uint   `json:"user_id"`
+	AssignerID uint   `json:"assigner_id"`
+	Title      string `json:"title"`
+}
+
+func (w *Worker) registerBuiltins() {
+	w.Register(TaskWelcomeEmail, TypedHandler(time.Minute, w.sendWelcomeEmail))
+	w.Register(TaskDueReminder, TypedHandler(time.Minute, w.sendDueReminder))
+	w.Register(TaskTodoCompleted, TypedHandler(time.Minute, w.sendTodoCompleted))
+	w.Register(TaskTodoAssigned, TypedHandler(time.Minute, w.sendTodoAssigned))
+	w.Register(TaskWebhookDelivery, TypedHandler(30*time.Second, w.deliverWebhook))
+}
+
+func (w *Worker) sendWelcomeEmail(ctx context.Context, p WelcomeEmailPayload) error {
+	return w.sendEmail(ctx, mailer.TemplateWelcome, p.Name, p.Email, p)
+}
+
+func (w *Worker) sendDueReminder(ctx context.Context, p DueReminderPayload) error {
+	data := struct {
+		DueReminderPayload
+		When string
+	}{p, dueWhen(time.Until(p.DueDate))}
+	return w.sendEmail(ctx, mailer.TemplateReminder, p.Name, p.Email, data)
+}
+
+func (w *Worker) sendTodoCompleted(ctx context.Context, p TodoCompletedPayload) error {
+	// Tasks enqueued before the payload carried the owner are only logged
+	if p.UserID == 0 {
+		slog.Info("TODO COMPLETION LOGGED", slog.String("title", p.Title))
+		return nil
+	}
+	user, err := w.repos.Users.FindByID(p.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return Permanent(err)
+	}
+	if err != nil {
+		return err
+	}
+	data := struct {
+		Name  string
+		Title string
+	}{user.Name, p.Title}
+	return w.sendEmail(ctx, mailer.TemplateCompleted, user.Name, user.Email, data)
+}
+
+func (w *Worker) sendTodoAssigned(ctx context.Context, p TodoAssignedPayload) error {
+	user, err := w.repos.Users.FindByID(p.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return Permanent(err)
+	}
+	if err != nil {
+		return err
+	}
+	assigner, err := w.repos.Users.FindByID(p.AssignerID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return Permanent(err)
+	}
+	if err != nil {
+		return err
+	}
+	data := struct {
+		Name     string
+		Assigner string
+		Title    string
+	}{user.Name, assigner.Name, p.Title}
+	return w.sendEmail(ctx, mailer.TemplateAssigned, user.Name, user.Email, data)
+}
+
+// sendEmail renders template name with data and mails it to the named
+// address. Rendering failures are permanent; delivery failures are retried.
+func (w *Worker) sendEmail(ctx context.Context, template, name, email string, data interface{}) error {
+	to := (&mail.Address{Name: name, Address: email}).String()
+	msg, err := mailer.Render(template, to, data)
+	if err != nil {
+		return Permanent(err)
+	}
+	if err := w.opts.Mailer.Send(ctx, msg); err != nil {
+		return fmt.Errorf("send %s email: %w", template, err)
+	}
+	slog.Info("Email sent", slog.String("template", template), slog.String("email", email))
+	return nil
+}
+
+// dueWhen describes how long is left until a todo is due for the reminder
+// email, e.g. "is due in 2 hours", or "was due 20 minutes ago" for a
+// reminder held back past the due date
+func dueWhen(left time.Duration) string {
+	minutes := int(left.Round(time.Minute) / time.Minute)
+	switch {
+	case minutes > 0:
+		return "is due in " + span(minutes)
+	case minutes < 0:
+		return "was due " + span(-minutes) + " ago"
+	default:
+		return "is due now"
+	}
+}
+
+// span words a positive number of minutes, in whole days or hours when
+// they are exact or the span is long enough for the unit to read better
+func span(minutes int) string {
+	unit := func(n int, name string) string {
+		if n == 1 {
+			return "1 " + name
+		}
+		return fmt.Sprintf("%d %ss", n, name)
+	}
+	switch {
+	case minutes%(24*60) == 0 || minutes >= 2*24*60:
+		return unit((minutes+12*60)/(24*60), "day")
+	case minutes%60 == 0 || minutes >= 2*60:
+		return unit((minutes+30)/60, "hour")
+	default:
+		return unit(minutes, "minute")
+	}
+}
@@ -0,0 +1,88 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/go-todo-api/internal/mailer"
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository/memory"
+)
+
+type recordingMailer struct {
+	sent []mailer.Message
+}
+
+func (m *recordingMailer) Send(ctx context.Context, msg mailer.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func TestBuiltinTasksSendEmails(t *testing.T) {
+	repos := memory.NewRepositories()
+	user := &models.User{Email: "ada@example.com", Name: "Ada", Password: "hash"}
+	require.NoError(t, repos.Users.Create(user))
+
+	outbox := &recordingMailer{}
+	w := New(repos, Options{Mailer: outbox})
+	due := time.Now().UTC().Add(2 * time.Hour)
+	for _, task := range []Task{
+		{Type: TaskWelcomeEmail, Payload: WelcomeEmailPayload{Email: user.Email, Name: user.Name}},
+		{Type: TaskDueReminder, Payload: DueReminderPayload{TodoID: 1, Email: user.Email, Name: user.Name, Title: "Dentist", DueDate: due, OffsetMinutes: 120}},
+		{Type: TaskTodoCompleted, Payload: TodoCompletedPayload{ID: 1, UserID: user.ID, Title: "Dentist"}},
+	} {
+		require.NoError(t, w.Enqueue(task))
+		assert.True(t, w.runNext())
+	}
+
+	require.Len(t, outbox.sent, 3)
+	for _, msg := range outbox.sent {
+		assert.Equal(t, `"Ada" <ada@example.com>`, msg.To)
+	}
+	assert.Equal(t, "Welcome to Todo, Ada!", outbox.sent[0].Subject)
+	assert.Equal(t, "Reminder: Dentist is due in 2 hours", outbox.sent[1].Subject)
+	assert.Contains(t, outbox.sent[1].Text, due.Format("Mon, 02 Jan 2006 at 15:04 UTC"))
+	assert.Equal(t, "Completed: Dentist", outbox.sent[2].Subject)
+
+	// A completion for a deleted user cannot be delivered and is not retried
+	require.NoError(t, w.Enqueue(Task{Type: TaskTodoCompleted, Payload: TodoCompletedPayload{ID: 2, UserID: 99, Title: "Gone"}}))
+	assert.True(t, w.runNext())
+	assert.Equal(t, models.JobDead, findJob(t, w, 4).Status)
+}
+
+func TestTodoAssignedEmail(t *testing.T) {
+	repos := memory.NewRepositories()
+	ada := &models.User{Email: "ada@example.com", Name: "Ada", Password: "hash"}
+	require.NoError(t, repos.Users.Create(ada))
+	grace := &models.User{Email: "grace@example.com", Name: "Grace", Password: "hash"}
+	require.NoError(t, repos.Users.Create(grace))
+
+	outbox := &recordingMailer{}
+	w := New(repos, Options{Mailer: outbox})
+	require.NoError(t, w.Enqueue(Task{Type: TaskTodoAssigned, Payload: TodoAssignedPayload{ID: 1, UserID: grace.ID, AssignerID: ada.ID, Title: "Dentist"}}))
+	assert.True(t, w.runNext())
+
+	require.Len(t, outbox.sent, 1)
+	assert.Equal(t, `"Grace" <grace@example.com>`, outbox.sent[0].To)
+	assert.Equal(t, "Assigned to you: Dentist", outbox.sent[0].Subject)
+	assert.Contains(t, outbox.sent[0].Text, `Ada assigned "Dentist" to you.`)
+
+	// An assignment to a deleted user is not retried
+	require.NoError(t, w.Enqueue(Task{Type: TaskTodoAssigned, Payload: TodoAssignedPayload{ID: 2, UserID: 99, AssignerID: ada.ID, Title: "Gone"}}))
+	assert.True(t, w.runNext())
+	assert.Equal(t, models.JobDead, findJob(t, w, 2).Status)
+}
+
+func TestDueWhen(t *testing.T) {
+	for minutes, want := range map[int]string{
+		0: "is due now", 1: "is due in 1 minute", 15: "is due in 15 minutes", 60: "is due in 1 hour", 90: "is due in 90 minutes",
+		1437: "is due in 24 hours", 2880: "is due in 2 days", 3000: "is due in 2 days",
+		-20: "was due 20 minutes ago", -180: "was due 3 hours ago",
+	} {
+		assert.Equal(t, want, dueWhen(time.Duration(minutes)*time.Minute), minutes)
+	}
+	assert.Equal(t, "is due now", dueWhen(20*time.Second))
+}
@@ -0,0 +1,97 @@
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrBlockedAddress is returned for webhook targets that are not publicly
+// routable, so that webhooks cannot be used to reach internal services
+var ErrBlockedAddress = errors.New("address is loopback, private, link-local or reserved")
+
+// blockedNets are the ranges blockedIP refuses besides those the net.IP
+// methods recognise: "this network", the shared address space of
+// carrier-grade NAT and the IETF protocol assignments
+var blockedNets = func() []*net.IPNet {
+	var nets []*net.IPNet
+	for _, cidr := range []string{"0.0.0.0/8", "100.64.0.0/10", "192.0.0.0/24"} {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets
+}()
+
+// blockedIP reports whether webhooks must not connect to ip: loopback,
+// private, link-local (which includes cloud metadata services such as
+// 169.254.169.254), multicast and unspecified addresses, and blockedNets.
+// IPv4-mapped IPv6 addresses are checked as the IPv4 address they carry.
+func blockedIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return true
+	}
+	for _, ipNet := range blockedNets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckWebhookHost rejects webhook hosts that are blocked IP literals or
+// localhost names. Other names are checked when the worker connects, after
+// they are resolved, since DNS can change after the webhook is saved.
+func CheckWebhookHost(host string) error {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrBlockedAddress
+	}
+	if ip := net.ParseIP(host); ip != nil && blockedIP(ip) {
+		return ErrBlockedAddress
+	}
+	return nil
+}
+
+// newWebhookClient returns the client webhooks are delivered with by
+// default. It refuses to connect to addresses for which blocked is true,
+// checking the resolved address of every connection so a name cannot be
+// rebound to an internal one, ignores proxy settings, which would hide the
+// target from the check, and does not follow redirects.
+func newWebhookClient(blocked func(net.IP) bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || blocked(ip) {
+				return fmt.Errorf("%w: %s", ErrBlockedAddress, host)
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,146 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/user/go-todo-api/internal/events"
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository"
+	"github.com/user/go-todo-api/pkg/utils"
+)
+
+// TaskWebhookDelivery POSTs one event to one webhook
+const TaskWebhookDelivery = "DELIVER_WEBHOOK"
+
+// Headers sent with every webhook delivery. The signature covers the
+// timestamp and the body; see utils.SignWebhook.
+const (
+	HeaderWebhookEvent     = "X-Todo-Event"
+	HeaderWebhookDelivery  = "X-Todo-Delivery"
+	HeaderWebhookTimestamp = "X-Todo-Timestamp"
+	HeaderWebhookSignature = "X-Todo-Signature"
+)
+
+// maxLoggedResponse caps how much of an endpoint's response is kept in the
+// delivery log. Only public endpoints are reached, so their responses are
+// safe to show the webhook's owner.
+const maxLoggedResponse = 1024
+
+// WebhookDeliveryPayload is the payload of TaskWebhookDelivery. Body is
+// encoded once when the event is published, so every attempt sends the
+// same bytes.
+type WebhookDeliveryPayload struct {
+	WebhookID uint   `json:"webhook_id"`
+	EventID   string `json:"event_id"`
+	Event     string `json:"event"`
+	Body      string `json:"body"`
+}
+
+// DispatchWebhooks queues e for each of its user's webhooks subscribed to
+// it. It is meant to be subscribed to the events bus.
+func (w *Worker) DispatchWebhooks(e events.Event) {
+	hooks, err := w.repos.Webhooks.FindSubscribed(e.UserID, e.Type)
+	if err != nil {
+		slog.Error("Failed to find webhooks", slog.String("event", e.Type), slog.Any("error", err))
+		return
+	}
+	for i := range hooks {
+		w.EnqueueWebhook(&hooks[i], e)
+	}
+}
+
+// EnqueueWebhook queues the delivery of e to hook, whatever its subscription
+func (w *Worker) EnqueueWebhook(hook *models.Webhook, e events.Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		slog.Error("Failed to encode event", slog.String("event", e.Type), slog.Any("error", err))
+		return err
+	}
+	return w.Enqueue(Task{
+		Type: TaskWebhookDelivery,
+		Payload: WebhookDeliveryPayload{
+			WebhookID: hook.ID,
+			EventID:   e.ID,
+			Event:     e.Type,
+			Body:      string(body),
+		},
+	})
+}
+
+// deliverWebhook POSTs the event and logs the attempt. Any response but a
+// 2xx fails the attempt so it is retried with backoff.
+func (w *Worker) deliverWebhook(ctx context.Context, p WebhookDeliveryPayload) error {
+	hook, err := w.repos.Webhooks.FindByID(p.WebhookID)
+	if errors.Is(err, repository.ErrNotFound) {
+		slog.Info("Dropped delivery to deleted webhook", slog.Uint64("webhook_id", uint64(p.WebhookID)))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// Test events go out even while the webhook is paused
+	if !hook.Active && p.Event != models.EventPing {
+		slog.Info("Dropped delivery to paused webhook", slog.Uint64("webhook_id", uint64(hook.ID)))
+		return nil
+	}
+
+	delivery := &models.WebhookDelivery{
+		WebhookID: hook.ID,
+		EventID:   p.EventID,
+		Event:     p.Event,
+		Payload:   p.Body,
+	}
+	start := time.Now()
+	deliverErr := w.post(ctx, hook, p, delivery)
+	delivery.DurationMS = time.Since(start).Milliseconds()
+	delivery.CreatedAt = models.Now()
+	if deliverErr != nil {
+		delivery.Error = deliverErr.Error()
+	}
+	if err := w.repos.Webhooks.CreateDelivery(delivery); err != nil {
+		slog.Error("Failed to log webhook delivery", slog.Uint64("webhook_id", uint64(hook.ID)), slog.Any("error", err))
+	}
+	return deliverErr
+}
+
+// post sends the signed request, recording the response in delivery
+func (w *Worker) post(ctx context.Context, hook *models.Webhook, p WebhookDeliveryPayload, delivery *models.WebhookDelivery) error {
+	body := []byte(p.Body)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return Permanent(err)
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-todo-api-webhooks/1.0")
+	req.Header.Set(HeaderWebhookEvent, p.Event)
+	req.Header.Set(HeaderWebhookDelivery, p.EventID)
+	req.Header.Set(HeaderWebhookTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderWebhookSignature, utils.SignWebhook(hook.Secret, timestamp, body))
+
+	resp, err := w.opts.HTTPClient.Do(req)
+	if errors.Is(err, ErrBlockedAddress) {
+		return Permanent(err)
+	}
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	response, _ := io.ReadAll(io.LimitReader(resp.Body, maxLoggedResponse))
+	delivery.StatusCode = resp.StatusCode
+	delivery.Response = string(response)
+	if !delivery.Succeeded() {
+		return fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package worker
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/go-todo-api/internal/events"
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository/memory"
+	"github.com/user/go-todo-api/pkg/utils"
+)
+
+// webhookReceiver records the requests it gets, answering with the next
+// status in statuses (200 once they run out)
+type webhookReceiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rcv *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	rcv.requests = append(rcv.requests, r)
+	rcv.bodies = append(rcv.bodies, body)
+	status := http.StatusOK
+	if len(rcv.statuses) > 0 {
+		status, rcv.statuses = rcv.statuses[0], rcv.statuses[1:]
+	}
+	w.WriteHeader(status)
+	io.WriteString(w, "thanks")
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	rcv := &webhookReceiver{statuses: []int{http.StatusServiceUnavailable}}
+	server := httptest.NewServer(rcv)
+	defer server.Close()
+
+	repos := memory.NewRepositories()
+	// The test server listens on loopback, which the default client refuses
+	w := New(repos, Options{HTTPClient: server.Client()})
+	hook := &models.Webhook{UserID: 1, URL: server.URL, Secret: "whsec_test", Events: models.WebhookEvents{models.EventTodoCompleted}, Active: true}
+	require.NoError(t, repos.Webhooks.Create(hook))
+	other := &models.Webhook{UserID: 2, URL: server.URL, Secret: "whsec_other", Events: models.TodoEvents, Active: true}
+	require.NoError(t, repos.Webhooks.Create(other))
+
+	// Only the owner's webhooks subscribed to the event get it
+	w.DispatchWebhooks(events.New(models.EventTodoCreated, 1, nil))
+	assert.False(t, w.runNext())
+	event := events.New(models.EventTodoCompleted, 1, map[string]string{"title": "Taxes"})
+	w.DispatchWebhooks(event)
+
+	// The first attempt fails and is retried later with the same body
+	assert.True(t, w.runNext())
+	job := findJob(t, w, 1)
+	assert.Equal(t, models.JobPending, job.Status)
+	assert.Contains(t, job.LastError, "503")
+	job.RunAt = models.Now()
+	require.NoError(t, repos.Jobs.Update(job))
+	assert.True(t, w.runNext())
+	assert.Equal(t, models.JobDone, findJob(t, w, 1).Status)
+
+	require.Len(t, rcv.requests, 2)
+	assert.Equal(t, rcv.bodies[0], rcv.bodies[1])
+	req, body := rcv.requests[1], rcv.bodies[1]
+	assert.Equal(t, models.EventTodoCompleted, req.Header.Get(HeaderWebhookEvent))
+	assert.Equal(t, event.ID, req.Header.Get(HeaderWebhookDelivery))
+	timestamp, err := strconv.ParseInt(req.Header.Get(HeaderWebhookTimestamp), 10, 64)
+	require.NoError(t, err)
+	assert.True(t, utils.VerifyWebhook("whsec_test", req.Header.Get(HeaderWebhookSignature), timestamp, body, time.Minute, time.Now()))
+
+	var sent struct {
+		ID   string            `json:"id"`
+		Type string            `json:"type"`
+		Data map[string]string `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(body, &sent))
+	assert.Equal(t, event.ID, sent.ID)
+	assert.Equal(t, "Taxes", sent.Data["title"])
+
+	deliveries, err := repos.Webhooks.FindDeliveries(hook.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 2)
+	assert.Equal(t, http.StatusOK, deliveries[0].StatusCode)
+	assert.Equal(t, "thanks", deliveries[0].Response)
+	assert.Equal(t, http.StatusServiceUnavailable, deliveries[1].StatusCode)
+	assert.NotEmpty(t, deliveries[1].Error)
+}
+
+func TestWebhookPausedOrDeleted(t *testing.T) {
+	rcv := &webhookReceiver{}
+	server := httptest.NewServer(rcv)
+	defer server.Close()
+
+	repos := memory.NewRepositories()
+	// The test server listens on loopback, which the default client refuses
+	w := New(repos, Options{HTTPClient: server.Client()})
+	hook := &models.Webhook{UserID: 1, URL: server.URL, Secret: "whsec_test", Events: models.TodoEvents, Active: true}
+	require.NoError(t, repos.Webhooks.Create(hook))
+
+	// Events queued before the webhook was paused are dropped, but test
+	// events still go out
+	require.NoError(t, w.EnqueueWebhook(hook, events.New(models.EventTodoUpdated, 1, nil)))
+	require.NoError(t, w.EnqueueWebhook(hook, events.New(models.EventPing, 1, nil)))
+	hook.Active = false
+	require.NoError(t, repos.Webhooks.Update(hook))
+	assert.True(t, w.runNext())
+	assert.True(t, w.runNext())
+	require.Len(t, rcv.requests, 1)
+	assert.Equal(t, models.EventPing, rcv.requests[0].Header.Get(HeaderWebhookEvent))
+
+	require.NoError(t, w.EnqueueWebhook(hook, events.New(models.EventPing, 1, nil)))
+	require.NoError(t, repos.Webhooks.Delete(hook.ID, 1))
+	assert.True(t, w.runNext())
+	assert.Equal(t, models.JobDone, findJob(t, w, 3).Status)
+	assert.Len(t, rcv.requests, 1)
+}
+
+func TestWebhookRefusesInternalTargets(t *testing.T) {
+	rcv := &webhookReceiver{}
+	server := httptest.NewServer(rcv)
+	defer server.Close()
+
+	repos := memory.NewRepositories()
+	w := New(repos, Options{})
+	hook := &models.Webhook{UserID: 1, URL: server.URL, Secret: "whsec_test", Events: models.TodoEvents, Active: true}
+	require.NoError(t, repos.Webhooks.Create(hook))
+
+	// The loopback address is refused when connecting, and not retried
+	require.NoError(t, w.EnqueueWebhook(hook, events.New(models.EventPing, 1, nil)))
+	assert.True(t, w.runNext())
+	assert.Empty(t, rcv.requests)
+	assert.Equal(t, models.JobDead, findJob(t, w, 1).Status)
+
+	deliveries, err := repos.Webhooks.FindDeliveries(hook.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Zero(t, deliveries[0].StatusCode)
+	assert.Empty(t, deliveries[0].Response)
+	assert.Contains(t, deliveries[0].Error, "loopback")
+}
+
+func TestWebhookRedirectsNotFollowed(t *testing.T) {
+	rcv := &webhookReceiver{}
+	target := httptest.NewServer(rcv)
+	defer target.Close()
+	redirect := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusFound))
+	defer redirect.Close()
+
+	// Allow loopback so only the redirect policy is under test
+	client := newWebhookClient(func(net.IP) bool { return false })
+	repos := memory.NewRepositories()
+	w := New(repos, Options{HTTPClient: client})
+	hook := &models.Webhook{UserID: 1, URL: redirect.URL, Secret: "whsec_test", Events: models.TodoEvents, Active: true}
+	require.NoError(t, repos.Webhooks.Create(hook))
+
+	require.NoError(t, w.EnqueueWebhook(hook, events.New(models.EventPing, 1, nil)))
+	assert.True(t, w.runNext())
+	assert.Empty(t, rcv.requests)
+	deliveries, err := repos.Webhooks.FindDeliveries(hook.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, http.StatusFound, deliveries[0].StatusCode)
+	assert.NotEmpty(t, deliveries[0].Error)
+}
+
+func TestBlockedIP(t *testing.T) {
+	tests := []struct {
+		ip      string
+		blocked bool
+	}{
+		{"127.0.0.1", true},
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"192.168.1.1", true},
+		{"169.254.169.254", true},
+		{"224.0.0.1", true},
+		{"0.0.0.0", true},
+		{"0.1.2.3", true},
+		{"100.64.0.1", true},
+		{"100.127.255.254", true},
+		{"192.0.0.8", true},
+		{"::1", true},
+		{"::", true},
+		{"fe80::1", true},
+		{"fc00::1", true},
+		{"ff02::1", true},
+		{"::ffff:127.0.0.1", true},
+		{"::ffff:10.0.0.1", true},
+		{"::ffff:169.254.169.254", true},
+		{"::ffff:100.64.0.1", true},
+		{"::ffff:192.0.0.8", true},
+		{"::ffff:0.0.0.1", true},
+		{"93.184.216.34", false},
+		{"100.63.255.255", false},
+		{"100.128.0.1", false},
+		{"192.0.1.1", false},
+		{"1.0.0.1", false},
+		{"::ffff:93.184.216.34", false},
+		{"2606:2800:220:1::248", false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.ip, func(t *testing.T) {
+			ip := net.ParseIP(tc.ip)
+			require.NotNil(t, ip)
+			assert.Equal(t, tc.blocked, blockedIP(ip))
+		})
+	}
+}
+
+func TestCheckWebhookHost(t *testing.T) {
+	for _, host := range []string{"localhost", "api.localhost", "127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254", "::1", "fe80::1", "0.0.0.0", "::ffff:127.0.0.1", "100.64.0.1", "::ffff:10.0.0.1"} {
+		assert.ErrorIs(t, CheckWebhookHost(host), ErrBlockedAddress, host)
+	}
+	for _, host := range []string{"example.com", "93.184.216.34", "2606:2800:220:1::248"} {
+		assert.NoError(t, CheckWebhookHost(host), host)
+	}
+}
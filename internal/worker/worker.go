@@ -1,76 +1,450 @@
 package worker
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"sync"
 	"time"
+
+	"github.com/user/go-todo-api/internal/mailer"
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository"
 )
 
-// Task represents an asynchronous task
+// Task represents an asynchronous task. Payload is stored as JSON and must
+// decode into the payload type of the handler registered for Type.
 type Task struct {
 	Type    string
-	Payload map[string]interface{}
+	Payload interface{}
+	// RunAt schedules the task; the zero time runs it as soon as possible
+	RunAt time.Time
+	// MaxAttempts overrides Options.MaxAttempts when positive
+	MaxAttempts int
+}
+
+// Options tunes the worker; zero fields take the defaults below
+type Options struct {
+	Concurrency  int           // tasks processed in parallel (default 4)
+	MaxAttempts  int           // attempts before a task is dead (default 5)
+	PollInterval time.Duration // how often idle workers look for due tasks (default 1s)
+	BaseBackoff  time.Duration // delay before the first retry, doubled on each further one (default 10s)
+	MaxBackoff   time.Duration // cap on the retry delay (default 1h)
+	TaskTimeout  time.Duration // per attempt, for handlers without their own Timeout (default 30s)
+	Mailer       mailer.Mailer // sends the built-in emails (default: logged by an outbox)
+	HTTPClient   *http.Client  // delivers webhooks (default: 10s timeout, public addresses only, no redirects)
+
+	// TrashRetention is how long deleted todos stay in the trash before
+	// they are purged (default 30 days)
+	TrashRetention time.Duration
+	// ArchiveCompletedAfter is how long after their completion todos are
+	// archived; zero leaves them alone
+	ArchiveCompletedAfter time.Duration
+	// JobRetention is how long done tasks are kept before they are
+	// deleted (default 7 days); dead tasks are kept for inspection
+	JobRetention time.Duration
+}
+
+func (o Options) withDefaults() Options {
+	if o.Concurrency < 1 {
+		o.Concurrency = 4
+	}
+	if o.MaxAttempts < 1 {
+		o.MaxAttempts = 5
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = time.Second
+	}
+	if o.BaseBackoff <= 0 {
+		o.BaseBackoff = 10 * time.Second
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = time.Hour
+	}
+	if o.TaskTimeout <= 0 {
+		o.TaskTimeout = 30 * time.Second
+	}
+	if o.TrashRetention <= 0 {
+		o.TrashRetention = 30 * 24 * time.Hour
+	}
+	if o.JobRetention <= 0 {
+		o.JobRetention = 7 * 24 * time.Hour
+	}
+	if o.Mailer == nil {
+		o.Mailer = mailer.NewOutbox("", "Todo API <no-reply@localhost>")
+	}
+	if o.HTTPClient == nil {
+		o.HTTPClient = newWebhookClient(blockedIP)
+	}
+	return o
 }
 
-// Worker handles asynchronous background tasks
+// staleLockAge is how long a task may stay running before it is presumed
+// orphaned by a crashed worker and queued again
+const staleLockAge = 10 * time.Minute
+
+// reminderInterval is how often the worker checks for due reminders and
+// runs its housekeeping
+const reminderInterval = 5 * time.Minute
+
+// Worker handles asynchronous background tasks. Tasks are stored as jobs in
+// repos.Jobs, so they survive restarts, and failed tasks are retried with
+// exponential backoff until they run out of attempts.
 type Worker struct {
-	taskQueue chan Task
+	repos repository.Repositories
+	opts  Options
+	wake  chan struct{} // nudges an idle processor when a task is enqueued
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+
+	// Lifecycle: quit stops new work, tasksCtx is cancelled to interrupt
+	// running handlers when Stop's deadline passes, and wg tracks the
+	// processor and ticker goroutines
+	quit        chan struct{}
+	stopOnce    sync.Once
+	tasksCtx    context.Context
+	cancelTasks context.CancelFunc
+	wg          sync.WaitGroup
 }
 
+// errInterrupted fails an attempt cut short by Stop; the task is queued
+// again without counting the attempt
+var errInterrupted = errors.New("task interrupted by shutdown")
+
 var GlobalWorker *Worker
 
-// InitWorker initializes the background worker
-func InitWorker() {
-	GlobalWorker = &Worker{
-		taskQueue: make(chan Task, 100),
+// New returns a worker that stores its tasks in repos.Jobs, with the
+// built-in task types registered. Nothing runs until Start is called.
+func New(repos repository.Repositories, opts Options) *Worker {
+	w := &Worker{
+		repos:    repos,
+		opts:     opts.withDefaults(),
+		wake:     make(chan struct{}, 1),
+		handlers: make(map[string]Handler),
+		quit:     make(chan struct{}),
+	}
+	w.tasksCtx, w.cancelTasks = context.WithCancel(context.Background())
+	w.registerBuiltins()
+	return w
+}
+
+// InitWorker initializes and starts the background worker. repos holds the
+// job queue and is used by the periodic jobs, such as due-date reminders.
+func InitWorker(repos repository.Repositories, opts Options) {
+	GlobalWorker = New(repos, opts)
+	GlobalWorker.Start()
+}
+
+// Start launches the task processors and the reminder ticker. A worker is
+// started at most once.
+func (w *Worker) Start() {
+	w.requeueStale()
+	slog.Info("Background worker started", slog.Int("concurrency", w.opts.Concurrency))
+	w.wg.Add(w.opts.Concurrency + 1)
+	for i := 0; i < w.opts.Concurrency; i++ {
+		go func() {
+			defer w.wg.Done()
+			w.run()
+		}()
+	}
+	go func() {
+		defer w.wg.Done()
+		w.startReminderTicker()
+	}()
+}
+
+// Stop stops taking new tasks and waits for running ones to finish. If ctx
+// ends first, running handlers are cancelled and their tasks queued again
+// for the next start, and ctx's error is returned. Tasks enqueued after
+// Stop stay in the queue.
+func (w *Worker) Stop(ctx context.Context) error {
+	w.stopOnce.Do(func() { close(w.quit) })
+
+	stopped := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		slog.Info("Background worker stopped")
+		return nil
+	case <-ctx.Done():
+		// Interrupted handlers return at once, so the processors only
+		// need to record the requeue
+		w.cancelTasks()
+		<-stopped
+		slog.Warn("Background worker stopped before running tasks finished; they were queued again")
+		return ctx.Err()
 	}
-	go GlobalWorker.start()
-	go GlobalWorker.startReminderTicker()
 }
 
 func (w *Worker) startReminderTicker() {
-	ticker := time.NewTicker(5 * time.Minute)
+	ticker := time.NewTicker(reminderInterval)
 	defer ticker.Stop()
 
-	for range ticker.C {
+	for {
+		select {
+		case <-w.quit:
+			return
+		case <-ticker.C:
+		}
 		w.checkDueTodos()
+		w.requeueStale()
+		w.deleteExpiredIdempotencyKeys()
+		w.deleteFinishedJobs()
+		w.purgeTrash()
+		w.archiveCompleted()
 	}
 }
 
 func (w *Worker) checkDueTodos() {
 	slog.Info("Checking for todos due soon...")
-	// This would typically query the DB for todos due in the next X minutes
-	// For this example, we just log the check.
+	n, err := w.sendDueReminders(models.Now())
+	if err != nil {
+		slog.Error("Failed to check due todos", slog.Any("error", err))
+		return
+	}
+	if n > 0 {
+		slog.Info("Due reminders enqueued", slog.Int("count", n))
+	}
 }
 
-// Enqueue adds a task to the queue
-func (w *Worker) Enqueue(t Task) {
-	w.taskQueue <- t
+func (w *Worker) requeueStale() {
+	n, err := w.repos.Jobs.RequeueStale(models.Now().Add(-staleLockAge))
+	if err != nil {
+		slog.Error("Failed to requeue stale tasks", slog.Any("error", err))
+		return
+	}
+	if n > 0 {
+		slog.Warn("Requeued stale tasks", slog.Int64("count", n))
+	}
 }
 
-func (w *Worker) start() {
-	slog.Info("Background worker started")
-	for task := range w.taskQueue {
-		w.processTask(task)
+func (w *Worker) deleteExpiredIdempotencyKeys() {
+	n, err := w.repos.Idempotency.DeleteExpired(models.Now())
+	if err != nil {
+		slog.Error("Failed to delete expired idempotency keys", slog.Any("error", err))
+		return
+	}
+	if n > 0 {
+		slog.Info("Deleted expired idempotency keys", slog.Int64("count", n))
 	}
 }
 
-func (w *Worker) processTask(t Task) {
-	slog.Info("Processing background task", slog.String("type", t.Type))
+// deleteFinishedJobs deletes the tasks that have been done for longer than
+// the retention period
+func (w *Worker) deleteFinishedJobs() {
+	n, err := w.repos.Jobs.DeleteFinishedBefore(models.Now().Add(-w.opts.JobRetention))
+	if err != nil {
+		slog.Error("Failed to delete finished tasks", slog.Any("error", err))
+		return
+	}
+	if n > 0 {
+		slog.Info("Deleted finished tasks", slog.Int64("count", n))
+	}
+}
 
-	switch t.Type {
-	case "SEND_WELCOME_EMAIL":
-		// Mock email sending
-		time.Sleep(1 * time.Second) // Simulate network delay
-		slog.Info("WELCOME EMAIL SENT",
-			slog.String("email", t.Payload["email"].(string)),
-			slog.String("name", t.Payload["name"].(string)),
-		)
-	case "TODO_COMPLETED_NOTIFICATION":
-		time.Sleep(500 * time.Millisecond)
-		slog.Info("TODO COMPLETION LOGGED",
-			slog.String("title", t.Payload["title"].(string)),
+// purgeTrash deletes for good the todos that have been in the trash for
+// longer than the retention period
+func (w *Worker) purgeTrash() {
+	n, err := w.repos.Todos.PurgeDeletedBefore(models.Now().Add(-w.opts.TrashRetention))
+	if err != nil {
+		slog.Error("Failed to purge deleted todos", slog.Any("error", err))
+		return
+	}
+	if n > 0 {
+		slog.Info("Purged deleted todos", slog.Int64("count", n))
+	}
+}
+
+// archiveCompleted archives the todos completed more than
+// ArchiveCompletedAfter ago
+func (w *Worker) archiveCompleted() {
+	if w.opts.ArchiveCompletedAfter <= 0 {
+		return
+	}
+	n, err := w.repos.Todos.ArchiveCompletedBefore(models.Now().Add(-w.opts.ArchiveCompletedAfter))
+	if err != nil {
+		slog.Error("Failed to archive completed todos", slog.Any("error", err))
+		return
+	}
+	if n > 0 {
+		slog.Info("Archived completed todos", slog.Int64("count", n))
+	}
+}
+
+// Enqueue stores a task for the processors. Tasks of unregistered types are
+// rejected with ErrUnknownTaskType. Failures are logged as well as returned,
+// since most producers have no way to recover from them.
+func (w *Worker) Enqueue(t Task) error {
+	if _, ok := w.handler(t.Type); !ok {
+		slog.Error("Rejected task of unknown type", slog.String("type", t.Type))
+		return fmt.Errorf("%w: %q", ErrUnknownTaskType, t.Type)
+	}
+
+	payload, err := json.Marshal(t.Payload)
+	if err != nil {
+		slog.Error("Failed to encode task", slog.String("type", t.Type), slog.Any("error", err))
+		return err
+	}
+
+	job := &models.Job{
+		Type:        t.Type,
+		Payload:     string(payload),
+		Status:      models.JobPending,
+		MaxAttempts: t.MaxAttempts,
+		RunAt:       t.RunAt.UTC(),
+	}
+	if job.MaxAttempts < 1 {
+		job.MaxAttempts = w.opts.MaxAttempts
+	}
+	if t.RunAt.IsZero() {
+		job.RunAt = models.Now()
+	}
+	if err := w.repos.Jobs.Create(job); err != nil {
+		slog.Error("Failed to enqueue task", slog.String("type", t.Type), slog.Any("error", err))
+		return err
+	}
+
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// run processes due tasks until there are none, then waits for a new one
+// or the next poll, until the worker is stopped
+func (w *Worker) run() {
+	for {
+		select {
+		case <-w.quit:
+			return
+		default:
+		}
+		if w.runNext() {
+			continue
+		}
+		select {
+		case <-w.quit:
+			return
+		case <-w.wake:
+		case <-time.After(w.opts.PollInterval):
+		}
+	}
+}
+
+// runNext claims and processes one due task, reporting whether there was one
+func (w *Worker) runNext() bool {
+	job, err := w.repos.Jobs.Claim(models.Now())
+	if errors.Is(err, repository.ErrNotFound) {
+		return false
+	}
+	if err != nil {
+		slog.Error("Failed to claim task", slog.Any("error", err))
+		return false
+	}
+
+	w.finish(job, w.execute(job))
+	return true
+}
+
+// execute runs the job's handler under its timeout. A handler that panics
+// fails the attempt instead of killing the processor, and one that ignores
+// its context is abandoned when the timeout expires.
+func (w *Worker) execute(job *models.Job) error {
+	handler, ok := w.handler(job.Type)
+	if !ok {
+		return Permanent(fmt.Errorf("%w: %q", ErrUnknownTaskType, job.Type))
+	}
+
+	timeout := handler.Timeout
+	if timeout <= 0 {
+		timeout = w.opts.TaskTimeout
+	}
+	ctx, cancel := context.WithTimeout(w.tasksCtx, timeout)
+	defer cancel()
+
+	slog.Info("Processing background task", slog.String("type", job.Type), slog.Uint64("job_id", uint64(job.ID)))
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				slog.Error("Task handler panicked",
+					slog.String("type", job.Type),
+					slog.Any("panic", r),
+					slog.String("stack", string(debug.Stack())),
+				)
+				done <- fmt.Errorf("task panicked: %v", r)
+			}
+		}()
+		done <- handler.Handle(ctx, json.RawMessage(job.Payload))
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("task timed out after %s", timeout)
+	}
+	if err != nil && w.tasksCtx.Err() != nil {
+		return errInterrupted
+	}
+	return err
+}
+
+// finish records the outcome of a task attempt: done, retried after a
+// backoff, dead once its attempts are used up, or queued again as it was
+// when interrupted by Stop
+func (w *Worker) finish(job *models.Job, err error) {
+	job.LockedAt = nil
+	switch {
+	case errors.Is(err, errInterrupted):
+		job.Status = models.JobPending
+		job.Attempts--
+	case err == nil:
+		job.Status = models.JobDone
+		job.LastError = ""
+	case job.Attempts >= job.MaxAttempts || isPermanent(err):
+		job.Status = models.JobDead
+		job.LastError = err.Error()
+		slog.Error("Task failed permanently",
+			slog.Uint64("job_id", uint64(job.ID)),
+			slog.String("type", job.Type),
+			slog.Int("attempts", job.Attempts),
+			slog.Any("error", err),
 		)
 	default:
-		slog.Warn("Unknown task type", slog.String("type", t.Type))
+		job.Status = models.JobPending
+		job.LastError = err.Error()
+		job.RunAt = models.Now().Add(w.backoff(job.Attempts))
+		slog.Warn("Task failed, will retry",
+			slog.Uint64("job_id", uint64(job.ID)),
+			slog.String("type", job.Type),
+			slog.Int("attempts", job.Attempts),
+			slog.Time("run_at", job.RunAt),
+			slog.Any("error", err),
+		)
+	}
+
+	if err := w.repos.Jobs.Update(job); err != nil {
+		slog.Error("Failed to record task outcome", slog.Uint64("job_id", uint64(job.ID)), slog.Any("error", err))
+	}
+}
+
+// backoff returns the delay before retrying after the given number of
+// attempts: BaseBackoff, doubled per further attempt, capped at MaxBackoff
+func (w *Worker) backoff(attempts int) time.Duration {
+	delay := w.opts.BaseBackoff
+	for i := 1; i < attempts && delay < w.opts.MaxBackoff; i++ {
+		delay *= 2
 	}
+	return min(delay, w.opts.MaxBackoff)
 }
@@ -0,0 +1,265 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository"
+	"github.com/user/go-todo-api/internal/repository/memory"
+)
+
+type greetPayload struct {
+	Name string `json:"name"`
+}
+
+// newTestWorker returns a worker with a "GREET" task whose behaviour is
+// chosen per payload name
+func newTestWorker(t *testing.T, opts Options) (*Worker, *[]string) {
+	t.Helper()
+	w := New(memory.NewRepositories(), opts)
+	var greeted []string
+	var mu sync.Mutex
+	w.Register("GREET", TypedHandler(50*time.Millisecond, func(ctx context.Context, p greetPayload) error {
+		switch p.Name {
+		case "fail":
+			return errors.New("greeting failed")
+		case "panic":
+			panic("greeting panicked")
+		case "hang":
+			<-make(chan struct{})
+		case "slow":
+			if err := sleep(ctx, 20*time.Millisecond); err != nil {
+				return err
+			}
+		}
+		mu.Lock()
+		greeted = append(greeted, p.Name)
+		mu.Unlock()
+		return nil
+	}))
+	return w, &greeted
+}
+
+// enqueue adds a task and returns its job ID, relying on the in-memory
+// queue numbering jobs from 1
+func enqueue(t *testing.T, w *Worker, task Task) uint {
+	t.Helper()
+	require.NoError(t, w.Enqueue(task))
+	for id := uint(1); ; id++ {
+		if _, err := w.repos.Jobs.FindByID(id + 1); err != nil {
+			return id
+		}
+	}
+}
+
+func findJob(t *testing.T, w *Worker, id uint) *models.Job {
+	t.Helper()
+	job, err := w.repos.Jobs.FindByID(id)
+	require.NoError(t, err)
+	return job
+}
+
+func TestBackoff(t *testing.T) {
+	w := New(memory.NewRepositories(), Options{BaseBackoff: time.Second, MaxBackoff: 5 * time.Second})
+	var delays []time.Duration
+	for attempts := 1; attempts <= 5; attempts++ {
+		delays = append(delays, w.backoff(attempts))
+	}
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}, delays)
+}
+
+func TestEnqueueRejectsUnknownTypes(t *testing.T) {
+	w, _ := newTestWorker(t, Options{})
+	err := w.Enqueue(Task{Type: "NO_SUCH_TASK"})
+	assert.ErrorIs(t, err, ErrUnknownTaskType)
+	assert.False(t, w.runNext())
+}
+
+func TestTaskRunsTypedHandler(t *testing.T) {
+	w, greeted := newTestWorker(t, Options{})
+	id := enqueue(t, w, Task{Type: "GREET", Payload: greetPayload{Name: "alice"}})
+
+	assert.True(t, w.runNext())
+	assert.Equal(t, []string{"alice"}, *greeted)
+	assert.Equal(t, models.JobDone, findJob(t, w, id).Status)
+}
+
+func TestTaskRetriesUntilDead(t *testing.T) {
+	w, _ := newTestWorker(t, Options{MaxAttempts: 2})
+	id := enqueue(t, w, Task{Type: "GREET", Payload: greetPayload{Name: "fail"}})
+
+	assert.True(t, w.runNext())
+	job := findJob(t, w, id)
+	assert.Equal(t, models.JobPending, job.Status)
+	assert.Equal(t, 1, job.Attempts)
+	assert.Equal(t, "greeting failed", job.LastError)
+	assert.True(t, job.RunAt.After(time.Now()), "retry is backed off")
+	assert.False(t, w.runNext(), "nothing is due before the backoff ends")
+
+	job.RunAt = time.Now().UTC()
+	require.NoError(t, w.repos.Jobs.Update(job))
+	assert.True(t, w.runNext())
+	job = findJob(t, w, id)
+	assert.Equal(t, models.JobDead, job.Status)
+	assert.Equal(t, 2, job.Attempts)
+}
+
+func TestTaskPanicsAndTimeoutsFailTheAttempt(t *testing.T) {
+	w, greeted := newTestWorker(t, Options{})
+	panicked := enqueue(t, w, Task{Type: "GREET", Payload: greetPayload{Name: "panic"}})
+	hung := enqueue(t, w, Task{Type: "GREET", Payload: greetPayload{Name: "hang"}})
+	after := enqueue(t, w, Task{Type: "GREET", Payload: greetPayload{Name: "bob"}})
+
+	for w.runNext() {
+	}
+	assert.Contains(t, findJob(t, w, panicked).LastError, "task panicked: greeting panicked")
+	assert.Contains(t, findJob(t, w, hung).LastError, "timed out")
+	assert.Equal(t, models.JobPending, findJob(t, w, hung).Status)
+	assert.Equal(t, models.JobDone, findJob(t, w, after).Status)
+	assert.Equal(t, []string{"bob"}, *greeted)
+}
+
+func TestMalformedPayloadIsDeadAtOnce(t *testing.T) {
+	w, _ := newTestWorker(t, Options{})
+	id := enqueue(t, w, Task{Type: "GREET", Payload: map[string]int{"name": 42}})
+
+	assert.True(t, w.runNext())
+	job := findJob(t, w, id)
+	assert.Equal(t, models.JobDead, job.Status)
+	assert.Equal(t, 1, job.Attempts)
+}
+
+func TestScheduledTask(t *testing.T) {
+	w, greeted := newTestWorker(t, Options{})
+	require.NoError(t, w.Enqueue(Task{
+		Type:    "GREET",
+		Payload: greetPayload{Name: "later"},
+		RunAt:   time.Now().Add(time.Hour),
+	}))
+	assert.False(t, w.runNext())
+	assert.Empty(t, *greeted)
+}
+
+func TestStopDrainsRunningTasks(t *testing.T) {
+	w, greeted := newTestWorker(t, Options{Concurrency: 2})
+	first := enqueue(t, w, Task{Type: "GREET", Payload: greetPayload{Name: "slow"}})
+	second := enqueue(t, w, Task{Type: "GREET", Payload: greetPayload{Name: "slow"}})
+	w.Start()
+
+	require.Eventually(t, func() bool {
+		return findJob(t, w, first).Status != models.JobPending && findJob(t, w, second).Status != models.JobPending
+	}, time.Second, time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, w.Stop(ctx))
+
+	assert.Equal(t, []string{"slow", "slow"}, *greeted)
+	assert.Equal(t, models.JobDone, findJob(t, w, first).Status)
+	assert.Equal(t, models.JobDone, findJob(t, w, second).Status)
+
+	// Tasks enqueued after Stop wait for the next start
+	later := enqueue(t, w, Task{Type: "GREET", Payload: greetPayload{Name: "later"}})
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, models.JobPending, findJob(t, w, later).Status)
+}
+
+func TestStopRequeuesInterruptedTasks(t *testing.T) {
+	w, _ := newTestWorker(t, Options{})
+	w.Register("WAIT", TypedHandler(time.Minute, func(ctx context.Context, p greetPayload) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	id := enqueue(t, w, Task{Type: "WAIT", Payload: greetPayload{}})
+	w.Start()
+
+	require.Eventually(t, func() bool { return findJob(t, w, id).Status == models.JobRunning }, time.Second, time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, w.Stop(ctx), context.DeadlineExceeded)
+
+	job := findJob(t, w, id)
+	assert.Equal(t, models.JobPending, job.Status)
+	assert.Zero(t, job.Attempts, "an interrupted attempt does not count")
+	assert.Nil(t, job.LockedAt)
+}
+
+// sleep waits for d, or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestPurgeTrash(t *testing.T) {
+	w := New(memory.NewRepositories(), Options{TrashRetention: time.Hour})
+	todo := &models.Todo{Title: "Deleted", UserID: 1}
+	require.NoError(t, w.repos.Todos.Create(todo))
+	require.NoError(t, w.repos.Todos.Delete(todo.ID, 1))
+
+	// Within the retention period the todo can still be restored
+	w.purgeTrash()
+	_, err := w.repos.Todos.FindDeletedByIDAndUserID(todo.ID, 1)
+	require.NoError(t, err)
+
+	w.opts.TrashRetention = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	w.purgeTrash()
+	_, err = w.repos.Todos.FindDeletedByIDAndUserID(todo.ID, 1)
+	assert.Error(t, err)
+}
+
+func TestDeleteFinishedJobs(t *testing.T) {
+	w := New(memory.NewRepositories(), Options{JobRetention: time.Hour})
+	done := &models.Job{Type: "DONE", Payload: "{}", Status: models.JobDone}
+	dead := &models.Job{Type: "DEAD", Payload: "{}", Status: models.JobDead}
+	require.NoError(t, w.repos.Jobs.Create(done))
+	require.NoError(t, w.repos.Jobs.Create(dead))
+
+	// Within the retention period the task is kept
+	w.deleteFinishedJobs()
+	_, err := w.repos.Jobs.FindByID(done.ID)
+	require.NoError(t, err)
+
+	w.opts.JobRetention = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	w.deleteFinishedJobs()
+	_, err = w.repos.Jobs.FindByID(done.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	_, err = w.repos.Jobs.FindByID(dead.ID)
+	assert.NoError(t, err)
+}
+
+func TestArchiveCompleted(t *testing.T) {
+	w := New(memory.NewRepositories(), Options{})
+	done := &models.Todo{Title: "Done", Status: models.StatusCompleted, UserID: 1}
+	open := &models.Todo{Title: "Open", UserID: 1}
+	require.NoError(t, w.repos.Todos.Create(done))
+	require.NoError(t, w.repos.Todos.Create(open))
+	time.Sleep(time.Millisecond)
+
+	// Auto-archiving is off unless configured
+	w.archiveCompleted()
+	found, err := w.repos.Todos.FindByID(done.ID)
+	require.NoError(t, err)
+	assert.False(t, found.Archived)
+
+	w.opts.ArchiveCompletedAfter = time.Nanosecond
+	w.archiveCompleted()
+	found, err = w.repos.Todos.FindByID(done.ID)
+	require.NoError(t, err)
+	assert.True(t, found.Archived)
+	found, err = w.repos.Todos.FindByID(open.ID)
+	require.NoError(t, err)
+	assert.False(t, found.Archived)
+}
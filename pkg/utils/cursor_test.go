@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/user/go-todo-api/internal/config"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	config.AppConfig = &config.Config{JWTSecret: "test-secret"}
+
+	type position struct {
+		SortBy string `json:"s"`
+		ID     uint   `json:"i"`
+	}
+
+	token, err := EncodeCursor(position{SortBy: "title", ID: 42})
+	assert.NoError(t, err)
+
+	var decoded position
+	assert.NoError(t, DecodeCursor(token, &decoded))
+	assert.Equal(t, position{SortBy: "title", ID: 42}, decoded)
+}
+
+func TestCursorRejectsTampering(t *testing.T) {
+	config.AppConfig = &config.Config{JWTSecret: "test-secret"}
+
+	token, _ := EncodeCursor(map[string]int{"i": 1})
+	forged, _ := EncodeCursor(map[string]int{"i": 2})
+
+	var decoded map[string]int
+	// Payload of one cursor with the signature of another
+	payload, _, _ := strings.Cut(forged, ".")
+	_, signature, _ := strings.Cut(token, ".")
+	assert.ErrorIs(t, DecodeCursor(payload+"."+signature, &decoded), ErrInvalidCursor)
+	assert.ErrorIs(t, DecodeCursor("not-a-cursor", &decoded), ErrInvalidCursor)
+
+	config.AppConfig = &config.Config{JWTSecret: "other-secret"}
+	assert.ErrorIs(t, DecodeCursor(token, &decoded), ErrInvalidCursor)
+}
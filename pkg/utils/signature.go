@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+// SignWebhook returns the signature of a webhook body sent at timestamp
+// (Unix seconds): "sha256=" followed by the hex HMAC-SHA256, keyed with
+// secret, of the timestamp, a dot and the body. Covering the timestamp
+// lets receivers reject replayed deliveries.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhook checks a signature made by SignWebhook and that timestamp
+// is within tolerance of now
+func VerifyWebhook(secret, signature string, timestamp int64, body []byte, tolerance time.Duration, now time.Time) bool {
+	age := now.Sub(time.Unix(timestamp, 0))
+	if age > tolerance || age < -tolerance {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(SignWebhook(secret, timestamp, body)))
+}
@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignWebhook(t *testing.T) {
+	body := []byte(`{"type":"ping"}`)
+
+	// Receivers reproduce it as HMAC-SHA256(secret, "1700000000." + body)
+	assert.Equal(t, "sha256=bc08c591847b765241711bcbe7067e3869a219e424d3fdd9d00b3b6f915baf97", SignWebhook("whsec_test", 1700000000, body))
+}
+
+func TestVerifyWebhook(t *testing.T) {
+	body := []byte(`{"type":"ping"}`)
+	now := time.Unix(1700000000, 0)
+	signature := SignWebhook("whsec_test", now.Unix(), body)
+
+	assert.True(t, VerifyWebhook("whsec_test", signature, now.Unix(), body, 5*time.Minute, now.Add(time.Minute)))
+	assert.False(t, VerifyWebhook("whsec_other", signature, now.Unix(), body, 5*time.Minute, now))
+	assert.False(t, VerifyWebhook("whsec_test", signature, now.Unix(), []byte(`{"type":"pong"}`), 5*time.Minute, now))
+	assert.False(t, VerifyWebhook("whsec_test", signature, now.Unix(), body, 5*time.Minute, now.Add(time.Hour)))
+}
@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/go-todo-api/internal/handlers"
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository"
+)
+
+func TestTodoArchiveAndPin(t *testing.T) {
+	forEachBackend(t, testTodoArchiveAndPin)
+}
+
+func testTodoArchiveAndPin(t *testing.T, repos repository.Repositories) {
+	handler := handlers.NewTodoHandler(repos.Todos, repos.Tags, repos.Projects, repos.Series)
+
+	router := newTestRouter()
+	router.GET("/todos", handler.GetAll)
+	router.POST("/todos", handler.Create)
+	router.POST("/todos/:id/archive", handler.Archive)
+	router.POST("/todos/:id/unarchive", handler.Unarchive)
+	router.POST("/todos/:id/pin", handler.Pin)
+	router.POST("/todos/:id/unpin", handler.Unpin)
+	api := testClient{t, router, createTestUser(t, repos, "alice")}
+
+	list := func(query string) []string {
+		var data struct {
+			Todos []models.TodoResponse `json:"todos"`
+		}
+		require.Equal(t, http.StatusOK, api.do("GET", "/todos"+query, nil, &data).Code)
+		var titles []string
+		for _, todo := range data.Todos {
+			titles = append(titles, todo.Title)
+		}
+		return titles
+	}
+
+	ids := make(map[string]uint)
+	var todo models.TodoResponse
+	for _, title := range []string{"Alpha", "Bravo", "Charlie"} {
+		require.Equal(t, http.StatusCreated, api.do("POST", "/todos", map[string]interface{}{"title": title}, &todo).Code)
+		ids[title] = todo.ID
+	}
+	path := func(title, action string) string {
+		return fmt.Sprintf("/todos/%d/%s", ids[title], action)
+	}
+	assert.Equal(t, []string{"Alpha", "Bravo", "Charlie"}, list("?sort_by=title&sort_dir=ASC"))
+
+	// Pinned todos lead whatever the ordering
+	w := api.do("POST", path("Charlie", "pin"), nil, &todo)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, todo.Pinned)
+	assert.EqualValues(t, 2, todo.Version)
+	assert.NotEmpty(t, w.Header().Get("ETag"))
+	assert.Equal(t, []string{"Charlie", "Alpha", "Bravo"}, list("?sort_by=title&sort_dir=ASC"))
+	assert.Equal(t, []string{"Charlie", "Bravo", "Alpha"}, list("?sort_by=title&sort_dir=DESC"))
+
+	// Pinning twice changes nothing
+	api.do("POST", path("Charlie", "pin"), nil, &todo)
+	assert.EqualValues(t, 2, todo.Version)
+
+	require.Equal(t, http.StatusOK, api.do("POST", path("Charlie", "unpin"), nil, &todo).Code)
+	assert.False(t, todo.Pinned)
+	assert.Equal(t, []string{"Alpha", "Bravo", "Charlie"}, list("?sort_by=title&sort_dir=ASC"))
+
+	// Archived todos are only listed on request
+	require.Equal(t, http.StatusOK, api.do("POST", path("Bravo", "archive"), nil, &todo).Code)
+	assert.True(t, todo.Archived)
+	assert.Equal(t, []string{"Alpha", "Charlie"}, list("?sort_by=title&sort_dir=ASC"))
+	assert.Equal(t, []string{"Alpha", "Bravo", "Charlie"}, list("?sort_by=title&sort_dir=ASC&include_archived=true"))
+	assert.Equal(t, http.StatusBadRequest, api.do("GET", "/todos?include_archived=maybe", nil, nil).Code)
+
+	// A stale ETag is refused
+	w = api.do("POST", path("Bravo", "unarchive"), nil, nil, "If-Match", `"1-000000000000"`)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	require.Equal(t, http.StatusOK, api.do("POST", path("Bravo", "unarchive"), nil, &todo).Code)
+	assert.False(t, todo.Archived)
+	assert.Equal(t, []string{"Alpha", "Bravo", "Charlie"}, list("?sort_by=title&sort_dir=ASC"))
+
+	assert.Equal(t, http.StatusNotFound, api.do("POST", "/todos/999/archive", nil, nil).Code)
+}
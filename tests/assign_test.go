@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/go-todo-api/internal/handlers"
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository"
+)
+
+func TestAssignTodos(t *testing.T) {
+	forEachBackend(t, testAssignTodos)
+}
+
+func testAssignTodos(t *testing.T, repos repository.Repositories) {
+	todoHandler := handlers.NewTodoHandler(repos.Todos, repos.Tags, repos.Projects, repos.Series)
+	alice, bob, carol := createTestUser(t, repos, "alice"), createTestUser(t, repos, "bob"), createTestUser(t, repos, "carol")
+
+	router := newTestRouter()
+	router.GET("/todos", todoHandler.GetAll)
+	router.POST("/todos/:id/assign", todoHandler.Assign)
+	router.POST("/todos/:id/unassign", todoHandler.Unassign)
+	api := testClient{t: t, router: router}
+
+	assignedTo := func(user models.User) []string {
+		var data struct {
+			Todos []models.TodoResponse `json:"todos"`
+		}
+		require.Equal(t, http.StatusOK, api.as(user).do("GET", "/todos?assigned_to_me=true&sort_by=title&sort_dir=ASC", nil, &data).Code)
+		var titles []string
+		for _, todo := range data.Todos {
+			titles = append(titles, todo.Title)
+		}
+		return titles
+	}
+
+	work := &models.Project{UserID: alice.ID, Name: "Work"}
+	require.NoError(t, repos.Projects.Create(work))
+	require.NoError(t, repos.Projects.AddMember(&models.ProjectMember{ProjectID: work.ID, UserID: bob.ID, Role: models.RoleViewer}))
+	report := &models.Todo{Title: "Write report", UserID: alice.ID, ProjectID: &work.ID}
+	require.NoError(t, repos.Todos.Create(report))
+	private := &models.Todo{Title: "Read a book", UserID: alice.ID}
+	require.NoError(t, repos.Todos.Create(private))
+
+	assignPath := fmt.Sprintf("/todos/%d/assign", report.ID)
+
+	// Todos can be assigned to their creator and to project members
+	var todo models.TodoResponse
+	require.Equal(t, http.StatusOK, api.as(alice).do("POST", assignPath, map[string]interface{}{"user_id": bob.ID}, &todo).Code)
+	require.NotNil(t, todo.AssigneeID)
+	assert.Equal(t, bob.ID, *todo.AssigneeID)
+	assert.Equal(t, []string{"Write report"}, assignedTo(bob))
+	assert.Empty(t, assignedTo(alice))
+
+	require.Equal(t, http.StatusOK, api.as(alice).do("POST", fmt.Sprintf("/todos/%d/assign", private.ID), map[string]interface{}{"user_id": alice.ID}, nil).Code)
+	assert.Equal(t, []string{"Read a book"}, assignedTo(alice))
+
+	// Nobody else can be assigned, and viewers cannot assign
+	assert.Equal(t, http.StatusBadRequest, api.as(alice).do("POST", assignPath, map[string]interface{}{"user_id": carol.ID}, nil).Code)
+	assert.Equal(t, http.StatusBadRequest, api.as(alice).do("POST", fmt.Sprintf("/todos/%d/assign", private.ID), map[string]interface{}{"user_id": bob.ID}, nil).Code)
+	assert.Equal(t, http.StatusBadRequest, api.as(alice).do("POST", assignPath, map[string]interface{}{}, nil).Code)
+	assert.Equal(t, http.StatusForbidden, api.as(bob).do("POST", assignPath, map[string]interface{}{"user_id": alice.ID}, nil).Code)
+	assert.Equal(t, http.StatusNotFound, api.as(carol).do("POST", assignPath, map[string]interface{}{"user_id": carol.ID}, nil).Code)
+
+	// Reassigning replaces the assignee
+	require.Equal(t, http.StatusOK, api.as(alice).do("POST", assignPath, map[string]interface{}{"user_id": alice.ID}, &todo).Code)
+	assert.Equal(t, alice.ID, *todo.AssigneeID)
+	assert.Empty(t, assignedTo(bob))
+	assert.Equal(t, []string{"Read a book", "Write report"}, assignedTo(alice))
+
+	var unassigned models.TodoResponse
+	require.Equal(t, http.StatusOK, api.as(alice).do("POST", fmt.Sprintf("/todos/%d/unassign", report.ID), nil, &unassigned).Code)
+	assert.Nil(t, unassigned.AssigneeID)
+	assert.Equal(t, []string{"Read a book"}, assignedTo(alice))
+	assert.Equal(t, http.StatusBadRequest, api.as(alice).do("GET", "/todos?assigned_to_me=maybe", nil, nil).Code)
+}
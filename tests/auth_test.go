@@ -40,7 +40,7 @@ func TestRegister(t *testing.T) {
 
 	// Register route
 	router := gin.New()
-	authHandler := handlers.NewAuthHandler()
+	authHandler := handlers.NewAuthHandler(testRepos.Users, testRepos.Tokens)
 	router.POST("/register", authHandler.Register)
 
 	for _, tc := range tests {
@@ -62,7 +62,7 @@ func TestLogin(t *testing.T) {
 
 	// Setup router and handler
 	router := gin.New()
-	authHandler := handlers.NewAuthHandler()
+	authHandler := handlers.NewAuthHandler(testRepos.Users, testRepos.Tokens)
 	router.POST("/login", authHandler.Login)
 
 	// Create a user first (manually or via endpoint) for login test
@@ -94,3 +94,49 @@ func TestLogin(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateProfile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	user := &models.User{Email: "profile@example.com", Password: "hash", Name: "Profile User"}
+	if err := testRepos.Users.Create(user); err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	authHandler := handlers.NewAuthHandler(testRepos.Users, testRepos.Tokens)
+	router.PUT("/profile", func(c *gin.Context) {
+		c.Set("userID", user.ID)
+	}, authHandler.UpdateProfile)
+
+	tests := []struct {
+		name           string
+		payload        map[string]string
+		expectedStatus int
+	}{
+		{"Timezone", map[string]string{"timezone": "America/New_York"}, http.StatusOK},
+		{"Quiet Hours", map[string]string{"quiet_hours_start": "22:00", "quiet_hours_end": "07:00"}, http.StatusOK},
+		{"Unknown Timezone", map[string]string{"timezone": "Mars/Olympus_Mons"}, http.StatusBadRequest},
+		{"Half Quiet Hours", map[string]string{"quiet_hours_start": "22:00"}, http.StatusBadRequest},
+		{"Malformed Quiet Hours", map[string]string{"quiet_hours_start": "10pm", "quiet_hours_end": "07:00"}, http.StatusBadRequest},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			body, _ := json.Marshal(tc.payload)
+			req, _ := http.NewRequest("PUT", "/profile", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+
+	found, err := testRepos.Users.FindByID(user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "America/New_York", found.Timezone)
+	assert.Equal(t, "22:00", found.QuietHoursStart)
+	assert.Equal(t, "07:00", found.QuietHoursEnd)
+}
@@ -0,0 +1,107 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/go-todo-api/internal/handlers"
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository"
+)
+
+func TestBulkTodos(t *testing.T) {
+	// The SQL run checks that the transaction does not wait on itself for
+	// the single connection of the in-memory test database
+	forEachBackend(t, testBulkTodos)
+}
+
+func testBulkTodos(t *testing.T, repos repository.Repositories) {
+	handler := handlers.NewTodoHandler(repos.Todos, repos.Tags, repos.Projects, repos.Series)
+	router := newTestRouter()
+	router.POST("/todos/bulk", handler.Bulk)
+	user := createTestUser(t, repos, "alice")
+	api := testClient{t, router, user}
+
+	count := func() int {
+		todos, err := repos.Todos.FindAllByUserID(user.ID)
+		require.NoError(t, err)
+		return len(todos)
+	}
+
+	var resp models.BulkTodoResponse
+	code := api.do("POST", "/todos/bulk", map[string]interface{}{"operations": []interface{}{}}, &resp).Code
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	due := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	code = api.do("POST", "/todos/bulk", map[string]interface{}{"operations": []map[string]interface{}{
+		{"op": "create", "todo": map[string]interface{}{"title": "First", "due_date": due}},
+		{"op": "create", "todo": map[string]interface{}{"title": "Second"}},
+		{"op": "create", "todo": map[string]interface{}{"title": ""}},
+		{"op": "create", "todo": map[string]interface{}{"title": "Weekly", "due_date": due, "rrule": "FREQ=WEEKLY"}},
+		{"op": "explode", "id": 1},
+	}}, &resp).Code
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 2, resp.Succeeded)
+	assert.Equal(t, 3, resp.Failed)
+	require.Len(t, resp.Results, 5)
+	for i, result := range resp.Results {
+		assert.Equal(t, i, result.Index)
+	}
+	assert.Equal(t, models.BulkOK, resp.Results[0].Result)
+	require.NotNil(t, resp.Results[0].Todo)
+	assert.Equal(t, "First", resp.Results[0].Todo.Title)
+	for _, result := range resp.Results[2:] {
+		assert.Equal(t, models.BulkFailed, result.Result)
+		assert.NotEmpty(t, result.Error)
+	}
+	first, second := resp.Results[0].ID, resp.Results[1].ID
+	assert.Equal(t, 2, count())
+
+	// Non-atomic batches keep what succeeded
+	code = api.do("POST", "/todos/bulk", map[string]interface{}{"operations": []map[string]interface{}{
+		{"op": "update_status", "id": first, "status": "completed"},
+		{"op": "shift_due_date", "id": first, "minutes": 1440},
+		{"op": "shift_due_date", "id": second, "minutes": 60},
+		{"op": "update_status", "id": second, "status": "done"},
+		{"op": "delete", "id": 9999},
+	}}, &resp).Code
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 2, resp.Succeeded)
+	assert.Equal(t, "Todo has no due date to shift", resp.Results[2].Error)
+	assert.Equal(t, "Todo not found", resp.Results[4].Error)
+
+	todo, err := repos.Todos.FindByIDAndUserID(first, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusCompleted, todo.Status)
+	require.NotNil(t, todo.DueDate)
+	assert.True(t, due.Add(24*time.Hour).Equal(*todo.DueDate))
+
+	// Atomic batches apply nothing when one operation fails
+	code = api.do("POST", "/todos/bulk", map[string]interface{}{"atomic": true, "operations": []map[string]interface{}{
+		{"op": "create", "todo": map[string]interface{}{"title": "Third"}},
+		{"op": "delete", "id": first},
+		{"op": "delete", "id": 9999},
+	}}, &resp).Code
+	require.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, 0, resp.Succeeded)
+	assert.Equal(t, 1, resp.Failed)
+	assert.Equal(t, models.BulkRolledBack, resp.Results[0].Result)
+	assert.Zero(t, resp.Results[0].ID)
+	assert.Nil(t, resp.Results[0].Todo)
+	assert.Equal(t, models.BulkRolledBack, resp.Results[1].Result)
+	assert.Equal(t, models.BulkFailed, resp.Results[2].Result)
+	assert.Equal(t, 2, count())
+	_, err = repos.Todos.FindByIDAndUserID(first, user.ID)
+	assert.NoError(t, err)
+
+	code = api.do("POST", "/todos/bulk", map[string]interface{}{"atomic": true, "operations": []map[string]interface{}{
+		{"op": "delete", "id": first},
+		{"op": "delete", "id": second},
+	}}, &resp).Code
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 2, resp.Succeeded)
+	assert.Equal(t, 0, count())
+}
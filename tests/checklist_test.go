@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/go-todo-api/internal/events"
+	"github.com/user/go-todo-api/internal/handlers"
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository"
+)
+
+func TestChecklistItems(t *testing.T) {
+	forEachBackend(t, testChecklistItems)
+}
+
+func testChecklistItems(t *testing.T, repos repository.Repositories) {
+	todoHandler := handlers.NewTodoHandler(repos.Todos, repos.Tags, repos.Projects, repos.Series)
+	checklistHandler := handlers.NewChecklistHandler(repos.Todos, repos.Projects, repos.Items, repos.Series)
+
+	router := newTestRouter()
+	router.POST("/todos", todoHandler.Create)
+	router.GET("/todos/:id/items", checklistHandler.GetAll)
+	router.POST("/todos/:id/items", checklistHandler.Create)
+	router.PUT("/todos/:id/items/order", checklistHandler.Reorder)
+	router.POST("/todos/:id/items/:item_id/toggle", checklistHandler.Toggle)
+	router.DELETE("/todos/:id/items/:item_id", checklistHandler.Delete)
+	api := testClient{t, router, createTestUser(t, repos, "alice")}
+
+	var received []string
+	unsubscribe := events.Default.Subscribe(func(e events.Event) {
+		received = append(received, e.Type)
+	})
+	defer unsubscribe()
+
+	var todo map[string]interface{}
+	require.Equal(t, http.StatusCreated, api.do("POST", "/todos", map[string]interface{}{"title": "Move house", "auto_complete": true}, &todo).Code)
+	assert.Equal(t, map[string]interface{}{"done": float64(0), "total": float64(0)}, todo["progress"])
+	base := fmt.Sprintf("/todos/%v/items", todo["id"])
+
+	var itemIDs []uint
+	for _, title := range []string{"Pack", "Load van", "Unpack"} {
+		require.Equal(t, http.StatusCreated, api.do("POST", base, map[string]interface{}{"title": title}, &todo).Code)
+		items := todo["items"].([]interface{})
+		itemIDs = append(itemIDs, uint(items[len(items)-1].(map[string]interface{})["id"].(float64)))
+	}
+	assert.Equal(t, http.StatusBadRequest, api.do("POST", base, map[string]interface{}{"title": ""}, nil).Code)
+
+	// Reordering must name every item exactly once
+	w := api.do("PUT", base+"/order", map[string]interface{}{"item_ids": []uint{itemIDs[2], itemIDs[0]}}, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = api.do("PUT", base+"/order", map[string]interface{}{"item_ids": []uint{itemIDs[2], itemIDs[0], itemIDs[1]}}, &todo)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "Unpack", todo["items"].([]interface{})[0].(map[string]interface{})["title"])
+
+	require.Equal(t, http.StatusOK, api.do("POST", fmt.Sprintf("%s/%d/toggle", base, itemIDs[0]), nil, &todo).Code)
+	assert.Equal(t, map[string]interface{}{"done": float64(1), "total": float64(3)}, todo["progress"])
+	assert.Equal(t, "pending", todo["status"])
+
+	api.do("POST", fmt.Sprintf("%s/%d/toggle", base, itemIDs[1]), nil, nil)
+	assert.Equal(t, http.StatusNotFound, api.do("POST", fmt.Sprintf("%s/999/toggle", base), nil, nil).Code)
+
+	// Deleting the last open item finishes the checklist and completes the todo
+	require.Equal(t, http.StatusOK, api.do("DELETE", fmt.Sprintf("%s/%d", base, itemIDs[2]), nil, &todo).Code)
+	assert.Equal(t, map[string]interface{}{"done": float64(2), "total": float64(2)}, todo["progress"])
+	assert.Equal(t, "completed", todo["status"])
+
+	// Each successful change publishes the todo: three additions, the
+	// reorder, two toggles and the deletion that completes it
+	want := append([]string{models.EventTodoCreated}, slices.Repeat([]string{models.EventTodoUpdated}, 7)...)
+	assert.Equal(t, append(want, models.EventTodoCompleted), received)
+
+	assert.Equal(t, http.StatusNotFound, api.do("GET", "/todos/999/items", nil, nil).Code)
+}
@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/go-todo-api/internal/handlers"
+	"github.com/user/go-todo-api/internal/middleware"
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository"
+)
+
+func TestIdempotencyKey(t *testing.T) {
+	forEachBackend(t, testIdempotencyKey)
+}
+
+func testIdempotencyKey(t *testing.T, repos repository.Repositories) {
+	handler := handlers.NewTodoHandler(repos.Todos, repos.Tags, repos.Projects, repos.Series)
+	release := make(chan struct{})
+	failures := 0
+
+	router := newTestRouter()
+	router.Use(middleware.IdempotencyMiddleware(repos.Idempotency, time.Hour))
+	router.POST("/todos", handler.Create)
+	router.POST("/slow", func(c *gin.Context) {
+		<-release
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+	router.POST("/flaky", func(c *gin.Context) {
+		failures++
+		if failures == 1 {
+			c.JSON(http.StatusInternalServerError, gin.H{"ok": false})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"ok": true})
+	})
+	alice, bob := createTestUser(t, repos, "alice"), createTestUser(t, repos, "bob")
+	api := testClient{t, router, alice}
+
+	post := func(path, key string, payload interface{}) *httptest.ResponseRecorder {
+		return api.do("POST", path, payload, nil, middleware.IdempotencyHeader, key)
+	}
+	todoID := func(w *httptest.ResponseRecorder) uint {
+		var todo models.TodoResponse
+		decodeData(t, w, &todo)
+		return todo.ID
+	}
+	count := func(user models.User) int {
+		todos, err := repos.Todos.FindAllByUserID(user.ID)
+		require.NoError(t, err)
+		return len(todos)
+	}
+
+	// A retry gets the first response back and creates nothing
+	first := post("/todos", "create-1", map[string]string{"title": "Once"})
+	require.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get("Idempotent-Replayed"))
+	retry := post("/todos", "create-1", map[string]string{"title": "Once"})
+	require.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, first.Header().Get("ETag"), retry.Header().Get("ETag"))
+	assert.Equal(t, todoID(first), todoID(retry))
+	assert.Equal(t, 1, count(alice))
+
+	// The same key with a different body is rejected
+	w := post("/todos", "create-1", map[string]string{"title": "Twice"})
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, 1, count(alice))
+
+	// Keys belong to a user, and requests without one always run
+	w = api.as(bob).do("POST", "/todos", map[string]string{"title": "Once"}, nil, middleware.IdempotencyHeader, "create-1")
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Empty(t, w.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, 1, count(bob))
+	post("/todos", "", map[string]string{"title": "Again"})
+	post("/todos", "", map[string]string{"title": "Again"})
+	assert.Equal(t, 3, count(alice))
+
+	w = post("/todos", string(bytes.Repeat([]byte("k"), 256)), map[string]string{"title": "Long"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// A retry while the first request is still running is turned away
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- post("/slow", "slow-1", nil) }()
+	require.Eventually(t, func() bool {
+		_, err := repos.Idempotency.Find(alice.ID, "POST", "/slow", "slow-1")
+		return err == nil
+	}, time.Second, 5*time.Millisecond)
+	w = post("/slow", "slow-1", nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	close(release)
+	assert.Equal(t, http.StatusOK, (<-done).Code)
+	assert.Equal(t, "true", post("/slow", "slow-1", nil).Header().Get("Idempotent-Replayed"))
+
+	// Server errors are not kept, so the retry runs again
+	assert.Equal(t, http.StatusInternalServerError, post("/flaky", "flaky-1", nil).Code)
+	w = post("/flaky", "flaky-1", nil)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Empty(t, w.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, 2, failures)
+}
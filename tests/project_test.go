@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/go-todo-api/internal/handlers"
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository"
+)
+
+func TestProjects(t *testing.T) {
+	forEachBackend(t, testProjects)
+}
+
+func testProjects(t *testing.T, repos repository.Repositories) {
+	projectHandler := handlers.NewProjectHandler(repos.Projects, repos.Todos, repos.Users)
+	todoHandler := handlers.NewTodoHandler(repos.Todos, repos.Tags, repos.Projects, repos.Series)
+
+	router := newTestRouter()
+	router.GET("/projects", projectHandler.GetAll)
+	router.PUT("/projects/order", projectHandler.Reorder)
+	router.GET("/projects/:id", projectHandler.GetByID)
+	router.POST("/projects", projectHandler.Create)
+	router.PUT("/projects/:id", projectHandler.Update)
+	router.DELETE("/projects/:id", projectHandler.Delete)
+	router.GET("/todos", todoHandler.GetAll)
+	router.POST("/todos", todoHandler.Create)
+	router.PATCH("/todos/:id", todoHandler.Patch)
+	api := testClient{t, router, createTestUser(t, repos, "alice")}
+
+	listProjects := func(query string) []string {
+		var projects []models.ProjectResponse
+		require.Equal(t, http.StatusOK, api.do("GET", "/projects"+query, nil, &projects).Code)
+		var names []string
+		for _, project := range projects {
+			names = append(names, project.Name)
+		}
+		return names
+	}
+	listTodos := func(query string) []string {
+		var data struct {
+			Todos []models.TodoResponse `json:"todos"`
+		}
+		require.Equal(t, http.StatusOK, api.do("GET", "/todos?sort_by=title&sort_dir=ASC&"+query, nil, &data).Code)
+		var titles []string
+		for _, todo := range data.Todos {
+			titles = append(titles, todo.Title)
+		}
+		return titles
+	}
+
+	var work, home models.ProjectResponse
+	require.Equal(t, http.StatusCreated, api.do("POST", "/projects", map[string]interface{}{"name": "Work"}, &work).Code)
+	assert.Equal(t, models.DefaultProjectColor, work.Color)
+	require.Equal(t, http.StatusCreated, api.do("POST", "/projects", map[string]interface{}{"name": "Home", "color": "#22c55e"}, &home).Code)
+	assert.Equal(t, 1, home.Position)
+
+	assert.Equal(t, http.StatusConflict, api.do("POST", "/projects", map[string]interface{}{"name": "Work"}, nil).Code)
+	assert.Equal(t, http.StatusBadRequest, api.do("POST", "/projects", map[string]interface{}{"name": "Bad", "color": "red"}, nil).Code)
+
+	// Todos are put in a project on creation and moved with PATCH
+	var report, plants models.TodoResponse
+	require.Equal(t, http.StatusCreated, api.do("POST", "/todos", map[string]interface{}{"title": "Write report", "project_id": work.ID}, &report).Code)
+	require.NotNil(t, report.ProjectID)
+	assert.Equal(t, work.ID, *report.ProjectID)
+	require.Equal(t, http.StatusCreated, api.do("POST", "/todos", map[string]interface{}{"title": "Water plants", "project_id": work.ID, "status": "completed"}, &plants).Code)
+	require.Equal(t, http.StatusCreated, api.do("POST", "/todos", map[string]interface{}{"title": "Read a book"}, nil).Code)
+	assert.Equal(t, http.StatusBadRequest, api.do("POST", "/todos", map[string]interface{}{"title": "Lost", "project_id": 999}, nil).Code)
+
+	require.Equal(t, http.StatusOK, api.do("PATCH", fmt.Sprintf("/todos/%d", plants.ID), map[string]interface{}{"project_id": home.ID}, &plants).Code)
+	assert.Equal(t, home.ID, *plants.ProjectID)
+	assert.Equal(t, http.StatusBadRequest, api.do("PATCH", fmt.Sprintf("/todos/%d", plants.ID), map[string]interface{}{"project_id": 999}, nil).Code)
+
+	assert.Equal(t, []string{"Write report"}, listTodos(fmt.Sprintf("project_id=%d", work.ID)))
+	assert.Equal(t, []string{"Water plants"}, listTodos(fmt.Sprintf("project_id=%d", home.ID)))
+	assert.Equal(t, []string{"Read a book"}, listTodos("project_id=none"))
+	assert.Equal(t, http.StatusBadRequest, api.do("GET", "/todos?project_id=abc", nil, nil).Code)
+
+	// Counts by status come with every project
+	var found models.ProjectResponse
+	require.Equal(t, http.StatusOK, api.do("GET", fmt.Sprintf("/projects/%d", home.ID), nil, &found).Code)
+	assert.Equal(t, models.StatusCounts{Completed: 1, Total: 1}, found.Counts)
+	var projects []models.ProjectResponse
+	require.Equal(t, http.StatusOK, api.do("GET", "/projects", nil, &projects).Code)
+	require.Len(t, projects, 2)
+	assert.Equal(t, models.StatusCounts{Pending: 1, Total: 1}, projects[0].Counts)
+
+	// Reordering must list every project exactly once
+	assert.Equal(t, http.StatusBadRequest, api.do("PUT", "/projects/order", map[string]interface{}{"project_ids": []uint{home.ID}}, nil).Code)
+	assert.Equal(t, http.StatusBadRequest, api.do("PUT", "/projects/order", map[string]interface{}{"project_ids": []uint{home.ID, home.ID}}, nil).Code)
+	require.Equal(t, http.StatusOK, api.do("PUT", "/projects/order", map[string]interface{}{"project_ids": []uint{home.ID, work.ID}}, nil).Code)
+	assert.Equal(t, []string{"Home", "Work"}, listProjects(""))
+
+	// Archived projects are only listed on request
+	require.Equal(t, http.StatusOK, api.do("PUT", fmt.Sprintf("/projects/%d", home.ID), map[string]interface{}{"archived": true, "description": "Chores"}, &found).Code)
+	assert.True(t, found.Archived)
+	assert.Equal(t, "Chores", found.Description)
+	assert.Equal(t, "Home", found.Name)
+	assert.Equal(t, []string{"Work"}, listProjects(""))
+	assert.Equal(t, []string{"Home", "Work"}, listProjects("?include_archived=true"))
+	assert.Equal(t, http.StatusConflict, api.do("PUT", fmt.Sprintf("/projects/%d", home.ID), map[string]interface{}{"name": "Work"}, nil).Code)
+
+	// Deleting a project keeps its todos
+	require.Equal(t, http.StatusOK, api.do("DELETE", fmt.Sprintf("/projects/%d", work.ID), nil, nil).Code)
+	assert.Equal(t, http.StatusNotFound, api.do("GET", fmt.Sprintf("/projects/%d", work.ID), nil, nil).Code)
+	assert.Equal(t, []string{"Read a book", "Write report"}, listTodos("project_id=none"))
+	assert.Equal(t, http.StatusNotFound, api.do("DELETE", fmt.Sprintf("/projects/%d", work.ID), nil, nil).Code)
+}
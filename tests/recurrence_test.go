@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/go-todo-api/internal/handlers"
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository"
+)
+
+func TestRecurringTodos(t *testing.T) {
+	forEachBackend(t, testRecurringTodos)
+}
+
+func testRecurringTodos(t *testing.T, repos repository.Repositories) {
+	todoHandler := handlers.NewTodoHandler(repos.Todos, repos.Tags, repos.Projects, repos.Series)
+
+	router := newTestRouter()
+	router.POST("/todos", todoHandler.Create)
+	router.GET("/todos/:id", todoHandler.GetByID)
+	router.PATCH("/todos/:id", todoHandler.Patch)
+	router.GET("/todos/:id/occurrences", todoHandler.Occurrences)
+	user := createTestUser(t, repos, "alice")
+	api := testClient{t, router, user}
+
+	var todo map[string]interface{}
+	w := api.do("POST", "/todos", map[string]interface{}{"title": "Standup", "rrule": "FREQ=WEEKLY"}, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "a recurring todo needs a due date")
+	w = api.do("POST", "/todos", map[string]interface{}{"title": "Standup", "due_date": "2026-03-02T09:00:00Z", "rrule": "FREQ=HOURLY"}, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Mondays and Thursdays, four times in total
+	w = api.do("POST", "/todos", map[string]interface{}{
+		"title":    "Standup",
+		"due_date": "2026-03-02T09:00:00Z",
+		"rrule":    "freq=weekly;byday=MO,TH;count=4",
+	}, &todo)
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO,TH;COUNT=4", todo["rrule"])
+	first := fmt.Sprintf("/todos/%v", todo["id"])
+
+	var preview map[string]interface{}
+	require.Equal(t, http.StatusOK, api.do("GET", first+"/occurrences?n=5", nil, &preview).Code)
+	assert.Equal(t, []interface{}{"2026-03-05T09:00:00Z", "2026-03-09T09:00:00Z", "2026-03-12T09:00:00Z"}, preview["occurrences"])
+	assert.Equal(t, http.StatusBadRequest, api.do("GET", first+"/occurrences?n=0", nil, nil).Code)
+
+	// Editing the series needs scope=future; scope=this changes one instance
+	assert.Equal(t, http.StatusBadRequest, api.do("PATCH", first, map[string]interface{}{"rrule": "FREQ=DAILY"}, nil).Code)
+	assert.Equal(t, http.StatusBadRequest, api.do("PATCH", first+"?scope=all", map[string]interface{}{"title": "Sync"}, nil).Code)
+	require.Equal(t, http.StatusOK, api.do("PATCH", first, map[string]interface{}{"title": "Standup (moved)"}, nil).Code)
+
+	// Completing an instance creates the next one from the template, once
+	require.Equal(t, http.StatusOK, api.do("PATCH", first, map[string]interface{}{"status": "completed"}, nil).Code)
+	api.do("PATCH", first, map[string]interface{}{"status": "completed"}, nil)
+	all, err := repos.Todos.FindAllByUserID(user.ID)
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	next := all[0]
+	assert.Equal(t, "Standup", next.Title)
+	assert.Equal(t, "pending", string(next.Status))
+	assert.Equal(t, "2026-03-05T09:00:00Z", next.DueDate.UTC().Format("2006-01-02T15:04:05Z"))
+
+	// A future-scoped edit updates the template for later instances
+	second := fmt.Sprintf("/todos/%d", next.ID)
+
+	// A future-scoped edit that loses a race with another writer leaves the
+	// series alone
+	racing := newTestRouter()
+	racing.PATCH("/todos/:id", handlers.NewTodoHandler(conflictingTodos{repos.Todos}, repos.Tags, repos.Projects, repos.Series).Patch)
+	w = testClient{t, racing, user}.do("PATCH", second+"?scope=future", map[string]interface{}{"title": "Stale sync"}, nil)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	series, err := repos.Series.FindByIDAndUserID(*next.SeriesID, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Standup", series.Title)
+
+	require.Equal(t, http.StatusOK, api.do("PATCH", second+"?scope=future", map[string]interface{}{"title": "Daily sync"}, nil).Code)
+	api.do("PATCH", second, map[string]interface{}{"status": "completed"}, nil)
+	all, _ = repos.Todos.FindAllByUserID(user.ID)
+	require.Len(t, all, 3)
+	assert.Equal(t, "Daily sync", all[0].Title)
+	assert.Equal(t, "2026-03-09T09:00:00Z", all[0].DueDate.UTC().Format("2006-01-02T15:04:05Z"))
+
+	// Stopping the series leaves a plain todo
+	third := fmt.Sprintf("/todos/%d", all[0].ID)
+	assert.Equal(t, http.StatusBadRequest, api.do("PATCH", third, map[string]interface{}{"rrule": ""}, nil).Code)
+	require.Equal(t, http.StatusOK, api.do("PATCH", third+"?scope=future", map[string]interface{}{"rrule": ""}, &todo).Code)
+	assert.Nil(t, todo["rrule"])
+	assert.Equal(t, http.StatusBadRequest, api.do("GET", third+"/occurrences", nil, nil).Code)
+	api.do("PATCH", third, map[string]interface{}{"status": "completed"}, nil)
+	all, _ = repos.Todos.FindAllByUserID(user.ID)
+	assert.Len(t, all, 3)
+}
+
+// conflictingTodos fails every update as if another writer got there first
+type conflictingTodos struct {
+	repository.TodoRepository
+}
+
+func (conflictingTodos) Update(*models.Todo) error {
+	return repository.ErrConflict
+}
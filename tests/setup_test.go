@@ -1,51 +1,209 @@
 package tests
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
-	"github.com/glebarez/sqlite"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
 	"github.com/user/go-todo-api/internal/config"
 	"github.com/user/go-todo-api/internal/database"
+	"github.com/user/go-todo-api/internal/migrate"
 	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository"
+	"github.com/user/go-todo-api/internal/repository/memory"
 	"github.com/user/go-todo-api/internal/worker"
 	"gorm.io/gorm"
 )
 
+// testRepos are the GORM repositories backed by the test database
+var testRepos repository.Repositories
+
 func TestMain(m *testing.M) {
 	// Setup test database
 	setupTestDB()
 
 	// Initialize worker
-	worker.InitWorker()
+	worker.InitWorker(testRepos, worker.Options{})
 
 	// Run tests
 	code := m.Run()
 
-	// Cleanup
+	// Cleanup: interrupt leftover tasks before the database goes away
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	worker.GlobalWorker.Stop(ctx)
+	cancel()
 	cleanupTestDB()
 
 	os.Exit(code)
 }
 
+// setupTestDB connects to the backend selected by DB_DRIVER (in-memory SQLite
+// by default, or the Postgres database described by the DB_* variables) and
+// recreates the schema so every run starts from empty tables.
 func setupTestDB() {
-	// Use in-memory SQLite for tests
-	config.AppConfig = &config.Config{
-		DBPath:         ":memory:",
-		JWTSecret:      "test-secret",
-		JWTExpiryHours: 1,
+	cfg := config.Load()
+	cfg.JWTSecret = "test-secret"
+	cfg.JWTExpiryHours = 1
+	if cfg.DBDriver == database.DriverSQLite {
+		cfg.DBPath = ":memory:"
 	}
+	config.AppConfig = cfg
 
 	var err error
-	database.DB, err = gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	database.DB, err = openTestDB()
 	if err != nil {
-		panic("failed to connect to test database")
+		panic("failed to set up test database: " + err.Error())
 	}
 
-	// Auto migrate
-	database.DB.AutoMigrate(&models.User{}, &models.Todo{}, &models.RefreshToken{})
+	testRepos = repository.NewRepositories(database.DB)
+}
+
+// openTestDB connects to the test database and migrates it from scratch
+func openTestDB() (*gorm.DB, error) {
+	db, err := database.Open(config.AppConfig, &gorm.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("connect: %w", err)
+	}
+
+	m, err := migrate.New(db)
+	if err != nil {
+		return nil, fmt.Errorf("load migrations: %w", err)
+	}
+	if _, err := m.To(0); err != nil {
+		return nil, fmt.Errorf("reset: %w", err)
+	}
+	if _, err := m.Up(); err != nil {
+		return nil, fmt.Errorf("migrate: %w", err)
+	}
+	return db, nil
 }
 
 func cleanupTestDB() {
-	// Close DB connection if possible, but in-memory cleans up on exit
+	if sqlDB, err := database.DB.DB(); err == nil {
+		sqlDB.Close()
+	}
+}
+
+// forEachBackend runs test once against the in-memory repositories and once
+// against the GORM repositories on an empty database, so the endpoints are
+// checked with the behaviour of both.
+func forEachBackend(t *testing.T, test func(t *testing.T, repos repository.Repositories)) {
+	t.Run("Memory", func(t *testing.T) {
+		test(t, memory.NewRepositories())
+	})
+	t.Run("SQL", func(t *testing.T) {
+		test(t, emptySQLRepos(t))
+	})
+}
+
+// emptySQLRepos returns GORM repositories with no rows and IDs starting from
+// one. SQLite gets a database of its own with foreign keys enforced, as
+// Postgres does; the Postgres test database is emptied instead.
+func emptySQLRepos(t *testing.T) repository.Repositories {
+	t.Helper()
+	if config.AppConfig.DBDriver == database.DriverPostgres {
+		var tables []string
+		require.NoError(t, database.DB.Raw(
+			"SELECT tablename FROM pg_tables WHERE schemaname = current_schema() AND tablename <> ?",
+			"schema_migrations",
+		).Scan(&tables).Error)
+		require.NoError(t, database.DB.Exec("TRUNCATE "+strings.Join(tables, ", ")+" RESTART IDENTITY CASCADE").Error)
+		return testRepos
+	}
+
+	db, err := openTestDB()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	require.NoError(t, db.Exec("PRAGMA foreign_keys = ON").Error)
+	return repository.NewRepositories(db)
+}
+
+// createTestUser registers a user called name
+func createTestUser(t *testing.T, repos repository.Repositories, name string) models.User {
+	t.Helper()
+	user := models.User{Email: name + "@example.com", Password: "password123", Name: name}
+	require.NoError(t, repos.Users.Create(&user))
+	return user
+}
+
+// newTestRouter returns a router that makes each request as the user in the
+// X-User-ID header, standing in for the auth middleware
+func newTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		id, _ := strconv.ParseUint(c.GetHeader("X-User-ID"), 10, 32)
+		c.Set("userID", uint(id))
+	})
+	return router
+}
+
+// testClient makes requests to a test router as one user
+type testClient struct {
+	t      *testing.T
+	router http.Handler
+	user   models.User
+}
+
+// as returns a client that makes its requests as user
+func (c testClient) as(user models.User) testClient {
+	c.user = user
+	return c
+}
+
+// do sends a request and decodes the data field of the response into data
+// unless it is nil. A string payload is sent as it is and anything else is
+// encoded as JSON; headers are name, value pairs and may replace the JSON
+// Content-Type.
+func (c testClient) do(method, path string, payload, data interface{}, headers ...string) *httptest.ResponseRecorder {
+	c.t.Helper()
+	var body bytes.Buffer
+	switch payload := payload.(type) {
+	case nil:
+	case string:
+		body.WriteString(payload)
+	default:
+		require.NoError(c.t, json.NewEncoder(&body).Encode(payload))
+	}
+	req := httptest.NewRequest(method, path, &body)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User-ID", strconv.FormatUint(uint64(c.user.ID), 10))
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	c.router.ServeHTTP(w, req)
+
+	if data != nil {
+		decodeData(c.t, w, data)
+	}
+	return w
+}
+
+// decodeData decodes the data field of a JSON response into data, starting
+// from its zero value so a reused map holds only this response. Responses
+// without a JSON body leave it zero.
+func decodeData(t *testing.T, w *httptest.ResponseRecorder, data interface{}) {
+	t.Helper()
+	value := reflect.ValueOf(data).Elem()
+	value.Set(reflect.Zero(value.Type()))
+	resp := struct {
+		Data interface{} `json:"data"`
+	}{Data: data}
+	json.Unmarshal(w.Body.Bytes(), &resp)
 }
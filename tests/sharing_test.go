@@ -0,0 +1,205 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/go-todo-api/internal/events"
+	"github.com/user/go-todo-api/internal/handlers"
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository"
+)
+
+func TestProjectSharing(t *testing.T) {
+	forEachBackend(t, testProjectSharing)
+}
+
+func testProjectSharing(t *testing.T, repos repository.Repositories) {
+	projectHandler := handlers.NewProjectHandler(repos.Projects, repos.Todos, repos.Users)
+	todoHandler := handlers.NewTodoHandler(repos.Todos, repos.Tags, repos.Projects, repos.Series)
+	checklistHandler := handlers.NewChecklistHandler(repos.Todos, repos.Projects, repos.Items, repos.Series)
+	alice, bob := createTestUser(t, repos, "alice"), createTestUser(t, repos, "bob")
+	createTestUser(t, repos, "carol")
+
+	router := newTestRouter()
+	router.GET("/projects", projectHandler.GetAll)
+	router.POST("/projects", projectHandler.Create)
+	router.GET("/projects/:id", projectHandler.GetByID)
+	router.PUT("/projects/:id", projectHandler.Update)
+	router.DELETE("/projects/:id", projectHandler.Delete)
+	router.GET("/projects/:id/members", projectHandler.GetMembers)
+	router.POST("/projects/:id/members", projectHandler.AddMember)
+	router.PUT("/projects/:id/members/:user_id", projectHandler.UpdateMember)
+	router.DELETE("/projects/:id/members/:user_id", projectHandler.RemoveMember)
+	router.GET("/todos", todoHandler.GetAll)
+	router.GET("/todos/:id", todoHandler.GetByID)
+	router.POST("/todos", todoHandler.Create)
+	router.PATCH("/todos/:id", todoHandler.Patch)
+	router.DELETE("/todos/:id", todoHandler.Delete)
+	router.POST("/todos/:id/items", checklistHandler.Create)
+	api := testClient{t: t, router: router}
+
+	listTodos := func(user models.User) []string {
+		var data struct {
+			Todos []models.TodoResponse `json:"todos"`
+		}
+		require.Equal(t, http.StatusOK, api.as(user).do("GET", "/todos?sort_by=title&sort_dir=ASC", nil, &data).Code)
+		var titles []string
+		for _, todo := range data.Todos {
+			titles = append(titles, todo.Title)
+		}
+		return titles
+	}
+
+	var work models.ProjectResponse
+	require.Equal(t, http.StatusCreated, api.as(alice).do("POST", "/projects", map[string]interface{}{"name": "Work"}, &work).Code)
+	assert.Equal(t, models.RoleOwner, work.Role)
+	var report models.TodoResponse
+	require.Equal(t, http.StatusCreated, api.as(alice).do("POST", "/todos", map[string]interface{}{"title": "Write report", "project_id": work.ID}, &report).Code)
+	require.Equal(t, http.StatusCreated, api.as(alice).do("POST", "/todos", map[string]interface{}{"title": "Read a book"}, nil).Code)
+
+	projectPath := fmt.Sprintf("/projects/%d", work.ID)
+	todoPath := fmt.Sprintf("/todos/%d", report.ID)
+
+	// Before sharing, the project and its todos do not exist for bob
+	assert.Equal(t, http.StatusNotFound, api.as(bob).do("GET", projectPath, nil, nil).Code)
+	assert.Equal(t, http.StatusNotFound, api.as(bob).do("GET", todoPath, nil, nil).Code)
+	assert.Equal(t, http.StatusNotFound, api.as(bob).do("POST", projectPath+"/members", map[string]interface{}{"email": "bob@example.com", "role": "owner"}, nil).Code)
+
+	// Only registered users can be invited, once, with a known role
+	var members []models.ProjectMemberResponse
+	assert.Equal(t, http.StatusNotFound, api.as(alice).do("POST", projectPath+"/members", map[string]interface{}{"email": "nobody@example.com", "role": "viewer"}, nil).Code)
+	assert.Equal(t, http.StatusBadRequest, api.as(alice).do("POST", projectPath+"/members", map[string]interface{}{"email": "bob@example.com", "role": "admin"}, nil).Code)
+	assert.Equal(t, http.StatusConflict, api.as(alice).do("POST", projectPath+"/members", map[string]interface{}{"email": "alice@example.com", "role": "viewer"}, nil).Code)
+	require.Equal(t, http.StatusCreated, api.as(alice).do("POST", projectPath+"/members", map[string]interface{}{"email": "bob@example.com", "role": "viewer"}, &members).Code)
+	assert.Equal(t, http.StatusConflict, api.as(alice).do("POST", projectPath+"/members", map[string]interface{}{"email": "bob@example.com", "role": "editor"}, nil).Code)
+	require.Len(t, members, 2)
+	assert.True(t, members[0].Creator)
+	assert.Equal(t, alice.ID, members[0].UserID)
+	assert.Equal(t, models.RoleOwner, members[0].Role)
+	assert.Equal(t, "bob@example.com", members[1].Email)
+	assert.Equal(t, models.RoleViewer, members[1].Role)
+
+	// Viewers read the project and its todos but change nothing
+	var found models.ProjectResponse
+	require.Equal(t, http.StatusOK, api.as(bob).do("GET", projectPath, nil, &found).Code)
+	assert.Equal(t, models.RoleViewer, found.Role)
+	assert.Equal(t, alice.ID, found.OwnerID)
+	assert.Equal(t, models.StatusCounts{Pending: 1, Total: 1}, found.Counts)
+	assert.Equal(t, []string{"Write report"}, listTodos(bob))
+	assert.Equal(t, http.StatusOK, api.as(bob).do("GET", todoPath, nil, nil).Code)
+	assert.Equal(t, http.StatusForbidden, api.as(bob).do("PATCH", todoPath, map[string]interface{}{"status": "completed"}, nil).Code)
+	assert.Equal(t, http.StatusForbidden, api.as(bob).do("DELETE", todoPath, nil, nil).Code)
+	assert.Equal(t, http.StatusForbidden, api.as(bob).do("POST", todoPath+"/items", map[string]interface{}{"title": "Outline"}, nil).Code)
+	assert.Equal(t, http.StatusForbidden, api.as(bob).do("POST", "/todos", map[string]interface{}{"title": "Sneak in", "project_id": work.ID}, nil).Code)
+	assert.Equal(t, http.StatusForbidden, api.as(bob).do("PUT", projectPath, map[string]interface{}{"name": "Mine"}, nil).Code)
+	assert.Equal(t, http.StatusForbidden, api.as(bob).do("POST", projectPath+"/members", map[string]interface{}{"email": "carol@example.com", "role": "viewer"}, nil).Code)
+
+	// Editors change the project's todos and add their own
+	memberPath := fmt.Sprintf("%s/members/%d", projectPath, bob.ID)
+	require.Equal(t, http.StatusOK, api.as(alice).do("PUT", memberPath, map[string]interface{}{"role": "editor"}, &members).Code)
+	assert.Equal(t, models.RoleEditor, members[1].Role)
+	assert.Equal(t, http.StatusOK, api.as(bob).do("PATCH", todoPath, map[string]interface{}{"status": "in_progress"}, nil).Code)
+	assert.Equal(t, http.StatusCreated, api.as(bob).do("POST", todoPath+"/items", map[string]interface{}{"title": "Outline"}, nil).Code)
+	var review models.TodoResponse
+	require.Equal(t, http.StatusCreated, api.as(bob).do("POST", "/todos", map[string]interface{}{"title": "Review report", "project_id": work.ID}, &review).Code)
+	reviewPath := fmt.Sprintf("/todos/%d", review.ID)
+	assert.Equal(t, []string{"Review report", "Write report"}, listTodos(alice)[1:])
+	assert.Equal(t, http.StatusForbidden, api.as(bob).do("DELETE", todoPath+"?permanent=true", nil, nil).Code)
+	assert.Equal(t, http.StatusForbidden, api.as(bob).do("DELETE", projectPath, nil, nil).Code)
+
+	// Shared projects are listed after the user's own, with their role
+	require.Equal(t, http.StatusCreated, api.as(bob).do("POST", "/projects", map[string]interface{}{"name": "Home"}, nil).Code)
+	var projects []models.ProjectResponse
+	require.Equal(t, http.StatusOK, api.as(bob).do("GET", "/projects", nil, &projects).Code)
+	require.Len(t, projects, 2)
+	assert.Equal(t, "Home", projects[0].Name)
+	assert.Equal(t, "Work", projects[1].Name)
+	assert.Equal(t, models.RoleEditor, projects[1].Role)
+	assert.Equal(t, models.StatusCounts{Pending: 1, InProgress: 1, Total: 2}, projects[1].Counts)
+
+	// Members hold their project role on the todos they added too, so
+	// once demoted they can no longer change them
+	require.Equal(t, http.StatusOK, api.as(alice).do("PUT", memberPath, map[string]interface{}{"role": "viewer"}, nil).Code)
+	assert.Equal(t, http.StatusOK, api.as(bob).do("GET", reviewPath, nil, nil).Code)
+	assert.Equal(t, http.StatusForbidden, api.as(bob).do("PATCH", reviewPath, map[string]interface{}{"status": "completed"}, nil).Code)
+	assert.Equal(t, http.StatusForbidden, api.as(bob).do("DELETE", reviewPath, nil, nil).Code)
+	require.Equal(t, http.StatusOK, api.as(alice).do("PUT", memberPath, map[string]interface{}{"role": "editor"}, nil).Code)
+
+	// The creator cannot be changed or removed
+	creatorPath := fmt.Sprintf("%s/members/%d", projectPath, alice.ID)
+	assert.Equal(t, http.StatusBadRequest, api.as(alice).do("PUT", creatorPath, map[string]interface{}{"role": "viewer"}, nil).Code)
+	assert.Equal(t, http.StatusBadRequest, api.as(alice).do("DELETE", creatorPath, nil, nil).Code)
+
+	// Members may leave; the todos they added stay in the project, out of
+	// their reach
+	require.Equal(t, http.StatusOK, api.as(bob).do("DELETE", memberPath, nil, nil).Code)
+	assert.Equal(t, http.StatusNotFound, api.as(bob).do("GET", projectPath, nil, nil).Code)
+	assert.Equal(t, http.StatusNotFound, api.as(bob).do("GET", todoPath, nil, nil).Code)
+	assert.Equal(t, http.StatusNotFound, api.as(bob).do("PATCH", reviewPath, map[string]interface{}{"title": "Mine now"}, nil).Code)
+	assert.Empty(t, listTodos(bob))
+	assert.Equal(t, []string{"Read a book", "Review report", "Write report"}, listTodos(alice))
+	assert.Equal(t, http.StatusNotFound, api.as(alice).do("DELETE", memberPath, nil, nil).Code)
+
+	// Only an unknown email is reported as such; a failed lookup is not
+	failing := newTestRouter()
+	failing.POST("/projects/:id/members", handlers.NewProjectHandler(repos.Projects, repos.Todos, failingUsers{repos.Users}).AddMember)
+	w := testClient{t, failing, alice}.do("POST", projectPath+"/members", map[string]interface{}{"email": "bob@example.com", "role": "viewer"}, nil)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+// failingUsers fails every lookup by email as if the database were down
+type failingUsers struct {
+	repository.UserRepository
+}
+
+func (failingUsers) FindByEmail(string) (*models.User, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestSharedTodoEvents(t *testing.T) {
+	forEachBackend(t, testSharedTodoEvents)
+}
+
+func testSharedTodoEvents(t *testing.T, repos repository.Repositories) {
+	todoHandler := handlers.NewTodoHandler(repos.Todos, repos.Tags, repos.Projects, repos.Series)
+	alice, bob := createTestUser(t, repos, "alice"), createTestUser(t, repos, "bob")
+	carol, dave := createTestUser(t, repos, "carol"), createTestUser(t, repos, "dave")
+
+	work := &models.Project{UserID: alice.ID, Name: "Work"}
+	require.NoError(t, repos.Projects.Create(work))
+	require.NoError(t, repos.Projects.AddMember(&models.ProjectMember{ProjectID: work.ID, UserID: bob.ID, Role: models.RoleEditor}))
+	require.NoError(t, repos.Projects.AddMember(&models.ProjectMember{ProjectID: work.ID, UserID: carol.ID, Role: models.RoleViewer}))
+
+	// Each user's stream and webhooks see the events published for them
+	received := make(map[uint][]string)
+	unsubscribe := events.Default.Subscribe(func(e events.Event) {
+		received[e.UserID] = append(received[e.UserID], e.Type)
+	})
+	defer unsubscribe()
+
+	router := newTestRouter()
+	router.POST("/todos", todoHandler.Create)
+	router.PATCH("/todos/:id", todoHandler.Patch)
+	api := testClient{t: t, router: router}
+
+	// Changes to a project's todos reach its owner and every member,
+	// whoever made them
+	var report models.TodoResponse
+	require.Equal(t, http.StatusCreated, api.as(bob).do("POST", "/todos", map[string]interface{}{"title": "Write report", "project_id": work.ID}, &report).Code)
+	require.Equal(t, http.StatusOK, api.as(alice).do("PATCH", fmt.Sprintf("/todos/%d", report.ID), map[string]interface{}{"status": "completed"}, nil).Code)
+	want := []string{models.EventTodoCreated, models.EventTodoUpdated, models.EventTodoCompleted}
+	assert.Equal(t, want, received[alice.ID])
+	assert.Equal(t, want, received[bob.ID])
+	assert.Equal(t, want, received[carol.ID])
+	assert.Empty(t, received[dave.ID])
+
+	// Todos outside projects stay with their owner
+	clear(received)
+	require.Equal(t, http.StatusCreated, api.as(carol).do("POST", "/todos", map[string]interface{}{"title": "Read a book"}, nil).Code)
+	assert.Equal(t, map[uint][]string{carol.ID: {models.EventTodoCreated}}, received)
+}
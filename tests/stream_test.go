@@ -0,0 +1,194 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/go-todo-api/internal/events"
+	"github.com/user/go-todo-api/internal/handlers"
+	"github.com/user/go-todo-api/internal/middleware"
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/pkg/utils"
+	"golang.org/x/net/websocket"
+)
+
+// sseEvent is one parsed Server-Sent Event
+type sseEvent struct {
+	id, event, data string
+}
+
+// readSSE returns the next event from an SSE stream, skipping comments
+func readSSE(t *testing.T, r *bufio.Reader) sseEvent {
+	t.Helper()
+	var e sseEvent
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && e.event != "":
+			return e
+		case strings.HasPrefix(line, "id: "):
+			e.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			e.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			e.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestEventStream(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	broker := events.NewBroker(10)
+	tickets := middleware.NewStreamTickets(time.Minute)
+	handler := handlers.NewStreamHandler(broker, tickets)
+	router := gin.New()
+	router.POST("/stream/ticket", middleware.AuthMiddleware(), handler.Ticket)
+	stream := router.Group("/stream", middleware.StreamAuthMiddleware(tickets))
+	stream.GET("", handler.SSE)
+	stream.GET("/ws", handler.WebSocket)
+
+	// Streams must outlive the server's timeouts
+	server := httptest.NewUnstartedServer(router)
+	server.Config.ReadTimeout = 100 * time.Millisecond
+	server.Config.WriteTimeout = 100 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	token, err := utils.GenerateToken(7, "stream@example.com")
+	require.NoError(t, err)
+
+	// Browsers open streams with a ticket issued for their JWT
+	ticket := func() string {
+		req, _ := http.NewRequest("POST", server.URL+"/stream/ticket", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		var body struct {
+			Data models.StreamTicketResponse `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		return body.Data.Ticket
+	}
+	open := func(lastEventID string) (*http.Response, *bufio.Reader) {
+		req, _ := http.NewRequest("GET", server.URL+"/stream?ticket="+ticket(), nil)
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp, bufio.NewReader(resp.Body)
+	}
+
+	resp, err := http.Get(server.URL + "/stream")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// The JWT itself is not accepted in the URL, and tickets work once
+	for _, query := range []string{"access_token=" + token, "ticket=unknown"} {
+		resp, err = http.Get(server.URL + "/stream?" + query)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, query)
+	}
+	used := ticket()
+	resp, err = http.Get(server.URL + "/stream/ws?ticket=" + used)
+	require.NoError(t, err)
+	resp.Body.Close()
+	resp, err = http.Get(server.URL + "/stream/ws?ticket=" + used)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp, r := open("")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	time.Sleep(300 * time.Millisecond)
+	broker.Publish(events.New("todo.created", 8, "someone else's"))
+	first := events.New("todo.created", 7, map[string]string{"title": "Live"})
+	broker.Publish(first)
+	got := readSSE(t, r)
+	assert.Equal(t, first.ID, got.id)
+	assert.Equal(t, "todo.created", got.event)
+	assert.Contains(t, got.data, `"title":"Live"`)
+
+	// Reconnecting with Last-Event-ID replays what was missed
+	second := events.New("todo.deleted", 7, nil)
+	broker.Publish(second)
+	_, r = open(first.ID)
+	assert.Equal(t, second.ID, readSSE(t, r).id)
+
+	_, r = open("evt_forgotten")
+	assert.Equal(t, "reset", readSSE(t, r).event)
+
+	// The WebSocket carries the same events as JSON messages
+	ws, err := websocket.Dial(strings.Replace(server.URL, "http", "ws", 1)+"/stream/ws?last_event_id="+first.ID+"&ticket="+ticket(), "", server.URL)
+	require.NoError(t, err)
+	defer ws.Close()
+	var msg struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+	}
+	require.NoError(t, websocket.JSON.Receive(ws, &msg))
+	assert.Equal(t, second.ID, msg.ID)
+	third := events.New("todo.updated", 7, nil)
+	broker.Publish(third)
+	require.NoError(t, websocket.JSON.Receive(ws, &msg))
+	assert.Equal(t, "todo.updated", msg.Type)
+
+	// Closing the broker ends the streams
+	broker.Close()
+	_, err = r.ReadString('\n')
+	for err == nil {
+		_, err = r.ReadString('\n')
+	}
+	assert.Error(t, websocket.JSON.Receive(ws, &msg))
+}
+
+func TestStreamTicketsExpire(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tickets := middleware.NewStreamTickets(time.Millisecond)
+	router := gin.New()
+	router.GET("/stream", middleware.StreamAuthMiddleware(tickets), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	ticket, _ := tickets.Issue(7, "stream@example.com")
+	time.Sleep(5 * time.Millisecond)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/stream?ticket="+ticket, nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestRequestLogRedactsCredentials(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var logs bytes.Buffer
+	router := gin.New()
+	router.Use(middleware.RequestLogger(&logs))
+	router.GET("/stream", middleware.StreamAuthMiddleware(middleware.NewStreamTickets(time.Minute)), func(c *gin.Context) {})
+
+	for _, query := range []string{"access_token=secret-jwt&last_event_id=evt_1", "ticket=secret-ticket"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/stream?"+query, nil))
+	}
+	assert.NotContains(t, logs.String(), "secret")
+	assert.Contains(t, logs.String(), "/stream?access_token=REDACTED&last_event_id=evt_1")
+	assert.Contains(t, logs.String(), "/stream?ticket=REDACTED")
+}
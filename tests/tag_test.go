@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/go-todo-api/internal/handlers"
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository"
+)
+
+func TestTagsAndTagFilters(t *testing.T) {
+	forEachBackend(t, testTagsAndTagFilters)
+}
+
+func testTagsAndTagFilters(t *testing.T, repos repository.Repositories) {
+	tagHandler := handlers.NewTagHandler(repos.Tags)
+	todoHandler := handlers.NewTodoHandler(repos.Todos, repos.Tags, repos.Projects, repos.Series)
+
+	router := newTestRouter()
+	router.GET("/tags", tagHandler.GetAll)
+	router.POST("/tags", tagHandler.Create)
+	router.PUT("/tags/:id", tagHandler.Update)
+	router.DELETE("/tags/:id", tagHandler.Delete)
+	router.GET("/todos", todoHandler.GetAll)
+	router.POST("/todos", todoHandler.Create)
+	router.PATCH("/todos/:id", todoHandler.Patch)
+	api := testClient{t, router, createTestUser(t, repos, "alice")}
+
+	createTag := func(name string) uint {
+		var tag models.TagResponse
+		require.Equal(t, http.StatusCreated, api.do("POST", "/tags", map[string]interface{}{"name": name, "color": "#ff0000"}, &tag).Code)
+		return tag.ID
+	}
+	listTitles := func(query string) []string {
+		var data struct {
+			Todos []models.TodoResponse `json:"todos"`
+		}
+		require.Equal(t, http.StatusOK, api.do("GET", "/todos?sort_by=title&sort_dir=ASC&"+query, nil, &data).Code)
+		var titles []string
+		for _, todo := range data.Todos {
+			titles = append(titles, todo.Title)
+		}
+		return titles
+	}
+
+	work, urgent := createTag("work"), createTag("urgent")
+
+	assert.Equal(t, http.StatusConflict, api.do("POST", "/tags", map[string]interface{}{"name": "work"}, nil).Code)
+	assert.Equal(t, http.StatusBadRequest, api.do("POST", "/tags", map[string]interface{}{"name": "blue", "color": "blue"}, nil).Code)
+
+	var todo models.TodoResponse
+	require.Equal(t, http.StatusCreated, api.do("POST", "/todos", map[string]interface{}{"title": "Ship", "tag_ids": []uint{work, urgent}}, &todo).Code)
+	assert.Len(t, todo.Tags, 2)
+	shipPath := fmt.Sprintf("/todos/%d", todo.ID)
+	api.do("POST", "/todos", map[string]interface{}{"title": "Report", "tag_ids": []uint{work}}, nil)
+	require.Equal(t, http.StatusCreated, api.do("POST", "/todos", map[string]interface{}{"title": "Read"}, &todo).Code)
+	assert.Empty(t, todo.Tags)
+
+	// Tags of other users (or that don't exist) cannot be assigned
+	assert.Equal(t, http.StatusBadRequest, api.do("POST", "/todos", map[string]interface{}{"title": "Nope", "tag_ids": []int{999}}, nil).Code)
+
+	assert.Equal(t, []string{"Report", "Ship"}, listTitles(fmt.Sprintf("tags=%d,%d", work, urgent)))
+	assert.Equal(t, []string{"Ship"}, listTitles(fmt.Sprintf("tags=%d&tags=%d&tag_mode=all", work, urgent)))
+	assert.Equal(t, http.StatusBadRequest, api.do("GET", "/todos?tags=abc", nil, nil).Code)
+	assert.Equal(t, http.StatusBadRequest, api.do("GET", "/todos?tags=1&tag_mode=some", nil, nil).Code)
+
+	// Omitting tag_ids keeps the tags; an empty list removes them
+	require.Equal(t, http.StatusOK, api.do("PATCH", shipPath, map[string]interface{}{"status": "completed"}, &todo).Code)
+	assert.Len(t, todo.Tags, 2)
+	require.Equal(t, http.StatusOK, api.do("PATCH", shipPath, map[string]interface{}{"tag_ids": []int{}}, &todo).Code)
+	assert.Empty(t, todo.Tags)
+
+	var tag models.TagResponse
+	require.Equal(t, http.StatusOK, api.do("PUT", fmt.Sprintf("/tags/%d", work), map[string]interface{}{"name": "office"}, &tag).Code)
+	assert.Equal(t, "office", tag.Name)
+
+	assert.Equal(t, http.StatusOK, api.do("DELETE", fmt.Sprintf("/tags/%d", work), nil, nil).Code)
+	assert.Empty(t, listTitles(fmt.Sprintf("tags=%d", work)))
+
+	var tags []models.TagResponse
+	require.Equal(t, http.StatusOK, api.do("GET", "/tags", nil, &tags).Code)
+	assert.Len(t, tags, 1)
+}
@@ -3,13 +3,18 @@ package tests
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/user/go-todo-api/internal/handlers"
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository"
 )
 
 func TestCreateTodoValidation(t *testing.T) {
@@ -35,7 +40,7 @@ func TestCreateTodoValidation(t *testing.T) {
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			router := gin.New()
-			handler := handlers.NewTodoHandler()
+			handler := handlers.NewTodoHandler(testRepos.Todos, testRepos.Tags, testRepos.Projects, testRepos.Series)
 
 			// Set up mock user context
 			router.POST("/todos", func(c *gin.Context) {
@@ -59,7 +64,7 @@ func TestGetTodoInvalidID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	router := gin.New()
-	handler := handlers.NewTodoHandler()
+	handler := handlers.NewTodoHandler(testRepos.Todos, testRepos.Tags, testRepos.Projects, testRepos.Series)
 
 	router.GET("/todos/:id", func(c *gin.Context) {
 		c.Set("userID", uint(1))
@@ -72,3 +77,263 @@ func TestGetTodoInvalidID(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
+
+// TestTodoLifecycle drives the handlers end to end on every backend
+func TestTodoLifecycle(t *testing.T) {
+	forEachBackend(t, testTodoLifecycle)
+}
+
+func testTodoLifecycle(t *testing.T, repos repository.Repositories) {
+	handler := handlers.NewTodoHandler(repos.Todos, repos.Tags, repos.Projects, repos.Series)
+
+	router := newTestRouter()
+	router.GET("/todos", handler.GetAll)
+	router.GET("/todos/:id", handler.GetByID)
+	router.POST("/todos", handler.Create)
+	router.PATCH("/todos/:id", handler.Patch)
+	router.DELETE("/todos/:id", handler.Delete)
+	api := testClient{t, router, createTestUser(t, repos, "alice")}
+
+	var data map[string]interface{}
+	require.Equal(t, http.StatusCreated, api.do("POST", "/todos", map[string]interface{}{"title": "In memory"}, &data).Code)
+	path := fmt.Sprintf("/todos/%v", data["id"])
+
+	assert.Equal(t, http.StatusOK, api.do("PATCH", path, map[string]interface{}{"status": "completed"}, &data).Code)
+	assert.Equal(t, "completed", data["status"])
+
+	assert.Equal(t, http.StatusOK, api.do("GET", "/todos", nil, &data).Code)
+	assert.EqualValues(t, 1, data["total"])
+
+	assert.Equal(t, http.StatusOK, api.do("DELETE", path, nil, nil).Code)
+	assert.Equal(t, http.StatusNotFound, api.do("GET", path, nil, nil).Code)
+}
+
+func TestTodoCursorPagination(t *testing.T) {
+	forEachBackend(t, testTodoCursorPagination)
+}
+
+func testTodoCursorPagination(t *testing.T, repos repository.Repositories) {
+	handler := handlers.NewTodoHandler(repos.Todos, repos.Tags, repos.Projects, repos.Series)
+	router := newTestRouter()
+	router.GET("/todos", handler.GetAll)
+	user := createTestUser(t, repos, "alice")
+	api := testClient{t, router, user}
+	for i := 0; i < 15; i++ {
+		require.NoError(t, repos.Todos.Create(&models.Todo{Title: fmt.Sprintf("Todo %d", i), Status: models.StatusPending, UserID: user.ID}))
+	}
+
+	var first, second map[string]interface{}
+	assert.Equal(t, http.StatusOK, api.do("GET", "/todos?page_size=10&sort_by=title&sort_dir=ASC", nil, &first).Code)
+	assert.EqualValues(t, 15, first["total"])
+	assert.Nil(t, first["prev_cursor"])
+	next, ok := first["next_cursor"].(string)
+	assert.True(t, ok)
+
+	assert.Equal(t, http.StatusOK, api.do("GET", "/todos?page_size=10&cursor="+next, nil, &second).Code)
+	assert.Len(t, second["todos"], 5)
+	assert.NotContains(t, second, "total")
+	assert.Nil(t, second["next_cursor"])
+	assert.NotNil(t, second["prev_cursor"])
+
+	// Cursors are bound to the filters they were issued with and cannot be forged
+	assert.Equal(t, http.StatusBadRequest, api.do("GET", "/todos?page_size=10&status=completed&cursor="+next, nil, nil).Code)
+	assert.Equal(t, http.StatusBadRequest, api.do("GET", "/todos?cursor="+next[:len(next)-4]+"AAAA", nil, nil).Code)
+}
+
+func TestTodoPriority(t *testing.T) {
+	forEachBackend(t, testTodoPriority)
+}
+
+func testTodoPriority(t *testing.T, repos repository.Repositories) {
+	handler := handlers.NewTodoHandler(repos.Todos, repos.Tags, repos.Projects, repos.Series)
+	router := newTestRouter()
+	router.GET("/todos", handler.GetAll)
+	router.POST("/todos", handler.Create)
+	router.PATCH("/todos/:id", handler.Patch)
+	api := testClient{t, router, createTestUser(t, repos, "alice")}
+
+	titles := func(data map[string]interface{}) []string {
+		var titles []string
+		for _, todo := range data["todos"].([]interface{}) {
+			titles = append(titles, todo.(map[string]interface{})["title"].(string))
+		}
+		return titles
+	}
+
+	var data map[string]interface{}
+	assert.Equal(t, http.StatusCreated, api.do("POST", "/todos", map[string]interface{}{"title": "Default"}, &data).Code)
+	assert.Equal(t, "none", data["priority"])
+
+	assert.Equal(t, http.StatusBadRequest, api.do("POST", "/todos", map[string]interface{}{"title": "Bad", "priority": "critical"}, nil).Code)
+
+	overdue := time.Now().Add(-time.Hour)
+	api.do("POST", "/todos", map[string]interface{}{"title": "Late", "priority": "low", "due_date": overdue}, nil)
+	assert.Equal(t, http.StatusCreated, api.do("POST", "/todos", map[string]interface{}{"title": "Fire", "priority": "high"}, &data).Code)
+	assert.Equal(t, "high", data["priority"])
+
+	assert.Equal(t, http.StatusOK, api.do("PATCH", fmt.Sprintf("/todos/%v", data["id"]), map[string]interface{}{"priority": "urgent"}, &data).Code)
+	assert.Equal(t, "urgent", data["priority"])
+
+	assert.Equal(t, http.StatusOK, api.do("GET", "/todos?sort_by=smart", nil, &data).Code)
+	assert.Equal(t, []string{"Late", "Fire", "Default"}, titles(data))
+	assert.Nil(t, data["next_cursor"])
+
+	assert.Equal(t, http.StatusOK, api.do("GET", "/todos?priority_min=low&priority_max=high&sort_by=title&sort_dir=ASC", nil, &data).Code)
+	assert.Equal(t, []string{"Late"}, titles(data))
+
+	assert.Equal(t, http.StatusBadRequest, api.do("GET", "/todos?priority_min=extreme", nil, nil).Code)
+}
+
+func TestTodoReminderOffsets(t *testing.T) {
+	forEachBackend(t, testTodoReminderOffsets)
+}
+
+func testTodoReminderOffsets(t *testing.T, repos repository.Repositories) {
+	handler := handlers.NewTodoHandler(repos.Todos, repos.Tags, repos.Projects, repos.Series)
+	router := newTestRouter()
+	router.POST("/todos", handler.Create)
+	router.PATCH("/todos/:id", handler.Patch)
+	api := testClient{t, router, createTestUser(t, repos, "alice")}
+
+	var data map[string]interface{}
+	assert.Equal(t, http.StatusCreated, api.do("POST", "/todos", map[string]interface{}{"title": "Dentist", "reminder_offsets": []int{15, 1440}}, &data).Code)
+	assert.Equal(t, []interface{}{float64(1440), float64(15)}, data["reminder_offsets"])
+	path := fmt.Sprintf("/todos/%v", data["id"])
+
+	for _, bad := range [][]int{{-5}, {15, 15}, {20000}, {1, 2, 3, 4, 5, 6}} {
+		w := api.do("POST", "/todos", map[string]interface{}{"title": "Bad", "reminder_offsets": bad}, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code, bad)
+	}
+
+	assert.Equal(t, http.StatusOK, api.do("PATCH", path, map[string]interface{}{"title": "Dentist at 3"}, &data).Code)
+	assert.Len(t, data["reminder_offsets"], 2)
+
+	assert.Equal(t, http.StatusOK, api.do("PATCH", path, map[string]interface{}{"reminder_offsets": []int{}}, &data).Code)
+	assert.Equal(t, []interface{}{}, data["reminder_offsets"])
+}
+
+func TestTodoReplaceAndPatch(t *testing.T) {
+	forEachBackend(t, testTodoReplaceAndPatch)
+}
+
+func testTodoReplaceAndPatch(t *testing.T, repos repository.Repositories) {
+	handler := handlers.NewTodoHandler(repos.Todos, repos.Tags, repos.Projects, repos.Series)
+	router := newTestRouter()
+	router.POST("/todos", handler.Create)
+	router.PUT("/todos/:id", handler.Update)
+	router.PATCH("/todos/:id", handler.Patch)
+	api := testClient{t, router, createTestUser(t, repos, "alice")}
+
+	var data map[string]interface{}
+	code := api.do("POST", "/todos", `{"title": "Report", "description": "Quarterly", "priority": "high", "due_date": "2030-01-01T09:00:00Z", "reminder_offsets": [60]}`, &data, "Content-Type", "application/json").Code
+	require.Equal(t, http.StatusCreated, code)
+	path := fmt.Sprintf("/todos/%v", data["id"])
+
+	// null in a merge patch clears a field; omitted fields are kept
+	code = api.do("PATCH", path, `{"description": null, "due_date": null}`, &data, "Content-Type", "application/merge-patch+json").Code
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "", data["description"])
+	assert.NotContains(t, data, "due_date")
+	assert.Equal(t, "Report", data["title"])
+	assert.Equal(t, "high", data["priority"])
+
+	code = api.do("PATCH", path, `[{"op": "test", "path": "/priority", "value": "high"},
+		  {"op": "replace", "path": "/priority", "value": "low"},
+		  {"op": "add", "path": "/reminder_offsets/-", "value": 15},
+		  {"op": "add", "path": "/due_date", "value": "2030-02-01T09:00:00Z"}]`, &data, "Content-Type", "application/json-patch+json").Code
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "low", data["priority"])
+	assert.Equal(t, []interface{}{float64(60), float64(15)}, data["reminder_offsets"])
+	assert.Equal(t, "2030-02-01T09:00:00Z", data["due_date"])
+
+	code = api.do("PATCH", path, `[{"op": "test", "path": "/priority", "value": "high"}]`, nil, "Content-Type", "application/json-patch+json").Code
+	assert.Equal(t, http.StatusConflict, code)
+
+	for _, bad := range []struct{ contentType, patch string }{
+		{"application/merge-patch+json", `{"title": null}`},
+		{"application/merge-patch+json", `{"colour": "red"}`},
+		{"application/merge-patch+json", `{"status": "done"}`},
+		{"application/json-patch+json", `[{"op": "remove", "path": "/nope"}]`},
+		{"application/json-patch+json", `{"op": "remove", "path": "/due_date"}`},
+	} {
+		code = api.do("PATCH", path, bad.patch, nil, "Content-Type", bad.contentType).Code
+		assert.Equal(t, http.StatusBadRequest, code, bad.patch)
+	}
+	code = api.do("PATCH", path, `title=Report`, nil, "Content-Type", "text/plain").Code
+	assert.Equal(t, http.StatusUnsupportedMediaType, code)
+
+	// PUT replaces the whole todo, so omitted fields take their defaults
+	code = api.do("PUT", path, `{"description": "No title"}`, nil, "Content-Type", "application/json").Code
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code = api.do("PUT", path, `{"title": "Report v2", "status": "in_progress"}`, &data, "Content-Type", "application/json").Code
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "Report v2", data["title"])
+	assert.Equal(t, "in_progress", data["status"])
+	assert.Equal(t, "none", data["priority"])
+	assert.NotContains(t, data, "due_date")
+	assert.Equal(t, []interface{}{}, data["reminder_offsets"])
+}
+
+func TestTodoETags(t *testing.T) {
+	// The SQL run checks that the ETag of a todo just written matches the
+	// one read back, whatever precision and time zone the database keeps
+	forEachBackend(t, testTodoETags)
+}
+
+func testTodoETags(t *testing.T, repos repository.Repositories) {
+	handler := handlers.NewTodoHandler(repos.Todos, repos.Tags, repos.Projects, repos.Series)
+	router := newTestRouter()
+	router.POST("/todos", handler.Create)
+	router.GET("/todos/:id", handler.GetByID)
+	router.PUT("/todos/:id", handler.Update)
+	router.PATCH("/todos/:id", handler.Patch)
+	router.DELETE("/todos/:id", handler.Delete)
+	api := testClient{t, router, createTestUser(t, repos, "alice")}
+
+	var created models.TodoResponse
+	w := api.do("POST", "/todos", `{"title": "Shared list", "due_date": "2026-03-02T09:00:00.123456789+02:00"}`, &created)
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.EqualValues(t, 1, created.Version)
+	path := fmt.Sprintf("/todos/%d", created.ID)
+	etag := w.Header().Get("ETag")
+	assert.Regexp(t, `^"1-[0-9a-f]+"$`, etag)
+
+	// The ETag of a write is the one later reads return
+	w = api.do("GET", path, nil, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, etag, w.Header().Get("ETag"))
+	w = api.do("PATCH", path, `{"due_date": "2026-03-03T09:00:00.987654321-05:00"}`, nil, "If-Match", etag)
+	require.Equal(t, http.StatusOK, w.Code)
+	etag = w.Header().Get("ETag")
+	w = api.do("PATCH", path, `{"status": "completed"}`, nil, "If-Match", etag)
+	require.Equal(t, http.StatusOK, w.Code)
+	etag = w.Header().Get("ETag")
+
+	// A cached copy that is still current is not sent again
+	w = api.do("GET", path, nil, nil, "If-None-Match", `"0-stale", W/`+etag)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.Equal(t, etag, w.Header().Get("ETag"))
+
+	// The phone updates first, so the laptop's edit based on the same ETag fails
+	w = api.do("PATCH", path, `{"title": "Edited on the phone"}`, nil, "If-Match", etag)
+	require.Equal(t, http.StatusOK, w.Code)
+	newETag := w.Header().Get("ETag")
+	assert.Regexp(t, `^"4-`, newETag)
+
+	w = api.do("PUT", path, `{"title": "Edited on the laptop"}`, nil, "If-Match", etag)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	w = api.do("DELETE", path, nil, nil, "If-Match", etag)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+	w = api.do("GET", path, nil, nil, "If-None-Match", etag)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Edited on the phone")
+
+	// Without If-Match, or with *, writes are unconditional
+	w = api.do("PATCH", path, `{"description": "last write wins"}`, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = api.do("DELETE", path, nil, nil, "If-Match", "*")
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,130 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/go-todo-api/internal/handlers"
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository"
+)
+
+func TestTodoTrash(t *testing.T) {
+	forEachBackend(t, testTodoTrash)
+}
+
+func testTodoTrash(t *testing.T, repos repository.Repositories) {
+	handler := handlers.NewTodoHandler(repos.Todos, repos.Tags, repos.Projects, repos.Series)
+
+	router := newTestRouter()
+	router.GET("/todos", handler.GetAll)
+	router.GET("/todos/trash", handler.Trash)
+	router.GET("/todos/:id", handler.GetByID)
+	router.POST("/todos", handler.Create)
+	router.DELETE("/todos/:id", handler.Delete)
+	router.POST("/todos/:id/restore", handler.Restore)
+	api := testClient{t, router, createTestUser(t, repos, "alice")}
+
+	trash := func() []models.TodoResponse {
+		var todos []models.TodoResponse
+		require.Equal(t, http.StatusOK, api.do("GET", "/todos/trash", nil, &todos).Code)
+		return todos
+	}
+
+	var todo models.TodoResponse
+	require.Equal(t, http.StatusCreated, api.do("POST", "/todos", map[string]interface{}{"title": "Keep me"}, &todo).Code)
+	path := fmt.Sprintf("/todos/%d", todo.ID)
+	assert.Empty(t, trash())
+
+	// Deleting moves the todo to the trash
+	require.Equal(t, http.StatusOK, api.do("DELETE", path, nil, nil).Code)
+	assert.Equal(t, http.StatusNotFound, api.do("GET", path, nil, nil).Code)
+	deleted := trash()
+	require.Len(t, deleted, 1)
+	assert.Equal(t, "Keep me", deleted[0].Title)
+	assert.NotNil(t, deleted[0].DeletedAt)
+
+	// Restoring brings it back under a new version
+	w := api.do("POST", path+"/restore", nil, &todo)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.EqualValues(t, 2, todo.Version)
+	assert.Nil(t, todo.DeletedAt)
+	assert.NotEmpty(t, w.Header().Get("ETag"))
+	assert.Equal(t, http.StatusOK, api.do("GET", path, nil, nil).Code)
+	assert.Empty(t, trash())
+	assert.Equal(t, http.StatusNotFound, api.do("POST", path+"/restore", nil, nil).Code)
+
+	// A permanent deletion empties it from the trash too
+	require.Equal(t, http.StatusOK, api.do("DELETE", path, nil, nil).Code)
+	assert.Equal(t, http.StatusBadRequest, api.do("DELETE", path+"?permanent=yes", nil, nil).Code)
+	require.Equal(t, http.StatusOK, api.do("DELETE", path+"?permanent=true", nil, nil).Code)
+	assert.Empty(t, trash())
+	assert.Equal(t, http.StatusNotFound, api.do("POST", path+"/restore", nil, nil).Code)
+	assert.Equal(t, http.StatusNotFound, api.do("DELETE", path+"?permanent=true", nil, nil).Code)
+
+	// Or skips the trash altogether
+	require.Equal(t, http.StatusCreated, api.do("POST", "/todos", map[string]interface{}{"title": "Gone"}, &todo).Code)
+	path = fmt.Sprintf("/todos/%d", todo.ID)
+	require.Equal(t, http.StatusOK, api.do("DELETE", path+"?permanent=true", nil, nil).Code)
+	assert.Empty(t, trash())
+	assert.Equal(t, http.StatusNotFound, api.do("GET", path, nil, nil).Code)
+}
+
+func TestProjectTrash(t *testing.T) {
+	forEachBackend(t, testProjectTrash)
+}
+
+func testProjectTrash(t *testing.T, repos repository.Repositories) {
+	handler := handlers.NewTodoHandler(repos.Todos, repos.Tags, repos.Projects, repos.Series)
+	alice, bob, carol := createTestUser(t, repos, "alice"), createTestUser(t, repos, "bob"), createTestUser(t, repos, "carol")
+
+	work := &models.Project{UserID: alice.ID, Name: "Work"}
+	require.NoError(t, repos.Projects.Create(work))
+	require.NoError(t, repos.Projects.AddMember(&models.ProjectMember{ProjectID: work.ID, UserID: bob.ID, Role: models.RoleEditor}))
+	require.NoError(t, repos.Projects.AddMember(&models.ProjectMember{ProjectID: work.ID, UserID: carol.ID, Role: models.RoleViewer}))
+	report := &models.Todo{Title: "Write report", UserID: bob.ID, ProjectID: &work.ID}
+	require.NoError(t, repos.Todos.Create(report))
+	path := fmt.Sprintf("/todos/%d", report.ID)
+
+	router := newTestRouter()
+	router.GET("/todos/trash", handler.Trash)
+	router.DELETE("/todos/:id", handler.Delete)
+	router.POST("/todos/:id/restore", handler.Restore)
+	api := testClient{t: t, router: router}
+
+	trash := func(user models.User) []string {
+		var todos []models.TodoResponse
+		require.Equal(t, http.StatusOK, api.as(user).do("GET", "/todos/trash", nil, &todos).Code)
+		var titles []string
+		for _, todo := range todos {
+			titles = append(titles, todo.Title)
+		}
+		return titles
+	}
+
+	// A todo deleted from a project is in the trash of everyone in it
+	require.Equal(t, http.StatusOK, api.as(bob).do("DELETE", path, nil, nil).Code)
+	for _, user := range []models.User{alice, bob, carol} {
+		assert.Equal(t, []string{"Write report"}, trash(user), user.Name)
+	}
+
+	// Its owner and editors may restore it, whoever deleted it
+	assert.Equal(t, http.StatusForbidden, api.as(carol).do("POST", path+"/restore", nil, nil).Code)
+	require.Equal(t, http.StatusOK, api.as(alice).do("POST", path+"/restore", nil, nil).Code)
+	assert.Empty(t, trash(bob))
+	restored, err := repos.Todos.FindByID(report.ID)
+	require.NoError(t, err)
+	assert.Equal(t, bob.ID, restored.UserID)
+
+	// Only the project's owner may empty it from the trash
+	require.Equal(t, http.StatusOK, api.as(bob).do("DELETE", path, nil, nil).Code)
+	assert.Equal(t, http.StatusForbidden, api.as(bob).do("DELETE", path+"?permanent=true", nil, nil).Code)
+	assert.Equal(t, http.StatusForbidden, api.as(carol).do("DELETE", path+"?permanent=true", nil, nil).Code)
+	require.Equal(t, http.StatusOK, api.as(alice).do("DELETE", path+"?permanent=true", nil, nil).Code)
+	assert.Empty(t, trash(alice))
+	assert.Empty(t, trash(bob))
+	assert.Equal(t, http.StatusNotFound, api.as(alice).do("POST", path+"/restore", nil, nil).Code)
+}
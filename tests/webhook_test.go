@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/go-todo-api/internal/events"
+	"github.com/user/go-todo-api/internal/handlers"
+	"github.com/user/go-todo-api/internal/models"
+	"github.com/user/go-todo-api/internal/repository"
+)
+
+func TestWebhooks(t *testing.T) {
+	forEachBackend(t, testWebhooks)
+}
+
+func testWebhooks(t *testing.T, repos repository.Repositories) {
+	handler := handlers.NewWebhookHandler(repos.Webhooks)
+	router := newTestRouter()
+	router.GET("/webhooks", handler.GetAll)
+	router.POST("/webhooks", handler.Create)
+	router.PUT("/webhooks/:id", handler.Update)
+	router.DELETE("/webhooks/:id", handler.Delete)
+	router.GET("/webhooks/:id/deliveries", handler.Deliveries)
+	router.POST("/webhooks/:id/test", handler.Test)
+	api := testClient{t, router, createTestUser(t, repos, "alice")}
+
+	for _, bad := range []map[string]interface{}{
+		{"url": "not a url"},
+		{"url": "ftp://example.com/hook"},
+		{"url": "http://localhost:8080/hook"},
+		{"url": "http://127.0.0.1/hook"},
+		{"url": "http://10.0.0.5/hook"},
+		{"url": "http://169.254.169.254/latest/meta-data"},
+		{"url": "http://[::1]/hook"},
+		{"url": "https://example.com/hook", "events": []string{"todo.exploded"}},
+		{"url": "https://example.com/hook", "secret": "short"},
+	} {
+		assert.Equal(t, http.StatusBadRequest, api.do("POST", "/webhooks", bad, nil).Code, bad)
+	}
+
+	// The secret is generated and shown once; no events means all of them
+	var hook map[string]interface{}
+	require.Equal(t, http.StatusCreated, api.do("POST", "/webhooks", map[string]interface{}{"url": "https://example.com/hook"}, &hook).Code)
+	assert.Regexp(t, "^whsec_[0-9a-f]{48}$", hook["secret"])
+	assert.Len(t, hook["events"], len(models.TodoEvents))
+	assert.Equal(t, true, hook["active"])
+	path := fmt.Sprintf("/webhooks/%v", hook["id"])
+
+	w := api.do("PUT", path, map[string]interface{}{"events": []string{"todo.completed"}, "active": false}, &hook)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []interface{}{"todo.completed"}, hook["events"])
+	assert.Equal(t, false, hook["active"])
+	assert.NotContains(t, hook, "secret")
+	assert.Equal(t, http.StatusBadRequest, api.do("PUT", path, map[string]interface{}{"url": "http://192.168.0.10/hook"}, nil).Code)
+
+	var hooks []interface{}
+	assert.Equal(t, http.StatusOK, api.do("GET", "/webhooks", nil, &hooks).Code)
+	assert.Len(t, hooks, 1)
+
+	var sent map[string]interface{}
+	assert.Equal(t, http.StatusAccepted, api.do("POST", path+"/test", nil, &sent).Code)
+	assert.Regexp(t, "^evt_", sent["event_id"])
+
+	var deliveries []interface{}
+	assert.Equal(t, http.StatusOK, api.do("GET", path+"/deliveries", nil, &deliveries).Code)
+	assert.Equal(t, []interface{}{}, deliveries)
+	assert.Equal(t, http.StatusBadRequest, api.do("GET", path+"/deliveries?limit=1000", nil, nil).Code)
+
+	assert.Equal(t, http.StatusOK, api.do("DELETE", path, nil, nil).Code)
+	assert.Equal(t, http.StatusNotFound, api.do("POST", path+"/test", nil, nil).Code)
+}
+
+func TestTodoChangesPublishEvents(t *testing.T) {
+	forEachBackend(t, testTodoChangesPublishEvents)
+}
+
+func testTodoChangesPublishEvents(t *testing.T, repos repository.Repositories) {
+	user := createTestUser(t, repos, "alice")
+	var published []string
+	unsubscribe := events.Default.Subscribe(func(e events.Event) {
+		if e.UserID == user.ID {
+			published = append(published, e.Type)
+		}
+	})
+	defer unsubscribe()
+
+	handler := handlers.NewTodoHandler(repos.Todos, repos.Tags, repos.Projects, repos.Series)
+	router := newTestRouter()
+	router.POST("/todos", handler.Create)
+	router.PATCH("/todos/:id", handler.Patch)
+	router.DELETE("/todos/:id", handler.Delete)
+	api := testClient{t, router, user}
+
+	var todo models.TodoResponse
+	require.Equal(t, http.StatusCreated, api.do("POST", "/todos", map[string]interface{}{"title": "Publish me"}, &todo).Code)
+	path := fmt.Sprintf("/todos/%d", todo.ID)
+	require.Equal(t, http.StatusOK, api.do("PATCH", path, map[string]interface{}{"status": "completed"}, nil).Code)
+	require.Equal(t, http.StatusOK, api.do("PATCH", path, map[string]interface{}{"title": "Still done"}, nil).Code)
+	require.Equal(t, http.StatusOK, api.do("DELETE", path, nil, nil).Code)
+
+	// Completion is announced once, when the status changes
+	assert.Equal(t, []string{
+		models.EventTodoCreated,
+		models.EventTodoUpdated, models.EventTodoCompleted,
+		models.EventTodoUpdated,
+		models.EventTodoDeleted,
+	}, published)
+}
@@ -3,6 +3,11 @@ export interface Todo {
     title: string;
     description: string;
     status: 'pending' | 'in_progress' | 'completed';
+    archived: boolean;
+    pinned: boolean;
+    project_id?: number;
+    assignee_id?: number;
+    version: number;
     created_at: string;
     updated_at: string;
 }
@@ -16,3 +21,14 @@ export interface TodoListResponse {
         total_pages: number;
     };
 }
+
+export type TodoEventType = 'todo.created' | 'todo.updated' | 'todo.completed' | 'todo.deleted' | 'todo.restored';
+
+// A change pushed by GET /api/stream; 'reset' means events were missed
+// and the list must be refetched
+export interface TodoEvent {
+    id: string;
+    type: TodoEventType | 'reset';
+    created_at: string;
+    data: Todo;
+}
@@ -1,10 +1,10 @@
-import { Component, OnInit, signal } from '@angular/core';
+import { Component, OnDestroy, OnInit, signal } from '@angular/core';
 import { CommonModule } from '@angular/common';
 import { FormBuilder, FormGroup, ReactiveFormsModule, Validators } from '@angular/forms';
-import { Observable } from 'rxjs';
+import { Observable, Subscription } from 'rxjs';
 import { TodoService } from '../../services/todo';
 import { AuthService } from '../../services/auth';
-import { Todo } from '../../models/todo.model';
+import { Todo, TodoEvent } from '../../models/todo.model';
 import { User } from '../../models/user.model';
 
 @Component({
@@ -14,13 +14,14 @@ import { User } from '../../models/user.model';
   templateUrl: './dashboard.html',
   styleUrl: './dashboard.css',
 })
-export class Dashboard implements OnInit {
+export class Dashboard implements OnInit, OnDestroy {
   todos = signal<Todo[]>([]);
   isLoading = signal<boolean>(false);
   showAddForm = signal<boolean>(false);
 
   todoForm: FormGroup;
   currentUser: Observable<User | null>;
+  private changes?: Subscription;
 
   constructor(
     private todoService: TodoService,
@@ -37,6 +38,37 @@ export class Dashboard implements OnInit {
 
   ngOnInit() {
     this.loadTodos();
+
+    // Apply changes made from other tabs and devices as they happen
+    const token = this.authService.getToken();
+    if (token) {
+      this.changes = this.todoService.changes(token).subscribe(event => this.applyChange(event));
+    }
+  }
+
+  ngOnDestroy() {
+    this.changes?.unsubscribe();
+  }
+
+  private applyChange(event: TodoEvent) {
+    switch (event.type) {
+      case 'reset':
+        this.loadTodos();
+        break;
+      case 'todo.created':
+      case 'todo.restored':
+        this.todos.update(todos =>
+          todos.some(t => t.id === event.data.id) ? todos : [event.data, ...todos]
+        );
+        break;
+      case 'todo.updated':
+      case 'todo.completed':
+        this.todos.update(todos => todos.map(t => t.id === event.data.id ? event.data : t));
+        break;
+      case 'todo.deleted':
+        this.todos.update(todos => todos.filter(t => t.id !== event.data.id));
+        break;
+    }
   }
 
   loadTodos() {
@@ -1,7 +1,7 @@
 import { Injectable } from '@angular/core';
-import { HttpClient, HttpParams } from '@angular/common/http';
+import { HttpClient, HttpHeaders, HttpParams } from '@angular/common/http';
 import { Observable } from 'rxjs';
-import { Todo, TodoListResponse } from '../models/todo.model';
+import { Todo, TodoEvent, TodoEventType, TodoListResponse } from '../models/todo.model';
 import { ApiResponse } from '../models/api-response.model';
 
 @Injectable({
@@ -9,6 +9,7 @@ import { ApiResponse } from '../models/api-response.model';
 })
 export class TodoService {
   private apiUrl = 'http://localhost:8080/api/todos';
+  private streamUrl = 'http://localhost:8080/api/stream';
 
   constructor(private http: HttpClient) { }
 
@@ -28,12 +29,29 @@ export class TodoService {
     return this.http.post<ApiResponse<Todo>>(this.apiUrl, todo);
   }
 
-  update(id: number, todo: Partial<Todo>): Observable<ApiResponse<Todo>> {
-    return this.http.put<ApiResponse<Todo>>(`${this.apiUrl}/${id}`, todo);
+  // update changes only the given fields; null clears one
+  update(id: number, changes: Partial<Todo>): Observable<ApiResponse<Todo>> {
+    const headers = new HttpHeaders({ 'Content-Type': 'application/merge-patch+json' });
+    return this.http.patch<ApiResponse<Todo>>(`${this.apiUrl}/${id}`, changes, { headers });
   }
 
   delete(id: number): Observable<ApiResponse<void>> {
     return this.http.delete<ApiResponse<void>>(`${this.apiUrl}/${id}`);
   }
-}
 
+  // changes streams the user's todo events. EventSource reconnects by
+  // itself and resumes from the last event it received.
+  changes(token: string): Observable<TodoEvent> {
+    return new Observable<TodoEvent>(subscriber => {
+      const source = new EventSource(`${this.streamUrl}?access_token=${encodeURIComponent(token)}`);
+      const types: (TodoEventType | 'reset')[] = ['todo.created', 'todo.updated', 'todo.completed', 'todo.deleted', 'todo.restored', 'reset'];
+      for (const type of types) {
+        source.addEventListener(type, event => {
+          const data = (event as MessageEvent).data;
+          subscriber.next(type === 'reset' ? { type } as TodoEvent : JSON.parse(data));
+        });
+      }
+      return () => source.close();
+    });
+  }
+}